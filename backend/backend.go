@@ -16,6 +16,12 @@
 package backend
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/logger"
 	"github.com/rs/zerolog"
@@ -60,6 +66,54 @@ type ProverConfig struct {
 	Force         bool                      // defaults to false
 	HintFunctions map[hint.ID]hint.Function // defaults to all built-in hint functions
 	CircuitLogger zerolog.Logger            // defaults to gnark.Logger
+
+	CompressedProofOutput bool // defaults to false
+
+	SolutionChecksum *uint64 // defaults to nil, no check
+
+	ReverseCheckOrder bool // defaults to false
+	SerialHints       bool // defaults to false
+
+	Profile *ProverProfile // defaults to nil, no profiling
+
+	NoSolvePanic bool // defaults to false
+
+	SolveTrace io.Writer // defaults to nil, no trace
+
+	ProverParallelism int // defaults to 0, all CPUs
+
+	HintOutputValidation bool // defaults to false
+
+	FFTStrategy FFTStrategy // defaults to FFTStrategyDIF
+
+	ValueBound         *big.Int                                   // defaults to nil, no check
+	ValueBoundCallback func(constraintID, wireID int, v *big.Int) // see WithValueBoundCheck
+
+	SolverTasks int // defaults to 0, adaptive
+
+	Checkpoints map[string]int // defaults to nil, no checkpoint
+}
+
+// FFTStrategy selects the decimations of the FFTs computing the quotient polynomial H in the
+// Groth16 prover, see WithFFTStrategy.
+type FFTStrategy uint8
+
+const (
+	// FFTStrategyDIF computes the inverse FFTs with decimation in frequency and the forward
+	// FFTs with decimation in time; this is the default.
+	FFTStrategyDIF FFTStrategy = iota
+	// FFTStrategyDIT computes the inverse FFTs with decimation in time and the forward FFTs
+	// with decimation in frequency, bit-reversing the inputs and the output.
+	FFTStrategyDIT
+)
+
+// ProverProfile holds the durations of the phases of a Groth16 Prove call, see WithProfiling.
+// The multi-exponentiations run concurrently, so the durations don't add up to the total.
+type ProverProfile struct {
+	Solve time.Duration // solving the constraint system
+	H     time.Duration // computing the quotient polynomial H with FFTs
+	MSMG1 time.Duration // G1 multi-exponentiations, from the first one started to the last one done
+	MSMG2 time.Duration // G2 multi-exponentiation
 }
 
 // NewProverConfig returns a default ProverConfig with given prover options opts
@@ -117,3 +171,219 @@ func WithCircuitLogger(l zerolog.Logger) ProverOption {
 		return nil
 	}
 }
+
+// WithCompressedProofOutput is a prover option that marks the generated proof such that
+// its MarshalBinary method outputs points in compressed form. Compressed proofs are half
+// the size of uncompressed ones, which matters when the proof is sent on-chain, but are
+// more expensive to decode.
+func WithCompressedProofOutput() ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.CompressedProofOutput = true
+		return nil
+	}
+}
+
+// WithSolutionChecksum is a prover option that sets the expected checksum of the solution
+// given to ProveSolved, for it to fail if the solution was corrupted since it was computed.
+// Only matters for the PLONK ProveSolved methods, see their ChecksumSolution.
+func WithSolutionChecksum(checksum uint64) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.SolutionChecksum = &checksum
+		return nil
+	}
+}
+
+// WithReverseCheckOrder is a prover option that makes the PLONK constraint solver first
+// solve all the constraints sequentially, and only then check them, in descending index
+// order. A constraint system which solves with this option but not without (or vice versa)
+// relies on the order in which constraints are solved, which hints at a compiler bug.
+//
+// This is a debugging tool for circuit compiler developers: it disables parallel solving
+// and must not be used in production.
+func WithReverseCheckOrder() ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.ReverseCheckOrder = true
+		return nil
+	}
+}
+
+// WithSerialHints is a prover option that makes the constraint solver call the hint
+// functions one at a time. By default, hints of independent constraints are called
+// concurrently from several goroutines; this option is needed by hint functions which
+// are not safe for concurrent use, for example if they mutate shared state.
+func WithSerialHints() ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.SerialHints = true
+		return nil
+	}
+}
+
+// WithProfiling is a prover option that makes the Groth16 prover record the duration of its
+// phases in profile, once Prove returns; see groth16.ProveWithProfile.
+func WithProfiling(profile *ProverProfile) ProverOption {
+	return func(opt *ProverConfig) error {
+		if profile == nil {
+			return errors.New("nil prover profile")
+		}
+		opt.Profile = profile
+		return nil
+	}
+}
+
+// WithNoSolvePanic is a prover option that makes the constraint solver return an error
+// instead of panicking when, after solving all the constraints, some wires were left
+// uninstantiated. This can only happen with a malformed constraint system; by default the
+// solver panics, which is louder during development but crashes a server process.
+func WithNoSolvePanic() ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.NoSolvePanic = true
+		return nil
+	}
+}
+
+// WithSolveTrace is a prover option that makes the constraint solver write to w, once done,
+// a trace of the solving of each level of the constraint system (see constraint.System.Levels)
+// in the Chrome trace event format, with the number of constraints of each level. The trace
+// can be loaded in chrome://tracing or https://ui.perfetto.dev to spot slow levels.
+//
+// Only the levels solved up to the first unsatisfied constraint are traced. The option is
+// ignored with WithReverseCheckOrder.
+func WithSolveTrace(w io.Writer) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.SolveTrace = w
+		return nil
+	}
+}
+
+// WithProverParallelism is a prover option that bounds to n the number of tasks each
+// multi-exponentiation and parallel loop of the Groth16 prover is split in, instead of the
+// number of CPUs, to limit the CPU usage on shared machines. It doesn't apply to the
+// constraint solver, nor to the FFTs, of which gnark-crypto doesn't expose the parallelism.
+func WithProverParallelism(n int) ProverOption {
+	return func(opt *ProverConfig) error {
+		if n <= 0 {
+			return fmt.Errorf("prover parallelism must be positive, got %d", n)
+		}
+		opt.ProverParallelism = n
+		return nil
+	}
+}
+
+// WithHintOutputValidation is a prover option that makes the constraint solver return an
+// error when a hint function returns a value which is not reduced modulo the field modulus,
+// that is, negative or larger than the modulus. By default such values are silently
+// reduced, which may hide a bug in the hint function.
+func WithHintOutputValidation() ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.HintOutputValidation = true
+		return nil
+	}
+}
+
+// WithFFTStrategy is a prover option that pins the decimations of the FFTs computing the
+// quotient polynomial H in the Groth16 prover. Both strategies compute the same H, through
+// different intermediate orderings; this is meant to bisect proof regressions.
+func WithFFTStrategy(strategy FFTStrategy) ProverOption {
+	return func(opt *ProverConfig) error {
+		if strategy != FFTStrategyDIF && strategy != FFTStrategyDIT {
+			return fmt.Errorf("unknown fft strategy %d", strategy)
+		}
+		opt.FFTStrategy = strategy
+		return nil
+	}
+}
+
+// WithValueBoundCheck is a prover option that makes the PLONK constraint solver call cb for
+// each wire it solves of which the value, as an integer in [0, r), exceeds max, with the ID of
+// the constraint solving it. This surfaces unintended large intermediate values, e.g. a hint
+// output wrapping around the modulus.
+//
+// cb may be called concurrently, from several goroutines. The constraints are solved level
+// by level, so the reported constraint of the lowest level is the first to introduce such a
+// value. The option is ignored by the R1CS solver.
+func WithValueBoundCheck(max *big.Int, cb func(constraintID, wireID int, v *big.Int)) ProverOption {
+	return func(opt *ProverConfig) error {
+		if max == nil || max.Sign() < 0 {
+			return errors.New("value bound must be a non-negative integer")
+		}
+		if cb == nil {
+			return errors.New("value bound callback must be set")
+		}
+		opt.ValueBound = new(big.Int).Set(max)
+		opt.ValueBoundCallback = cb
+		return nil
+	}
+}
+
+// WithSolverTasks is a prover option that pins to n the number of tasks the PLONK constraint
+// solver splits each level of constraints in (or the number of constraints of the level, if
+// lower), with boundaries depending only on the number of constraints. By default, the number
+// of tasks and their boundaries adapt to the number of CPUs and to the estimated cost of the
+// constraints; with n == 1, the levels are solved sequentially.
+//
+// This is meant for benchmarks, for the work distribution to be the same across runs and
+// machines: only the scheduling of the tasks varies.
+func WithSolverTasks(n int) ProverOption {
+	return func(opt *ProverConfig) error {
+		if n <= 0 {
+			return fmt.Errorf("solver tasks must be positive, got %d", n)
+		}
+		opt.SolverTasks = n
+		return nil
+	}
+}
+
+// WithCheckpoints is a prover option that names wires of the solution, by their wire ID, as
+// checkpoints, to check the intermediate values of multi-stage circuits during development.
+// The SolveWithCheckpoints method of the R1CS and SparseR1CS of each curve returns their
+// solved values by name, along with the solution. The constraint solver rejects a wire ID
+// out of the system before solving.
+func WithCheckpoints(checkpoints map[string]int) ProverOption {
+	return func(opt *ProverConfig) error {
+		for name, wID := range checkpoints {
+			if wID < 0 {
+				return fmt.Errorf("checkpoint %q: negative wire ID %d", name, wID)
+			}
+		}
+		opt.Checkpoints = checkpoints
+		return nil
+	}
+}
+
+// SetupOption defines option for altering the behaviour of the Setup algorithm
+// of a proof system. See the descriptions of functions returning instances of
+// this type for implemented options.
+type SetupOption func(*SetupConfig) error
+
+// SetupConfig is the configuration for the setup with the options applied.
+type SetupConfig struct {
+	// QuotientDomainMultiplier is the ratio between the sizes of the big (quotient)
+	// and small FFT domains in PLONK. Defaults to 0, for the setup to choose it.
+	QuotientDomainMultiplier uint64
+}
+
+// NewSetupConfig returns a default SetupConfig with given setup options opts
+// applied.
+func NewSetupConfig(opts ...SetupOption) (SetupConfig, error) {
+	var opt SetupConfig
+	for _, option := range opts {
+		if err := option(&opt); err != nil {
+			return SetupConfig{}, err
+		}
+	}
+	return opt, nil
+}
+
+// WithQuotientDomainMultiplier is a PLONK setup option that sets the ratio between the
+// sizes of the big FFT domain, on which the quotient polynomial is computed, and the
+// small one. k must be a power of two, and at least the default ratio (4, or 8 for
+// systems with less than 6 constraints); this is checked by Setup.
+func WithQuotientDomainMultiplier(k uint64) SetupOption {
+	return func(opt *SetupConfig) error {
+		if k == 0 || k&(k-1) != 0 {
+			return fmt.Errorf("quotient domain multiplier must be a power of two, got %d", k)
+		}
+		opt.QuotientDomainMultiplier = k
+		return nil
+	}
+}
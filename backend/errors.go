@@ -0,0 +1,27 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import "fmt"
+
+// ErrSRSTooSmall is returned when the SRS doesn't have enough points for the circuit.
+// Required is the minimum number of G1 points, Got the number of G1 points of the SRS.
+type ErrSRSTooSmall struct {
+	Required, Got int
+}
+
+func (e ErrSRSTooSmall) Error() string {
+	return fmt.Sprintf("kzg srs is too small: got %d points, need at least %d", e.Got, e.Required)
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groth16
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/blang/semver/v4"
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// archiveMagic starts the archives written by WriteArchive
+var archiveMagic = [8]byte{'g', 'n', 'a', 'r', 'k', 'g', '1', '6'}
+
+// archiveFormat is the version of the layout of the archives written by WriteArchive
+const archiveFormat uint16 = 1
+
+// ErrArchiveDigest is returned by ReadArchive when the digest of an archive doesn't match
+// its content.
+var ErrArchiveDigest = errors.New("archive digest mismatch")
+
+// WriteArchive writes to w the constraint system ccs and its key pair pk, vk in a single
+// archive, to ship them together, see ReadArchive. The archive is made of a header (magic
+// bytes, archive format, curve ID, gnark version and the lengths of the three sections),
+// the sections as written by the WriteTo methods, and a SHA-256 digest of all the above.
+//
+// It returns an error if the three objects are not on the same curve, or if pk and vk
+// don't match (see MatchingKeys).
+func WriteArchive(w io.Writer, ccs constraint.ConstraintSystem, pk ProvingKey, vk VerifyingKey) error {
+	curve := utils.FieldToCurve(ccs.Field())
+	if pk.CurveID() != curve || vk.CurveID() != curve {
+		return fmt.Errorf("curve mismatch: constraint system on %s, proving key on %s, verifying key on %s", curve, pk.CurveID(), vk.CurveID())
+	}
+	if !MatchingKeys(pk, vk) {
+		return errors.New("proving and verifying keys don't match")
+	}
+
+	var sections [3]bytes.Buffer
+	for i, o := range [3]io.WriterTo{ccs, pk, vk} {
+		if _, err := o.WriteTo(&sections[i]); err != nil {
+			return err
+		}
+	}
+
+	h := sha256.New()
+	mw := io.MultiWriter(w, h)
+	version := gnark.Version.String()
+	header := []interface{}{archiveMagic, archiveFormat, uint16(curve), uint16(len(version)), []byte(version)}
+	for i := range sections {
+		header = append(header, uint64(sections[i].Len()))
+	}
+	for _, v := range header {
+		if err := binary.Write(mw, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	for i := range sections {
+		if _, err := sections[i].WriteTo(mw); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(h.Sum(nil))
+	return err
+}
+
+// ReadArchive reads an archive written by WriteArchive and returns the constraint system
+// and key pair it holds. The digest is checked before decoding the sections, and
+// ErrArchiveDigest is returned if it doesn't match. Archives written by a more recent
+// gnark version are rejected.
+func ReadArchive(r io.Reader) (constraint.ConstraintSystem, ProvingKey, VerifyingKey, error) {
+	h := sha256.New()
+	tr := io.TeeReader(r, h)
+
+	var magic [8]byte
+	var format, curve, versionLen uint16
+	for _, v := range []interface{}{&magic, &format, &curve, &versionLen} {
+		if err := binary.Read(tr, binary.BigEndian, v); err != nil {
+			return nil, nil, nil, fmt.Errorf("read archive header: %w", err)
+		}
+	}
+	if magic != archiveMagic {
+		return nil, nil, nil, errors.New("not a groth16 archive")
+	}
+	if format != archiveFormat {
+		return nil, nil, nil, fmt.Errorf("unsupported archive format %d", format)
+	}
+	version := make([]byte, versionLen)
+	if _, err := io.ReadFull(tr, version); err != nil {
+		return nil, nil, nil, fmt.Errorf("read archive header: %w", err)
+	}
+	v, err := semver.Parse(string(version))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid gnark version %q: %w", version, err)
+	}
+	if v.GT(gnark.Version) {
+		return nil, nil, nil, fmt.Errorf("archive written by gnark %s, more recent than %s", v, gnark.Version)
+	}
+	var lengths [3]uint64
+	if err := binary.Read(tr, binary.BigEndian, &lengths); err != nil {
+		return nil, nil, nil, fmt.Errorf("read archive header: %w", err)
+	}
+
+	// the lengths are not trusted until the digest is checked: the sections grow as
+	// they're read rather than being allocated upfront
+	var sections [3]bytes.Buffer
+	for i := range sections {
+		n, err := sections[i].ReadFrom(io.LimitReader(tr, int64(lengths[i])))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if uint64(n) != lengths[i] {
+			return nil, nil, nil, io.ErrUnexpectedEOF
+		}
+	}
+	digest := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, digest); err != nil {
+		return nil, nil, nil, fmt.Errorf("read archive digest: %w", err)
+	}
+	if !bytes.Equal(digest, h.Sum(nil)) {
+		return nil, nil, nil, ErrArchiveDigest
+	}
+
+	supported := false
+	for _, c := range gnark.Curves() {
+		supported = supported || c == ecc.ID(curve)
+	}
+	if !supported {
+		return nil, nil, nil, fmt.Errorf("unsupported curve %d", curve)
+	}
+	ccs := NewCS(ecc.ID(curve))
+	pk := NewProvingKey(ecc.ID(curve))
+	vk := NewVerifyingKey(ecc.ID(curve))
+	for i, o := range [3]io.ReaderFrom{ccs, pk, vk} {
+		if _, err := o.ReadFrom(&sections[i]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if !MatchingKeys(pk, vk) {
+		return nil, nil, nil, errors.New("proving and verifying keys don't match")
+	}
+	if nbPublic := ccs.GetNbPublicVariables() - 1; nbPublic != vk.NbPublicWitness() {
+		return nil, nil, nil, fmt.Errorf("verifying key for %d public inputs, constraint system has %d", vk.NbPublicWitness(), nbPublic)
+	}
+
+	return ccs, pk, vk, nil
+}
@@ -20,6 +20,9 @@
 package groth16
 
 import (
+	"encoding"
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -33,6 +36,9 @@ import (
 	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
 	cs_bw6633 "github.com/consensys/gnark/constraint/bw6-633"
 	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/internal/utils"
 
 	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
@@ -65,6 +71,12 @@ type groth16Object interface {
 // it's underlying implementation is curve specific (see gnark/internal/backend)
 type Proof interface {
 	groth16Object
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+
+	// IsForced returns true if the proof was generated with backend.IgnoreSolverError
+	// from an invalid witness, in which case it is intentionally invalid
+	IsForced() bool
 }
 
 // ProvingKey represents a Groth16 ProvingKey
@@ -106,6 +118,10 @@ type VerifyingKey interface {
 	ExportSolidity(w io.Writer) error
 
 	IsDifferent(interface{}) bool
+
+	// Precompute caches the pairing e(α, β) and the negated [δ]2, [γ]2 used by Verify;
+	// it is called by Setup and ReadFrom.
+	Precompute() error
 }
 
 // Verify runs the groth16.Verify algorithm on provided proof with given witness
@@ -159,6 +175,121 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 	}
 }
 
+// VerifyRaw is like Verify, but takes the public inputs as a []fr.Element or a fr.Vector of
+// the curve of the proof (e.g. from gnark-crypto/ecc/bn254/fr for a BN254 proof, as returned
+// by witness.Vector()), instead of a witness.Witness.
+func VerifyRaw(proof Proof, vk VerifyingKey, publicInputs any) error {
+	switch _proof := proof.(type) {
+	case *groth16_bls12377.Proof:
+		var w []fr_bls12377.Element
+		switch v := publicInputs.(type) {
+		case []fr_bls12377.Element:
+			w = v
+		case fr_bls12377.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bls12377.Verify(_proof, vk.(*groth16_bls12377.VerifyingKey), w)
+	case *groth16_bls12381.Proof:
+		var w []fr_bls12381.Element
+		switch v := publicInputs.(type) {
+		case []fr_bls12381.Element:
+			w = v
+		case fr_bls12381.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bls12381.Verify(_proof, vk.(*groth16_bls12381.VerifyingKey), w)
+	case *groth16_bn254.Proof:
+		var w []fr_bn254.Element
+		switch v := publicInputs.(type) {
+		case []fr_bn254.Element:
+			w = v
+		case fr_bn254.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bn254.Verify(_proof, vk.(*groth16_bn254.VerifyingKey), w)
+	case *groth16_bw6761.Proof:
+		var w []fr_bw6761.Element
+		switch v := publicInputs.(type) {
+		case []fr_bw6761.Element:
+			w = v
+		case fr_bw6761.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bw6761.Verify(_proof, vk.(*groth16_bw6761.VerifyingKey), w)
+	case *groth16_bls24317.Proof:
+		var w []fr_bls24317.Element
+		switch v := publicInputs.(type) {
+		case []fr_bls24317.Element:
+			w = v
+		case fr_bls24317.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bls24317.Verify(_proof, vk.(*groth16_bls24317.VerifyingKey), w)
+	case *groth16_bls24315.Proof:
+		var w []fr_bls24315.Element
+		switch v := publicInputs.(type) {
+		case []fr_bls24315.Element:
+			w = v
+		case fr_bls24315.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bls24315.Verify(_proof, vk.(*groth16_bls24315.VerifyingKey), w)
+	case *groth16_bw6633.Proof:
+		var w []fr_bw6633.Element
+		switch v := publicInputs.(type) {
+		case []fr_bw6633.Element:
+			w = v
+		case fr_bw6633.Vector:
+			w = v
+		default:
+			return witness.ErrInvalidWitness
+		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
+		return groth16_bw6633.Verify(_proof, vk.(*groth16_bw6633.VerifyingKey), w)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+func checkNbPublicInputs(n int, vk VerifyingKey) error {
+	if n != vk.NbPublicWitness() {
+		return fmt.Errorf("invalid number of public inputs: got %d, expected %d", n, vk.NbPublicWitness())
+	}
+	return nil
+}
+
 // Prove runs the groth16.Prove algorithm.
 //
 // if the force flag is set:
@@ -174,54 +305,124 @@ func Prove(r1cs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.
 		return nil, err
 	}
 
+	return prove(r1cs, pk, fullWitness, opt)
+}
+
+// checkWitnessSize returns an error if a full witness of size n doesn't match the
+// number of public and secret inputs of r1cs.
+func checkWitnessSize(r1cs constraint.ConstraintSystem, n int) error {
+	nbPublic := r1cs.GetNbPublicVariables() - 1 // - 1 for ONE_WIRE
+	nbSecret := r1cs.GetNbSecretVariables()
+	if n != nbPublic+nbSecret {
+		return fmt.Errorf("invalid full witness size for %s R1CS: got %d, expected %d = %d (public) + %d (secret)",
+			utils.FieldToCurve(r1cs.Field()), n, nbPublic+nbSecret, nbPublic, nbSecret)
+	}
+	return nil
+}
+
+// ProveForced is like Prove with the backend.IgnoreSolverError option: it runs all the prover
+// computations even if the witness doesn't solve the system. It additionally returns whether
+// that was the case, i.e. proof.IsForced(), in which case the proof is invalid; this is only
+// meant for tests and benchmarks.
+func ProveForced(r1cs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (proof Proof, forced bool, err error) {
+	proof, err = Prove(r1cs, pk, fullWitness, append(opts, backend.IgnoreSolverError())...)
+	if err != nil {
+		return nil, false, err
+	}
+	return proof, proof.IsForced(), nil
+}
+
+// ProveWithProfile is like Prove, and additionally returns the durations of the prover
+// phases (see backend.WithProfiling).
+func ProveWithProfile(r1cs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (Proof, backend.ProverProfile, error) {
+	var profile backend.ProverProfile
+	proof, err := Prove(r1cs, pk, fullWitness, append(opts, backend.WithProfiling(&profile))...)
+	if err != nil {
+		return nil, backend.ProverProfile{}, err
+	}
+	return proof, profile, nil
+}
+
+func prove(r1cs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.Witness, opt backend.ProverConfig) (Proof, error) {
 	switch _r1cs := r1cs.(type) {
 	case *cs_bls12377.R1CS:
 		w, ok := fullWitness.Vector().(fr_bls12377.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bls12377.Prove(_r1cs, pk.(*groth16_bls12377.ProvingKey), w, opt)
 	case *cs_bls12381.R1CS:
 		w, ok := fullWitness.Vector().(fr_bls12381.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bls12381.Prove(_r1cs, pk.(*groth16_bls12381.ProvingKey), w, opt)
 	case *cs_bn254.R1CS:
 		w, ok := fullWitness.Vector().(fr_bn254.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bn254.Prove(_r1cs, pk.(*groth16_bn254.ProvingKey), w, opt)
 	case *cs_bw6761.R1CS:
 		w, ok := fullWitness.Vector().(fr_bw6761.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bw6761.Prove(_r1cs, pk.(*groth16_bw6761.ProvingKey), w, opt)
 	case *cs_bls24317.R1CS:
 		w, ok := fullWitness.Vector().(fr_bls24317.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bls24317.Prove(_r1cs, pk.(*groth16_bls24317.ProvingKey), w, opt)
 	case *cs_bls24315.R1CS:
 		w, ok := fullWitness.Vector().(fr_bls24315.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bls24315.Prove(_r1cs, pk.(*groth16_bls24315.ProvingKey), w, opt)
 	case *cs_bw6633.R1CS:
 		w, ok := fullWitness.Vector().(fr_bw6633.Vector)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(r1cs, len(w)); err != nil {
+			return nil, err
+		}
 		return groth16_bw6633.Prove(_r1cs, pk.(*groth16_bw6633.ProvingKey), w, opt)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
 }
 
+// SolveAndProve builds the full witness from the circuit assignment and proves it with
+// Prove; it is equivalent to calling frontend.NewWitness then Prove.
+func SolveAndProve(r1cs constraint.ConstraintSystem, pk ProvingKey, assignment frontend.Circuit, opts ...backend.ProverOption) (Proof, error) {
+	fullWitness, err := frontend.NewWitness(assignment, r1cs.Field())
+	if err != nil {
+		return nil, fmt.Errorf("new witness: %w", err)
+	}
+	return Prove(r1cs, pk, fullWitness, opts...)
+}
+
 // Setup runs groth16.Setup with provided R1CS and outputs a key pair associated with the circuit.
 //
 // Note that careful consideration must be given to this step in production environment.
@@ -338,6 +539,134 @@ func DummySetup(r1cs constraint.ConstraintSystem) (ProvingKey, error) {
 	}
 }
 
+// MatchingKeys reports whether pk and vk were generated together by Setup, to catch a
+// key pair mixed up between circuits before proving. Keys of different curves don't match.
+func MatchingKeys(pk ProvingKey, vk VerifyingKey) bool {
+	switch _pk := pk.(type) {
+	case *groth16_bls12377.ProvingKey:
+		_vk, ok := vk.(*groth16_bls12377.VerifyingKey)
+		return ok && groth16_bls12377.MatchingKeys(_pk, _vk)
+	case *groth16_bls12381.ProvingKey:
+		_vk, ok := vk.(*groth16_bls12381.VerifyingKey)
+		return ok && groth16_bls12381.MatchingKeys(_pk, _vk)
+	case *groth16_bn254.ProvingKey:
+		_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+		return ok && groth16_bn254.MatchingKeys(_pk, _vk)
+	case *groth16_bw6761.ProvingKey:
+		_vk, ok := vk.(*groth16_bw6761.VerifyingKey)
+		return ok && groth16_bw6761.MatchingKeys(_pk, _vk)
+	case *groth16_bls24317.ProvingKey:
+		_vk, ok := vk.(*groth16_bls24317.VerifyingKey)
+		return ok && groth16_bls24317.MatchingKeys(_pk, _vk)
+	case *groth16_bls24315.ProvingKey:
+		_vk, ok := vk.(*groth16_bls24315.VerifyingKey)
+		return ok && groth16_bls24315.MatchingKeys(_pk, _vk)
+	case *groth16_bw6633.ProvingKey:
+		_vk, ok := vk.(*groth16_bw6633.VerifyingKey)
+		return ok && groth16_bw6633.MatchingKeys(_pk, _vk)
+	default:
+		panic("unrecognized ProvingKey curve type")
+	}
+}
+
+// ProvingKeySizes returns the number of G1 and G2 elements of the ProvingKey Setup would
+// output for r1cs (see ProvingKey.NbG1 and ProvingKey.NbG2), without running the setup;
+// to size an SRS or plan memory usage.
+func ProvingKeySizes(r1cs constraint.ConstraintSystem) (nbG1, nbG2 int, err error) {
+	switch _r1cs := r1cs.(type) {
+	case *cs_bls12377.R1CS:
+		nbG1, nbG2 = groth16_bls12377.ProvingKeySizes(_r1cs)
+	case *cs_bls12381.R1CS:
+		nbG1, nbG2 = groth16_bls12381.ProvingKeySizes(_r1cs)
+	case *cs_bn254.R1CS:
+		nbG1, nbG2 = groth16_bn254.ProvingKeySizes(_r1cs)
+	case *cs_bw6761.R1CS:
+		nbG1, nbG2 = groth16_bw6761.ProvingKeySizes(_r1cs)
+	case *cs_bls24317.R1CS:
+		nbG1, nbG2 = groth16_bls24317.ProvingKeySizes(_r1cs)
+	case *cs_bls24315.R1CS:
+		nbG1, nbG2 = groth16_bls24315.ProvingKeySizes(_r1cs)
+	case *cs_bw6633.R1CS:
+		nbG1, nbG2 = groth16_bw6633.ProvingKeySizes(_r1cs)
+	default:
+		return 0, 0, fmt.Errorf("unrecognized R1CS curve type: %T", r1cs)
+	}
+	return nbG1, nbG2, nil
+}
+
+// CircuitAnalysis summarizes the size and shape of a R1CS, see Analyze.
+type CircuitAnalysis struct {
+	NbConstraints int // number of constraints, that is, the size of the FFT domain before padding
+
+	NbPublicVariables   int // number of public variables, including the constant ONE_WIRE
+	NbSecretVariables   int // number of secret variables
+	NbInternalVariables int // number of internal variables, including the hint outputs
+
+	NbCoefficients int // number of distinct coefficients
+	NbHints        int // number of hint calls; a hint call may have several outputs
+
+	NbLevels int // number of levels of the solver, that is, the length of the critical path
+}
+
+// Analyze returns a summary of r1cs: its number of constraints, variables, coefficients,
+// hint calls and solver levels.
+func Analyze(r1cs constraint.ConstraintSystem) (CircuitAnalysis, error) {
+	var system *constraint.System
+	switch _r1cs := r1cs.(type) {
+	case *cs_bls12377.R1CS:
+		system = &_r1cs.System
+	case *cs_bls12381.R1CS:
+		system = &_r1cs.System
+	case *cs_bn254.R1CS:
+		system = &_r1cs.System
+	case *cs_bw6761.R1CS:
+		system = &_r1cs.System
+	case *cs_bls24317.R1CS:
+		system = &_r1cs.System
+	case *cs_bls24315.R1CS:
+		system = &_r1cs.System
+	case *cs_bw6633.R1CS:
+		system = &_r1cs.System
+	default:
+		return CircuitAnalysis{}, fmt.Errorf("unrecognized R1CS curve type: %T", r1cs)
+	}
+
+	// the hint outputs share the same *constraint.Hint
+	hints := make(map[*constraint.Hint]struct{}, len(system.MHints))
+	for _, h := range system.MHints {
+		hints[h] = struct{}{}
+	}
+
+	return CircuitAnalysis{
+		NbConstraints:       r1cs.GetNbConstraints(),
+		NbPublicVariables:   r1cs.GetNbPublicVariables(),
+		NbSecretVariables:   r1cs.GetNbSecretVariables(),
+		NbInternalVariables: r1cs.GetNbInternalVariables(),
+		NbCoefficients:      r1cs.GetNbCoefficients(),
+		NbHints:             len(hints),
+		NbLevels:            len(system.Levels),
+	}, nil
+}
+
+// GetSchema returns the schema of the circuit r1cs was compiled from, to build
+// witnesses from JSON matching the circuit's field names.
+//
+// It returns an error if r1cs is not a R1CS, or has no schema (as a constraint system
+// serialized by a version of gnark that didn't store it).
+func GetSchema(r1cs constraint.ConstraintSystem) (*schema.Schema, error) {
+	switch r1cs.(type) {
+	case *cs_bls12377.R1CS, *cs_bls12381.R1CS, *cs_bn254.R1CS, *cs_bw6761.R1CS,
+		*cs_bls24317.R1CS, *cs_bls24315.R1CS, *cs_bw6633.R1CS:
+	default:
+		return nil, fmt.Errorf("unrecognized R1CS curve type: %T", r1cs)
+	}
+	s := r1cs.GetSchema()
+	if s == nil {
+		return nil, errors.New("constraint system has no schema")
+	}
+	return s, nil
+}
+
 // NewProvingKey instantiates a curve-typed ProvingKey and returns an interface object
 // This function exists for serialization purposes
 func NewProvingKey(curveID ecc.ID) ProvingKey {
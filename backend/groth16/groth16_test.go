@@ -1,17 +1,375 @@
 package groth16_test
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"math/big"
 	"testing"
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
 )
 
+func TestCompressedProofOutput(t *testing.T) {
+	for _, curve := range getCurves() {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, fullWitness := smallCircuit(t, curve)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			proof, err := groth16.Prove(ccs, pk, fullWitness, backend.WithCompressedProofOutput())
+			assert.NoError(err)
+
+			var compressed, raw bytes.Buffer
+			_, err = proof.WriteTo(&compressed)
+			assert.NoError(err)
+			_, err = proof.WriteRawTo(&raw)
+			assert.NoError(err)
+
+			data, err := proof.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(compressed.Bytes(), data, "MarshalBinary should output the compressed encoding")
+			assert.Equal(raw.Len(), 2*len(data))
+
+			decoded := groth16.NewProof(curve)
+			assert.NoError(decoded.UnmarshalBinary(data))
+			var decodedRaw bytes.Buffer
+			_, err = decoded.WriteRawTo(&decodedRaw)
+			assert.NoError(err)
+			assert.Equal(raw.Bytes(), decodedRaw.Bytes())
+
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(decoded, vk, publicWitness))
+
+			// without the option, MarshalBinary doesn't compress
+			proof, err = groth16.Prove(ccs, pk, fullWitness)
+			assert.NoError(err)
+			data, err = proof.MarshalBinary()
+			assert.NoError(err)
+			assert.Equal(raw.Len(), len(data))
+		})
+	}
+}
+
+func TestVerifyRaw(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, fullWitness := smallCircuit(t, ecc.BN254)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	publicInputs := []fr.Element(publicWitness.Vector().(fr.Vector))
+	assert.NoError(groth16.VerifyRaw(proof, vk, publicInputs))
+
+	// wrong public input
+	var wrong fr.Element
+	wrong.SetUint64(42)
+	assert.Error(groth16.VerifyRaw(proof, vk, []fr.Element{wrong}))
+
+	// wrong number of public inputs
+	assert.Error(groth16.VerifyRaw(proof, vk, append(publicInputs, wrong)))
+
+	// the vector of a witness is accepted too
+	assert.NoError(groth16.VerifyRaw(proof, vk, publicWitness.Vector()))
+
+	// wrong type
+	assert.ErrorIs(groth16.VerifyRaw(proof, vk, []uint64{42}), witness.ErrInvalidWitness)
+}
+
+// namedCircuit has tagged fields, whose names differ from the Go ones
+type namedCircuit struct {
+	Root   frontend.Variable `gnark:"root"`
+	Square frontend.Variable `gnark:"square,public"`
+}
+
+func (circuit *namedCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.Root, circuit.Root), circuit.Square)
+	return nil
+}
+
+// analyzedCircuit decomposes X in 3 bits with a single hint call and squares it
+type analyzedCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *analyzedCircuit) Define(api frontend.API) error {
+	api.ToBinary(circuit.X, 3)
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestAnalyze(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &analyzedCircuit{})
+	assert.NoError(err)
+
+	analysis, err := groth16.Analyze(ccs)
+	assert.NoError(err)
+	assert.Equal(groth16.CircuitAnalysis{
+		NbConstraints:       6, // 3 boolean constraints, the recomposition of X, X⋅X and the equality
+		NbPublicVariables:   2, // ONE_WIRE and Y
+		NbSecretVariables:   1,
+		NbInternalVariables: 4, // the 3 bits and X⋅X
+		NbCoefficients:      6, // the default 0, 1, 2, -1, -2 and 4 in the recomposition
+		NbHints:             1,
+		NbLevels:            2,
+	}, analysis)
+
+	// not a R1CS
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &analyzedCircuit{})
+	assert.NoError(err)
+	_, err = groth16.Analyze(ccs)
+	assert.Error(err)
+}
+
+func TestGetSchema(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &namedCircuit{})
+	assert.NoError(err)
+
+	// the schema survives the serialization of the constraint system
+	var buf bytes.Buffer
+	_, err = ccs.WriteTo(&buf)
+	assert.NoError(err)
+	decoded := groth16.NewCS(ecc.BN254)
+	_, err = decoded.ReadFrom(&buf)
+	assert.NoError(err)
+
+	s, err := groth16.GetSchema(decoded)
+	assert.NoError(err)
+	assert.Equal(1, s.NbPublic)
+	assert.Equal(1, s.NbSecret)
+
+	fullWitness, err := witness.New(ecc.BN254.ScalarField())
+	assert.NoError(err)
+	assert.NoError(fullWitness.FromJSON(s, []byte(`{"root": 3, "square": 9}`)))
+
+	pk, vk, err := groth16.Setup(decoded)
+	assert.NoError(err)
+	proof, err := groth16.Prove(decoded, pk, fullWitness)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	// not a R1CS
+	sparse, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &namedCircuit{})
+	assert.NoError(err)
+	_, err = groth16.GetSchema(sparse)
+	assert.Error(err)
+}
+
+func TestSolveAndProve(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &namedCircuit{})
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	assignment := &namedCircuit{Root: 3, Square: 9}
+	proof, err := groth16.SolveAndProve(ccs, pk, assignment)
+	assert.NoError(err)
+
+	// same public witness as the two-step path, whose proof verifies as well
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+	manual, err := groth16.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(manual, vk, publicWitness))
+
+	// an unsatisfying or incomplete assignment fails
+	_, err = groth16.SolveAndProve(ccs, pk, &namedCircuit{Root: 3, Square: 10})
+	assert.Error(err)
+	_, err = groth16.SolveAndProve(ccs, pk, &namedCircuit{Square: 9})
+	assert.Error(err)
+}
+
+func TestProveWitnessSize(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &namedCircuit{})
+	assert.NoError(err)
+	pk, _, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	// the public part only, given as a full witness
+	short, err := frontend.NewWitness(&namedCircuit{Root: 3, Square: 9}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	assert.NoError(err)
+	_, err = groth16.Prove(ccs, pk, short)
+	assert.EqualError(err, "invalid full witness size for bn254 R1CS: got 1, expected 2 = 1 (public) + 1 (secret)")
+}
+
+func TestProveForced(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &namedCircuit{})
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	// a valid witness gives a regular proof
+	good, err := frontend.NewWitness(&namedCircuit{Root: 3, Square: 9}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, forced, err := groth16.ProveForced(ccs, pk, good)
+	assert.NoError(err)
+	assert.False(forced)
+	publicWitness, err := good.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	// an invalid one gives a flagged proof which doesn't verify
+	bad, err := frontend.NewWitness(&namedCircuit{Root: 3, Square: 10}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, forced, err = groth16.ProveForced(ccs, pk, bad)
+	assert.NoError(err)
+	assert.True(forced)
+	assert.True(proof.IsForced())
+	publicWitness, err = bad.Public()
+	assert.NoError(err)
+	assert.Error(groth16.Verify(proof, vk, publicWitness))
+}
+
+func TestProveWithProverParallelism(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curve := range getCurves() {
+		ccs, fullWitness := smallCircuit(t, curve)
+		pk, vk, err := groth16.Setup(ccs)
+		assert.NoError(err)
+		publicWitness, err := fullWitness.Public()
+		assert.NoError(err)
+
+		proof, err := groth16.Prove(ccs, pk, fullWitness, backend.WithProverParallelism(1))
+		assert.NoError(err, curve.String())
+		assert.NoError(groth16.Verify(proof, vk, publicWitness), curve.String())
+	}
+
+	_, err := backend.NewProverConfig(backend.WithProverParallelism(0))
+	assert.Error(err)
+}
+
+func TestProveWithFFTStrategy(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curve := range getCurves() {
+		ccs, fullWitness := smallCircuit(t, curve)
+		pk, vk, err := groth16.Setup(ccs)
+		assert.NoError(err)
+		publicWitness, err := fullWitness.Public()
+		assert.NoError(err)
+
+		for _, strategy := range []backend.FFTStrategy{backend.FFTStrategyDIF, backend.FFTStrategyDIT} {
+			proof, err := groth16.Prove(ccs, pk, fullWitness, backend.WithFFTStrategy(strategy))
+			assert.NoError(err, "%s: strategy %d", curve, strategy)
+			assert.NoError(groth16.Verify(proof, vk, publicWitness), "%s: strategy %d", curve, strategy)
+		}
+	}
+
+	_, err := backend.NewProverConfig(backend.WithFFTStrategy(backend.FFTStrategyDIT + 1))
+	assert.Error(err)
+}
+
+func TestProveWithProfile(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, fullWitness := smallCircuit(t, ecc.BN254)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	proof, profile, err := groth16.ProveWithProfile(ccs, pk, fullWitness)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	assert.NotZero(profile.Solve, "solve")
+	assert.NotZero(profile.H, "H")
+	assert.NotZero(profile.MSMG1, "G1 MSM")
+	assert.NotZero(profile.MSMG2, "G2 MSM")
+
+	// Prove fills the profile of the option as well
+	var optProfile backend.ProverProfile
+	_, err = groth16.Prove(ccs, pk, fullWitness, backend.WithProfiling(&optProfile))
+	assert.NoError(err)
+	assert.NotZero(optProfile.Solve, "solve")
+	assert.NotZero(optProfile.MSMG2, "G2 MSM")
+
+	_, err = backend.NewProverConfig(backend.WithProfiling(nil))
+	assert.Error(err)
+}
+
+type committedCircuit struct {
+	X, Y frontend.Variable
+}
+
+func (circuit *committedCircuit) Define(api frontend.API) error {
+	commit, err := api.Compiler().Commit(circuit.X, circuit.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commit, 0)
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.Y), 6)
+	return nil
+}
+
+func TestProvingKeySizes(t *testing.T) {
+	for _, curve := range getCurves() {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, _ := smallCircuit(t, curve)
+			nbG1, nbG2, err := groth16.ProvingKeySizes(ccs)
+			assert.NoError(err)
+
+			pk, _, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			assert.Equal(pk.NbG1(), nbG1, "G1")
+			assert.Equal(pk.NbG2(), nbG2, "G2")
+		})
+	}
+
+	// with a commitment, the committed wires are not part of pk.G1.K
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &committedCircuit{})
+	require.NoError(t, err)
+	nbG1, nbG2, err := groth16.ProvingKeySizes(ccs)
+	require.NoError(t, err)
+	pk, _, err := groth16.Setup(ccs)
+	require.NoError(t, err)
+	require.Equal(t, pk.NbG1(), nbG1, "G1")
+	require.Equal(t, pk.NbG2(), nbG2, "G2")
+
+	// not a R1CS
+	sparse, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &namedCircuit{})
+	require.NoError(t, err)
+	_, _, err = groth16.ProvingKeySizes(sparse)
+	require.Error(t, err)
+}
+
 //--------------------//
 //     benches		  //
 //--------------------//
@@ -78,6 +436,40 @@ func BenchmarkVerifier(b *testing.B) {
 	}
 }
 
+// BenchmarkVerifierPrecompute compares verifying with the cached e(α, β) to recomputing it
+// before each verification.
+func BenchmarkVerifierPrecompute(b *testing.B) {
+	r1cs, _solution := referenceCircuit(ecc.BN254)
+	fullWitness, err := frontend.NewWitness(_solution, ecc.BN254.ScalarField())
+	if err != nil {
+		b.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		b.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(r1cs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	proof, err := groth16.Prove(r1cs, pk, fullWitness)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = groth16.Verify(proof, vk, publicWitness)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = vk.Precompute()
+			_ = groth16.Verify(proof, vk, publicWitness)
+		}
+	})
+}
+
 type refCircuit struct {
 	nbConstraints int
 	X             frontend.Variable
@@ -116,6 +508,89 @@ func referenceCircuit(curve ecc.ID) (constraint.ConstraintSystem, frontend.Circu
 	return r1cs, &good
 }
 
+func TestMatchingKeys(t *testing.T) {
+	for _, curve := range getCurves() {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, _ := smallCircuit(t, curve)
+			pkA, vkA, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			pkB, vkB, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			assert.True(groth16.MatchingKeys(pkA, vkA))
+			assert.True(groth16.MatchingKeys(pkB, vkB))
+			assert.False(groth16.MatchingKeys(pkA, vkB))
+			assert.False(groth16.MatchingKeys(pkB, vkA))
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	for _, curve := range getCurves() {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, fullWitness := smallCircuit(t, curve)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			var buf bytes.Buffer
+			assert.NoError(groth16.WriteArchive(&buf, ccs, pk, vk))
+			archive := buf.Bytes()
+
+			ccs2, pk2, vk2, err := groth16.ReadArchive(bytes.NewReader(archive))
+			assert.NoError(err)
+			assert.True(groth16.MatchingKeys(pk2, vk2))
+			proof, err := groth16.Prove(ccs2, pk2, fullWitness)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk2, publicWitness))
+			assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+			// a flipped bit in the last section (the verifying key) is caught by the digest
+			tampered := append([]byte(nil), archive...)
+			tampered[len(tampered)-sha256.Size-1] ^= 1
+			_, _, _, err = groth16.ReadArchive(bytes.NewReader(tampered))
+			assert.ErrorIs(err, groth16.ErrArchiveDigest)
+
+			// truncated
+			_, _, _, err = groth16.ReadArchive(bytes.NewReader(archive[:len(archive)-1]))
+			assert.Error(err)
+
+			// mixed up key pair
+			_, vkB, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			assert.Error(groth16.WriteArchive(&buf, ccs, pk, vkB))
+		})
+	}
+}
+
+// smallCircuit returns a compiled refCircuit with a few constraints, and a valid full witness
+func smallCircuit(tb testing.TB, curve ecc.ID) (constraint.ConstraintSystem, witness.Witness) {
+	const nbConstraints = 10
+	circuit := refCircuit{
+		nbConstraints: nbConstraints,
+	}
+	ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var good refCircuit
+	good.X = 2
+	exp := new(big.Int).Lsh(big.NewInt(1), nbConstraints)
+	good.Y = new(big.Int).Exp(big.NewInt(2), exp, curve.ScalarField())
+
+	fullWitness, err := frontend.NewWitness(&good, curve.ScalarField())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return ccs, fullWitness
+}
+
 func getCurves() []ecc.ID {
 	if testing.Short() {
 		return []ecc.ID{ecc.BN254}
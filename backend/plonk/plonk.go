@@ -20,12 +20,16 @@
 package plonk
 
 import (
+	"context"
+	"fmt"
 	"io"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
 
 	"github.com/consensys/gnark/backend/witness"
 	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
@@ -91,32 +95,127 @@ type VerifyingKey interface {
 	InitKZG(srs kzg.SRS) error
 	NbPublicWitness() int // number of elements expected in the public witness
 	ExportSolidity(w io.Writer) error
+
+	// NbG1 returns the number of G1 elements in the VerifyingKey
+	NbG1() int
+
+	// NbG2 returns the number of G2 elements in the VerifyingKey
+	NbG2() int
+
+	// ProofSize returns the size in bytes of a serialized (compressed) proof
+	ProofSize() int
+
+	// VerificationPairings returns the number of pairings computed to verify a proof
+	VerificationPairings() int
 }
 
+// ErrSRSTooSmall is returned by Setup and InitKZG when the SRS doesn't have enough points
+type ErrSRSTooSmall = backend.ErrSRSTooSmall
+
 // Setup prepares the public data associated to a circuit + public inputs.
-func Setup(ccs constraint.ConstraintSystem, kzgSRS kzg.SRS) (ProvingKey, VerifyingKey, error) {
+func Setup(ccs constraint.ConstraintSystem, kzgSRS kzg.SRS, opts ...backend.SetupOption) (ProvingKey, VerifyingKey, error) {
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return plonk_bn254.Setup(tccs, kzgSRS.(*kzg_bn254.SRS), opts...)
+	case *cs_bls12381.SparseR1CS:
+		return plonk_bls12381.Setup(tccs, kzgSRS.(*kzg_bls12381.SRS), opts...)
+	case *cs_bls12377.SparseR1CS:
+		return plonk_bls12377.Setup(tccs, kzgSRS.(*kzg_bls12377.SRS), opts...)
+	case *cs_bw6761.SparseR1CS:
+		return plonk_bw6761.Setup(tccs, kzgSRS.(*kzg_bw6761.SRS), opts...)
+	case *cs_bls24317.SparseR1CS:
+		return plonk_bls24317.Setup(tccs, kzgSRS.(*kzg_bls24317.SRS), opts...)
+	case *cs_bls24315.SparseR1CS:
+		return plonk_bls24315.Setup(tccs, kzgSRS.(*kzg_bls24315.SRS), opts...)
+	case *cs_bw6633.SparseR1CS:
+		return plonk_bw6633.Setup(tccs, kzgSRS.(*kzg_bw6633.SRS), opts...)
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+
+}
+
+// SetupWithContext is like Setup, but aborts returning ctx.Err() once ctx is done.
+// The context is checked between the setup phases.
+func SetupWithContext(ctx context.Context, ccs constraint.ConstraintSystem, kzgSRS kzg.SRS, opts ...backend.SetupOption) (ProvingKey, VerifyingKey, error) {
 
 	switch tccs := ccs.(type) {
 	case *cs_bn254.SparseR1CS:
-		return plonk_bn254.Setup(tccs, kzgSRS.(*kzg_bn254.SRS))
+		return plonk_bn254.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bn254.SRS), opts...)
 	case *cs_bls12381.SparseR1CS:
-		return plonk_bls12381.Setup(tccs, kzgSRS.(*kzg_bls12381.SRS))
+		return plonk_bls12381.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bls12381.SRS), opts...)
 	case *cs_bls12377.SparseR1CS:
-		return plonk_bls12377.Setup(tccs, kzgSRS.(*kzg_bls12377.SRS))
+		return plonk_bls12377.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bls12377.SRS), opts...)
 	case *cs_bw6761.SparseR1CS:
-		return plonk_bw6761.Setup(tccs, kzgSRS.(*kzg_bw6761.SRS))
+		return plonk_bw6761.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bw6761.SRS), opts...)
 	case *cs_bls24317.SparseR1CS:
-		return plonk_bls24317.Setup(tccs, kzgSRS.(*kzg_bls24317.SRS))
+		return plonk_bls24317.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bls24317.SRS), opts...)
 	case *cs_bls24315.SparseR1CS:
-		return plonk_bls24315.Setup(tccs, kzgSRS.(*kzg_bls24315.SRS))
+		return plonk_bls24315.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bls24315.SRS), opts...)
 	case *cs_bw6633.SparseR1CS:
-		return plonk_bw6633.Setup(tccs, kzgSRS.(*kzg_bw6633.SRS))
+		return plonk_bw6633.SetupWithContext(ctx, tccs, kzgSRS.(*kzg_bw6633.SRS), opts...)
 	default:
 		panic("unrecognized SparseR1CS curve type")
 	}
 
 }
 
+// SetupVerifyingKeyOnly returns the verifying key Setup would return, skipping the
+// parts of the setup only the prover needs.
+func SetupVerifyingKeyOnly(ccs constraint.ConstraintSystem, kzgSRS kzg.SRS, opts ...backend.SetupOption) (VerifyingKey, error) {
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return plonk_bn254.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bn254.SRS), opts...)
+	case *cs_bls12381.SparseR1CS:
+		return plonk_bls12381.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bls12381.SRS), opts...)
+	case *cs_bls12377.SparseR1CS:
+		return plonk_bls12377.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bls12377.SRS), opts...)
+	case *cs_bw6761.SparseR1CS:
+		return plonk_bw6761.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bw6761.SRS), opts...)
+	case *cs_bls24317.SparseR1CS:
+		return plonk_bls24317.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bls24317.SRS), opts...)
+	case *cs_bls24315.SparseR1CS:
+		return plonk_bls24315.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bls24315.SRS), opts...)
+	case *cs_bw6633.SparseR1CS:
+		return plonk_bw6633.SetupVerifyingKeyOnly(tccs, kzgSRS.(*kzg_bw6633.SRS), opts...)
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+
+}
+
+// MatchingKeys reports whether vk is the verifying key embedded in pk, to catch a key pair
+// mixed up between circuits before proving. Keys of different curves don't match.
+func MatchingKeys(pk ProvingKey, vk VerifyingKey) bool {
+	switch _pk := pk.(type) {
+	case *plonk_bn254.ProvingKey:
+		_vk, ok := vk.(*plonk_bn254.VerifyingKey)
+		return ok && plonk_bn254.MatchingKeys(_pk, _vk)
+	case *plonk_bls12381.ProvingKey:
+		_vk, ok := vk.(*plonk_bls12381.VerifyingKey)
+		return ok && plonk_bls12381.MatchingKeys(_pk, _vk)
+	case *plonk_bls12377.ProvingKey:
+		_vk, ok := vk.(*plonk_bls12377.VerifyingKey)
+		return ok && plonk_bls12377.MatchingKeys(_pk, _vk)
+	case *plonk_bw6761.ProvingKey:
+		_vk, ok := vk.(*plonk_bw6761.VerifyingKey)
+		return ok && plonk_bw6761.MatchingKeys(_pk, _vk)
+	case *plonk_bls24317.ProvingKey:
+		_vk, ok := vk.(*plonk_bls24317.VerifyingKey)
+		return ok && plonk_bls24317.MatchingKeys(_pk, _vk)
+	case *plonk_bls24315.ProvingKey:
+		_vk, ok := vk.(*plonk_bls24315.VerifyingKey)
+		return ok && plonk_bls24315.MatchingKeys(_pk, _vk)
+	case *plonk_bw6633.ProvingKey:
+		_vk, ok := vk.(*plonk_bw6633.VerifyingKey)
+		return ok && plonk_bw6633.MatchingKeys(_pk, _vk)
+	default:
+		panic("unrecognized ProvingKey curve type")
+	}
+}
+
 // Prove generates PLONK proof from a circuit, associated preprocessed public data, and the witness
 // if the force flag is set:
 //
@@ -137,6 +236,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bn254.Prove(tccs, pk.(*plonk_bn254.ProvingKey), w, opt)
 
 	case *cs_bls12381.SparseR1CS:
@@ -144,6 +246,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bls12381.Prove(tccs, pk.(*plonk_bls12381.ProvingKey), w, opt)
 
 	case *cs_bls12377.SparseR1CS:
@@ -151,6 +256,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bls12377.Prove(tccs, pk.(*plonk_bls12377.ProvingKey), w, opt)
 
 	case *cs_bw6761.SparseR1CS:
@@ -158,6 +266,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bw6761.Prove(tccs, pk.(*plonk_bw6761.ProvingKey), w, opt)
 
 	case *cs_bw6633.SparseR1CS:
@@ -165,6 +276,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bw6633.Prove(tccs, pk.(*plonk_bw6633.ProvingKey), w, opt)
 
 	case *cs_bls24317.SparseR1CS:
@@ -172,6 +286,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bls24317.Prove(tccs, pk.(*plonk_bls24317.ProvingKey), w, opt)
 
 	case *cs_bls24315.SparseR1CS:
@@ -179,6 +296,9 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
+		if err := checkWitnessSize(ccs, len(w)); err != nil {
+			return nil, err
+		}
 		return plonk_bls24315.Prove(tccs, pk.(*plonk_bls24315.ProvingKey), w, opt)
 
 	default:
@@ -186,6 +306,17 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 	}
 }
 
+// PublicWitnessFromAssignment returns the public witness of the circuit assignment, to be
+// given to Verify. Only the public fields of assignment are read, the secret ones may be
+// left unset; it is equivalent to frontend.NewWitness with frontend.PublicOnly.
+func PublicWitnessFromAssignment(curve ecc.ID, assignment frontend.Circuit) (witness.Witness, error) {
+	w, err := frontend.NewWitness(assignment, curve.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		return nil, fmt.Errorf("new public witness: %w", err)
+	}
+	return w, nil
+}
+
 // Verify verifies a PLONK proof, from the proof, preprocessed public data, and public witness.
 func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 
@@ -196,6 +327,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bn254.Verify(_proof, vk.(*plonk_bn254.VerifyingKey), w)
 
 	case *plonk_bls12381.Proof:
@@ -203,6 +337,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bls12381.Verify(_proof, vk.(*plonk_bls12381.VerifyingKey), w)
 
 	case *plonk_bls12377.Proof:
@@ -210,6 +347,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bls12377.Verify(_proof, vk.(*plonk_bls12377.VerifyingKey), w)
 
 	case *plonk_bw6761.Proof:
@@ -217,6 +357,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bw6761.Verify(_proof, vk.(*plonk_bw6761.VerifyingKey), w)
 
 	case *plonk_bw6633.Proof:
@@ -224,6 +367,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bw6633.Verify(_proof, vk.(*plonk_bw6633.VerifyingKey), w)
 
 	case *plonk_bls24317.Proof:
@@ -231,6 +377,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bls24317.Verify(_proof, vk.(*plonk_bls24317.VerifyingKey), w)
 
 	case *plonk_bls24315.Proof:
@@ -238,6 +387,9 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 		if !ok {
 			return witness.ErrInvalidWitness
 		}
+		if err := checkNbPublicInputs(len(w), vk); err != nil {
+			return err
+		}
 		return plonk_bls24315.Verify(_proof, vk.(*plonk_bls24315.VerifyingKey), w)
 
 	default:
@@ -245,6 +397,24 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness) error {
 	}
 }
 
+// checkWitnessSize returns an error if a full witness of size n doesn't match the
+// number of public and secret inputs of ccs.
+func checkWitnessSize(ccs constraint.ConstraintSystem, n int) error {
+	nbPublic, nbSecret := ccs.GetNbPublicVariables(), ccs.GetNbSecretVariables()
+	if n != nbPublic+nbSecret {
+		return fmt.Errorf("invalid full witness size for %s SparseR1CS: got %d, expected %d = %d (public) + %d (secret)",
+			utils.FieldToCurve(ccs.Field()), n, nbPublic+nbSecret, nbPublic, nbSecret)
+	}
+	return nil
+}
+
+func checkNbPublicInputs(n int, vk VerifyingKey) error {
+	if n != vk.NbPublicWitness() {
+		return fmt.Errorf("invalid number of public inputs: got %d, expected %d", n, vk.NbPublicWitness())
+	}
+	return nil
+}
+
 // NewCS instantiate a concrete curved-typed SparseR1CS and return a ConstraintSystem interface
 // This method exists for (de)serialization purposes
 func NewCS(curveID ecc.ID) constraint.ConstraintSystem {
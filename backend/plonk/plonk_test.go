@@ -2,12 +2,16 @@ package plonk_test
 
 import (
 	"bytes"
+	"crypto/rand"
+	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
 	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
@@ -58,6 +62,148 @@ func TestProver(t *testing.T) {
 	}
 }
 
+func TestSRSContribution(t *testing.T) {
+	assert := require.New(t)
+
+	const size = 64
+	srs0, _, err := plonk.NewSRSContribution(size, nil, rand.Reader)
+	assert.NoError(err)
+	srs1, contribution1, err := plonk.NewSRSContribution(size, srs0, rand.Reader)
+	assert.NoError(err)
+	srs2, contribution2, err := plonk.NewSRSContribution(size, srs1, rand.Reader)
+	assert.NoError(err)
+
+	assert.NoError(plonk.VerifySRSUpdate(srs0, srs1, contribution1))
+	assert.NoError(plonk.VerifySRSUpdate(srs1, srs2, contribution2))
+	assert.Error(plonk.VerifySRSUpdate(srs1, srs1, srs1.G2[0]), "srs was not updated")
+
+	// a well formed srs which isn't derived from prev, or a contribution which isn't the
+	// one applied, must be rejected
+	other, otherContribution, err := plonk.NewSRSContribution(size, nil, rand.Reader)
+	assert.NoError(err)
+	assert.Error(plonk.VerifySRSUpdate(srs1, other, otherContribution))
+	assert.Error(plonk.VerifySRSUpdate(srs1, srs2, contribution1))
+
+	// the resulting srs can be used to setup, prove and verify
+	circuit := refCircuit{nbConstraints: 10}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	assert.NoError(err)
+
+	var good refCircuit
+	good.X = 2
+	expectedY := new(big.Int).Exp(big.NewInt(2), new(big.Int).Lsh(big.NewInt(1), 10), ecc.BN254.ScalarField())
+	good.Y = expectedY
+	fullWitness, err := frontend.NewWitness(&good, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+
+	pk, vk, err := plonk.Setup(ccs, srs2)
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+
+	// tampering with a power must be detected
+	srs2.G1[5].Add(&srs2.G1[5], &srs2.G1[0])
+	assert.Error(plonk.VerifySRSUpdate(srs1, srs2, contribution2))
+}
+
+func TestSetupSRSTooSmall(t *testing.T) {
+	assert := require.New(t)
+
+	circuit := refCircuit{nbConstraints: 10}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	assert.NoError(err)
+
+	srs, err := kzg_bn254.NewSRS(4, big.NewInt(42))
+	assert.NoError(err)
+	_, _, err = plonk.Setup(ccs, srs)
+
+	var errSRS plonk.ErrSRSTooSmall
+	assert.True(errors.As(err, &errSRS), "expected a ErrSRSTooSmall, got %v", err)
+	assert.Equal(4, errSRS.Got)
+	assert.Equal(16, errSRS.Required) // 10 constraints + 1 placeholder, rounded to the next power of 2
+
+	// growing the srs to the required size is enough
+	srs, err = kzg_bn254.NewSRS(uint64(errSRS.Required), big.NewInt(42))
+	assert.NoError(err)
+	_, _, err = plonk.Setup(ccs, srs)
+	assert.NoError(err)
+}
+
+func TestMatchingKeys(t *testing.T) {
+	assert := require.New(t)
+
+	setup := func(nbConstraints int) (plonk.ProvingKey, plonk.VerifyingKey) {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &refCircuit{nbConstraints: nbConstraints})
+		assert.NoError(err)
+		srs, err := test.NewKZGSRS(ccs)
+		assert.NoError(err)
+		pk, vk, err := plonk.Setup(ccs, srs)
+		assert.NoError(err)
+		return pk, vk
+	}
+	pkA, vkA := setup(10)
+	pkB, vkB := setup(11)
+
+	assert.True(plonk.MatchingKeys(pkA, vkA))
+	assert.True(plonk.MatchingKeys(pkB, vkB))
+	assert.False(plonk.MatchingKeys(pkA, vkB))
+	assert.False(plonk.MatchingKeys(pkB, vkA))
+}
+
+func TestSetupQuotientDomainMultiplier(t *testing.T) {
+	assert := require.New(t)
+
+	const nbConstraints = 10
+	circuit := refCircuit{nbConstraints: nbConstraints}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+
+	// X = 2, Y = 2^(2^nbConstraints)
+	exp := new(big.Int).Lsh(big.NewInt(1), nbConstraints)
+	assignment := refCircuit{X: 2, Y: new(big.Int).Exp(big.NewInt(2), exp, ecc.BN254.ScalarField())}
+	fullWitness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+
+	pk, vk, err := plonk.Setup(ccs, srs, backend.WithQuotientDomainMultiplier(16))
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+
+	// not a power of two
+	_, _, err = plonk.Setup(ccs, srs, backend.WithQuotientDomainMultiplier(6))
+	assert.Error(err)
+
+	// too small for the quotient
+	_, _, err = plonk.Setup(ccs, srs, backend.WithQuotientDomainMultiplier(2))
+	assert.Error(err)
+}
+
+func TestVerifyingKeyNbG1G2(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curve := range getCurves() {
+		circuit := refCircuit{nbConstraints: 10}
+		ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &circuit)
+		assert.NoError(err)
+		srs, err := test.NewKZGSRS(ccs)
+		assert.NoError(err)
+		_, vk, err := plonk.Setup(ccs, srs)
+		assert.NoError(err)
+
+		// Ql, Qr, Qm, Qo, Qk, S1, S2, S3 and the 2 SRS points in G2
+		assert.Equal(8, vk.NbG1(), curve.String())
+		assert.Equal(2, vk.NbG2(), curve.String())
+	}
+}
+
 func BenchmarkSetup(b *testing.B) {
 	for _, curve := range getCurves() {
 		b.Run(curve.String(), func(b *testing.B) {
@@ -70,6 +216,32 @@ func BenchmarkSetup(b *testing.B) {
 	}
 }
 
+// manyPublicCircuit has as many public inputs as constraints
+type manyPublicCircuit struct {
+	P   [10000]frontend.Variable `gnark:",public"`
+	Sum frontend.Variable
+}
+
+func (circuit *manyPublicCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(circuit.P[0], circuit.P[1], circuit.P[2:]...), circuit.Sum)
+	return nil
+}
+
+func BenchmarkSetupManyPublicInputs(b *testing.B) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &manyPublicCircuit{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	srs, err := test.NewKZGSRS(ccs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = plonk.Setup(ccs, srs)
+	}
+}
+
 func BenchmarkProver(b *testing.B) {
 	for _, curve := range getCurves() {
 		b.Run(curve.String(), func(b *testing.B) {
@@ -120,6 +292,83 @@ func BenchmarkVerifier(b *testing.B) {
 	}
 }
 
+type twoPublicCircuit struct {
+	X, Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *twoPublicCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+func TestVerifyNbPublicInputs(t *testing.T) {
+	assert := require.New(t)
+
+	circuit := refCircuit{nbConstraints: 10}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+
+	exp := new(big.Int).Lsh(big.NewInt(1), 10)
+	y := new(big.Int).Exp(big.NewInt(2), exp, ecc.BN254.ScalarField())
+	fullWitness, err := frontend.NewWitness(&refCircuit{X: 2, Y: y}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+
+	// a public witness with 2 elements instead of 1
+	w, err := frontend.NewWitness(&twoPublicCircuit{X: y, Y: y}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	assert.NoError(err)
+	err = plonk.Verify(proof, vk, w)
+	assert.EqualError(err, "invalid number of public inputs: got 2, expected 1")
+}
+
+func TestProveWitnessSize(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &refCircuit{nbConstraints: 10})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	pk, _, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+
+	// the public part only, given as a full witness
+	short, err := frontend.NewWitness(&refCircuit{X: 2, Y: 4}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	assert.NoError(err)
+	_, err = plonk.Prove(ccs, pk, short)
+	assert.EqualError(err, "invalid full witness size for bn254 SparseR1CS: got 1, expected 2 = 1 (public) + 1 (secret)")
+}
+
+func TestPublicWitnessFromAssignment(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &refCircuit{nbConstraints: 10})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+
+	exp := new(big.Int).Lsh(big.NewInt(1), 10)
+	y := new(big.Int).Exp(big.NewInt(2), exp, ecc.BN254.ScalarField())
+	fullWitness, err := frontend.NewWitness(&refCircuit{X: 2, Y: y}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+
+	// the secret X is not needed
+	publicWitness, err := plonk.PublicWitnessFromAssignment(ecc.BN254, &refCircuit{Y: y})
+	assert.NoError(err)
+	expected, err := frontend.NewWitness(&refCircuit{X: 2, Y: y}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	assert.NoError(err)
+	assert.Equal(expected.Vector(), publicWitness.Vector())
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+}
+
 type refCircuit struct {
 	nbConstraints int
 	X             frontend.Variable
@@ -167,3 +416,58 @@ func getCurves() []ecc.ID {
 	}
 	return gnark.Curves()
 }
+
+type rangeCheckCircuit struct {
+	nbBits   int
+	toBinary bool
+	X        frontend.Variable
+	Y        frontend.Variable `gnark:",public"`
+}
+
+func (circuit *rangeCheckCircuit) Define(api frontend.API) error {
+	if circuit.toBinary {
+		api.ToBinary(circuit.X, circuit.nbBits)
+	} else {
+		api.Compiler().RangeCheck(circuit.X, circuit.nbBits)
+	}
+	api.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+func TestRangeCheckGate(t *testing.T) {
+	assert := require.New(t)
+
+	const nbBits = 64
+	for _, curve := range getCurves() {
+		ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &rangeCheckCircuit{nbBits: nbBits})
+		assert.NoError(err)
+		ccsToBinary, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &rangeCheckCircuit{nbBits: nbBits, toBinary: true})
+		assert.NoError(err)
+		// one gate per bit (and one for the assertion), instead of one for the boolean check
+		// and one for the accumulation per bit
+		assert.Equal(nbBits+1, ccs.GetNbConstraints(), curve.String())
+		assert.Less(ccs.GetNbConstraints(), ccsToBinary.GetNbConstraints()*2/3, curve.String())
+
+		srs, err := test.NewKZGSRS(ccs)
+		assert.NoError(err)
+		pk, vk, err := plonk.Setup(ccs, srs)
+		assert.NoError(err)
+
+		x := new(big.Int).Lsh(big.NewInt(1), nbBits)
+		x.Sub(x, big.NewInt(1))
+		w, err := frontend.NewWitness(&rangeCheckCircuit{X: x, Y: x}, curve.ScalarField())
+		assert.NoError(err)
+		proof, err := plonk.Prove(ccs, pk, w)
+		assert.NoError(err, curve.String())
+		publicWitness, err := w.Public()
+		assert.NoError(err)
+		assert.NoError(plonk.Verify(proof, vk, publicWitness), curve.String())
+
+		// 2⁶⁴ is out of range
+		x.Add(x, big.NewInt(1))
+		w, err = frontend.NewWitness(&rangeCheckCircuit{X: x, Y: x}, curve.ScalarField())
+		assert.NoError(err)
+		_, err = plonk.Prove(ccs, pk, w)
+		assert.Error(err, curve.String())
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plonk
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// NewSRSContribution extends prev with fresh randomness read from entropy and returns
+// the updated SRS of given size, along with [τ']G₂, where τ' is the contribution, for
+// VerifySRSUpdate. If prev is nil, a new SRS is created from scratch.
+//
+// [τ']G₂ is returned along with the SRS because an update can't be verified without it,
+// see VerifySRSUpdate.
+//
+// If prev = ([τⁱ]G₁, [τ]G₂) the returned SRS is ([(ττ')ⁱ]G₁, [ττ']G₂); as long as one
+// contributor discards its τ', nobody knows ττ'.
+//
+// This is meant for tests and local development setups only; it handles BN254 only.
+func NewSRSContribution(size uint64, prev *kzg_bn254.SRS, entropy io.Reader) (*kzg_bn254.SRS, curve.G2Affine, error) {
+	var contribution curve.G2Affine
+	if size < 2 {
+		return nil, contribution, errors.New("srs size must be at least 2")
+	}
+	if entropy == nil {
+		entropy = rand.Reader
+	}
+	tau, err := rand.Int(entropy, fr.Modulus())
+	if err != nil {
+		return nil, contribution, fmt.Errorf("sample contribution: %w", err)
+	}
+	if tau.Sign() == 0 {
+		return nil, contribution, errors.New("sampled a zero contribution")
+	}
+
+	if prev == nil {
+		srs, err := kzg_bn254.NewSRS(size, tau)
+		if err != nil {
+			return nil, contribution, err
+		}
+		return srs, srs.G2[1], nil
+	}
+
+	if uint64(len(prev.G1)) < size {
+		return nil, contribution, fmt.Errorf("previous srs is too small: got %d, need %d", len(prev.G1), size)
+	}
+
+	var next kzg_bn254.SRS
+	next.G1 = make([]curve.G1Affine, size)
+	next.G2[0].Set(&prev.G2[0])
+	next.G2[1].ScalarMultiplication(&prev.G2[1], tau)
+	contribution.ScalarMultiplication(&prev.G2[0], tau)
+
+	// powers of the contribution: [1, τ', τ'², ...]
+	powers := make([]fr.Element, size)
+	powers[0].SetOne()
+	powers[1].SetBigInt(tau)
+	for i := 2; i < len(powers); i++ {
+		powers[i].Mul(&powers[i-1], &powers[1])
+	}
+
+	utils.Parallelize(len(next.G1), func(start, end int) {
+		var s big.Int
+		for i := start; i < end; i++ {
+			powers[i].BigInt(&s)
+			next.G1[i].ScalarMultiplication(&prev.G1[i], &s)
+		}
+	})
+
+	return &next, contribution, nil
+}
+
+// VerifySRSUpdate checks that next is the SRS prev updated with the contribution τ', given
+// [τ']G₂ as returned by NewSRSContribution, that is, that:
+//   - next shares the generators of prev, and τ' ≠ 1;
+//   - e(next.G1[1], G₂) == e(prev.G1[1], [τ']G₂) and e(G₁, next.G2[1]) == e(prev.G1[1], [τ']G₂);
+//   - next.G1 are consecutive powers of the secret committed to in next.G2[1].
+//
+// The last check uses a random linear combination of the powers so that it costs a single
+// pairing check regardless of the SRS size.
+//
+// A check on prev and next alone can't work: pairings only relate next.G1 to next.G2, so
+// any well-formed SRS, including one built from scratch by someone who knows its secret,
+// would pass. Tying next to prev needs [τ']G₂, which only the contributor can produce.
+func VerifySRSUpdate(prev, next *kzg_bn254.SRS, contribution curve.G2Affine) error {
+	if prev == nil || next == nil {
+		return errors.New("nil srs")
+	}
+	if len(prev.G1) < 2 || len(next.G1) < 2 {
+		return errors.New("srs must contain at least 2 G1 points")
+	}
+	if !next.G1[0].Equal(&prev.G1[0]) || !next.G2[0].Equal(&prev.G2[0]) {
+		return errors.New("srs generators don't match")
+	}
+	if contribution.IsInfinity() || !contribution.IsInSubGroup() {
+		return errors.New("invalid contribution G2 point")
+	}
+	if contribution.Equal(&prev.G2[0]) {
+		return errors.New("srs was not updated")
+	}
+	if next.G2[1].IsInfinity() || !next.G2[1].IsInSubGroup() {
+		return errors.New("invalid srs G2 point")
+	}
+
+	// the update is consistent with prev: [ττ']G₁ and [ττ']G₂ are [τ]G₁ scaled by τ'
+	var prevG1Neg curve.G1Affine
+	prevG1Neg.Neg(&prev.G1[1])
+	ok, err := curve.PairingCheck([]curve.G1Affine{next.G1[1], prevG1Neg}, []curve.G2Affine{next.G2[0], contribution})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("srs G1 points are not derived from the previous srs")
+	}
+	ok, err = curve.PairingCheck([]curve.G1Affine{next.G1[0], prevG1Neg}, []curve.G2Affine{next.G2[1], contribution})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("srs G2 point is not derived from the previous srs")
+	}
+
+	// e(Σ rᵢ⋅G1[i+1], G₂) == e(Σ rᵢ⋅G1[i], [ττ']G₂) for random rᵢ
+	n := len(next.G1) - 1
+	r := make([]fr.Element, n)
+	for i := 0; i < n; i++ {
+		if _, err := r[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+	var left, right curve.G1Affine
+	config := ecc.MultiExpConfig{}
+	if _, err := left.MultiExp(next.G1[1:], r, config); err != nil {
+		return err
+	}
+	if _, err := right.MultiExp(next.G1[:n], r, config); err != nil {
+		return err
+	}
+	right.Neg(&right)
+
+	ok, err = curve.PairingCheck([]curve.G1Affine{left, right}, []curve.G2Affine{next.G2[0], next.G2[1]})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("srs G1 points are not consecutive powers of the G2 secret")
+	}
+	return nil
+}
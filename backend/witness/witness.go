@@ -47,6 +47,7 @@ import (
 	"math/big"
 	"reflect"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	fr_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
@@ -93,6 +94,10 @@ type Witness interface {
 	// Will allocate the underlying vector with nbPublic + nbSecret elements.
 	// This is typically call by internal APIs to fill the vector by walking a structure.
 	Fill(nbPublic, nbSecret int, values <-chan any) error
+
+	// AsBigInts returns the public then secret values of the witness as big integers,
+	// in the witness order.
+	AsBigInts() ([]big.Int, error)
 }
 
 type witness struct {
@@ -384,3 +389,88 @@ func (w *witness) FromJSON(s *schema.Schema, data []byte) error {
 
 	return w.Fill(s.NbPublic, s.NbSecret, chValues)
 }
+
+// AsBigInts returns the public then secret values of the witness as big integers,
+// in the witness order.
+func (w *witness) AsBigInts() ([]big.Int, error) {
+	if w.vector == nil {
+		return nil, ErrInvalidWitness
+	}
+	res := make([]big.Int, 0, w.nbPublic+w.nbSecret)
+	for v := range w.iterate() {
+		e, ok := v.(interface{ BigInt(*big.Int) *big.Int })
+		if !ok {
+			return nil, ErrInvalidWitness
+		}
+		res = append(res, big.Int{})
+		e.BigInt(&res[len(res)-1])
+	}
+	return res, nil
+}
+
+// WitnessFromBigInts returns a witness on the scalar field of curve holding the public
+// then secret values. The values must be reduced modulo the field, that is in [0, r).
+func WitnessFromBigInts(curve ecc.ID, public, secret []big.Int) (Witness, error) {
+	field := curve.ScalarField()
+	w, err := New(field)
+	if err != nil {
+		return nil, err
+	}
+	values := make(chan any, len(public)+len(secret))
+	for _, v := range [][]big.Int{public, secret} {
+		for i := range v {
+			if v[i].Sign() < 0 || v[i].Cmp(field) >= 0 {
+				return nil, fmt.Errorf("value %s is not reduced modulo %s", v[i].String(), field.String())
+			}
+			values <- &v[i]
+		}
+	}
+	close(values)
+	if err := w.Fill(len(public), len(secret), values); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// NewWitnessReader returns a witness on the scalar field of curve holding nbPublic public
+// then nbSecret secret values read from r, without the header of the binary protocol. Each
+// value is encoded as a big-endian byte array of the size of a field element (32 bytes on
+// BN254), as fr.Element.Bytes does, and must be reduced modulo the field.
+//
+// The values are read one at a time into the vector of the witness, allocated once, so that
+// a large witness isn't held in memory twice; r should be buffered.
+func NewWitnessReader(curve ecc.ID, r io.Reader, nbPublic, nbSecret int) (Witness, error) {
+	if nbPublic < 0 || nbSecret < 0 {
+		return nil, fmt.Errorf("invalid number of values: %d public, %d secret", nbPublic, nbSecret)
+	}
+	field := curve.ScalarField()
+	n := nbPublic + nbSecret
+	v, err := newVector(field, n)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, (field.BitLen()+7)/8)
+	var e big.Int
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("read value %d of %d: %w", i, n, err)
+		}
+		e.SetBytes(buf)
+		if e.Cmp(field) >= 0 {
+			return nil, fmt.Errorf("value %d is not reduced modulo %s", i, field.String())
+		}
+		if err := set(v, i, &e); err != nil {
+			return nil, err
+		}
+	}
+
+	return &witness{
+		vector:   v,
+		nbPublic: uint32(nbPublic),
+		nbSecret: uint32(nbSecret),
+	}, nil
+}
@@ -1,10 +1,14 @@
 package witness_test
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"math/big"
 	"reflect"
 	"testing"
 
+	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/witness"
@@ -148,3 +152,71 @@ func roundTripMarshalJSON(assert *require.Assertions, assignment circuit, public
 	assert.True(reflect.DeepEqual(rw, w), "witness json round trip serialization")
 
 }
+
+func TestBigIntsRoundTrip(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curve := range gnark.Curves() {
+		modulus := curve.ScalarField()
+		public := []big.Int{*big.NewInt(35), *new(big.Int).Sub(modulus, big.NewInt(1))}
+		secret := []big.Int{*big.NewInt(3)}
+
+		w, err := witness.WitnessFromBigInts(curve, public, secret)
+		assert.NoError(err, curve.String())
+
+		values, err := w.AsBigInts()
+		assert.NoError(err)
+		assert.Equal(len(public)+len(secret), len(values))
+		for i, v := range append(public, secret...) {
+			assert.Equal(0, v.Cmp(&values[i]), "%s: value %d", curve, i)
+		}
+
+		// the witness matches the one built from an assignment
+		expected, err := frontend.NewWitness(&circuit{X: 35, Y: public[1], E: 3}, modulus)
+		assert.NoError(err)
+		data, err := w.MarshalBinary()
+		assert.NoError(err)
+		expectedData, err := expected.MarshalBinary()
+		assert.NoError(err)
+		assert.Equal(expectedData, data)
+
+		// public part only
+		pw, err := w.Public()
+		assert.NoError(err)
+		values, err = pw.AsBigInts()
+		assert.NoError(err)
+		assert.Equal(len(public), len(values))
+
+		_, err = witness.WitnessFromBigInts(curve, []big.Int{*modulus}, nil)
+		assert.Error(err, "unreduced value")
+	}
+}
+
+func TestNewWitnessReader(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curve := range gnark.Curves() {
+		modulus := curve.ScalarField()
+		expected, err := frontend.NewWitness(&circuit{X: 35, Y: new(big.Int).Sub(modulus, big.NewInt(1)), E: 3}, modulus)
+		assert.NoError(err)
+
+		// the values of the witness, without the header
+		data, err := expected.MarshalBinary()
+		assert.NoError(err)
+		values := data[12:]
+
+		w, err := witness.NewWitnessReader(curve, bytes.NewReader(values), 2, 1)
+		assert.NoError(err, curve.String())
+		assert.True(reflect.DeepEqual(expected, w), "%s: witness read from a stream", curve)
+
+		// missing value
+		_, err = witness.NewWitnessReader(curve, bytes.NewReader(values), 2, 2)
+		assert.ErrorIs(err, io.ErrUnexpectedEOF, curve.String())
+
+		// unreduced value
+		unreduced := make([]byte, len(values)/3)
+		modulus.FillBytes(unreduced)
+		_, err = witness.NewWitnessReader(curve, bytes.NewReader(unreduced), 1, 0)
+		assert.Error(err, curve.String())
+	}
+}
@@ -17,13 +17,18 @@
 package cs
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/fxamacker/cbor/v2"
 	"io"
 	"math"
+	"math/big"
+	"math/bits"
+	"math/rand"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -42,8 +47,18 @@ type SparseR1CS struct {
 	constraint.SparseR1CSCore
 	CoeffTable
 	arithEngine
+
+	// per constraint, ±(k+1) if qO == ±2ᵏ and 0 otherwise; see fastSolveFlag
+	oFastSolve []int8
+
+	// per constraint, the estimated cost of solving and checking it; see constraintCost
+	costs []uint8
 }
 
+// maxFastSolveShift bounds k such that solving xc in a constraint with qO == ±2ᵏ is done with
+// k halvings instead of a multiplication by -1/qO
+const maxFastSolveShift = 8
+
 // NewSparseR1CS returns a new SparseR1CS and sets r1cs.Coefficient (fr.Element) from provided big.Int values
 func NewSparseR1CS(capacity int) *SparseR1CS {
 	cs := SparseR1CS{
@@ -66,25 +81,141 @@ func (cs *SparseR1CS) AddConstraint(c constraint.SparseR1C, debugInfo ...constra
 		cs.MDebug[cID] = len(cs.DebugInfo) - 1
 	}
 	cs.UpdateLevel(cID, &c)
+	cs.oFastSolve = append(cs.oFastSolve, fastSolveFlag(&cs.Coefficients[c.O.CID]))
+	cs.costs = append(cs.costs, cs.constraintCost(cID))
 
 	return cID
 }
 
+// fastSolveFlag returns k+1 if c == 2ᵏ, -(k+1) if c == -2ᵏ (with k < maxFastSolveShift)
+// and 0 otherwise
+func fastSolveFlag(c *fr.Element) int8 {
+	isPow2 := func(e *fr.Element) int8 {
+		if !e.IsUint64() {
+			return 0
+		}
+		u := e.Uint64()
+		if u == 0 || u&(u-1) != 0 || bits.TrailingZeros64(u) >= maxFastSolveShift {
+			return 0
+		}
+		return int8(bits.TrailingZeros64(u) + 1)
+	}
+	if f := isPow2(c); f != 0 {
+		return f
+	}
+	var neg fr.Element
+	neg.Neg(c)
+	return -isPow2(&neg)
+}
+
+// initFastSolve computes the fast solve flags of all constraints
+func (cs *SparseR1CS) initFastSolve() {
+	cs.oFastSolve = cs.oFastSolve[:0]
+	for i := 0; i < len(cs.Constraints); i++ {
+		cs.oFastSolve = append(cs.oFastSolve, fastSolveFlag(&cs.Coefficients[cs.Constraints[i].O.CID]))
+	}
+}
+
+// initCosts computes the estimated costs of all constraints
+func (cs *SparseR1CS) initCosts() {
+	cs.costs = cs.costs[:0]
+	for i := 0; i < len(cs.Constraints); i++ {
+		cs.costs = append(cs.costs, cs.constraintCost(i))
+	}
+}
+
+// fastSolve returns the fast solve flag of constraint cID, or 0 if the flags are not set
+// (e.g. if the constraints were not added through AddConstraint)
+func (cs *SparseR1CS) fastSolve(cID int) int8 {
+	if len(cs.oFastSolve) != len(cs.Constraints) {
+		return 0
+	}
+	return cs.oFastSolve[cID]
+}
+
 // Solve sets all the wires.
 // solution.values =  [publicInputs | secretInputs | internalVariables ]
 // witness: contains the input variables
 // it returns the full slice of wires
 func (cs *SparseR1CS) Solve(witness fr.Vector, opt backend.ProverConfig) (fr.Vector, error) {
+	return cs.SolvePrecomputed(witness, opt, nil)
+}
+
+// SolvePrecomputed solves the constraint system like Solve, with the negated inverses of
+// the coefficients given by coefficientsNegInv, as returned by PrecomputeNegInvCoefficients,
+// instead of computed at each call. If coefficientsNegInv is nil, they are computed.
+func (cs *SparseR1CS) SolvePrecomputed(witness fr.Vector, opt backend.ProverConfig, coefficientsNegInv fr.Vector) (fr.Vector, error) {
+	solution, err := cs.solve(witness, opt, coefficientsNegInv)
+	return solution.values, err
+}
+
+// SolveWithCheckpoints solves the constraint system like Solve, and also returns the solved
+// values of the wires named by the backend.WithCheckpoints option of opt, or nil if it isn't
+// set. If solving fails, they are those of the checkpoints solved up to the failure.
+func (cs *SparseR1CS) SolveWithCheckpoints(witness fr.Vector, opt backend.ProverConfig) (fr.Vector, map[string]fr.Element, error) {
+	solution, err := cs.solve(witness, opt, nil)
+	return solution.values, solution.checkpoints(opt.Checkpoints), err
+}
+
+func (cs *SparseR1CS) solve(witness fr.Vector, opt backend.ProverConfig, coefficientsNegInv fr.Vector) (solution, error) {
 	log := logger.Logger().With().Int("nbConstraints", len(cs.Constraints)).Str("backend", "plonk").Logger()
+	start := time.Now()
+
+	solution, coefficientsNegInv, err := cs.initSolution(witness, opt, coefficientsNegInv)
+	if err != nil {
+		return solution, err
+	}
+
+	// defer log printing once all solution.values are computed
+	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
+
+	solve := cs.parallelSolve
+	if opt.ReverseCheckOrder {
+		solve = cs.reverseCheckSolve
+	} else if opt.SolveTrace != nil {
+		solution.trace = constraint.NewSolveTrace()
+	}
+	err = solve(&solution, coefficientsNegInv)
+	if opt.SolveTrace != nil {
+		if _, errTrace := solution.trace.WriteTo(opt.SolveTrace); errTrace != nil && err == nil {
+			err = fmt.Errorf("write solve trace: %w", errTrace)
+		}
+	}
+	if err != nil {
+		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
+			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
+		} else {
+			log.Err(err).Send()
+		}
+		return solution, err
+	}
 
+	// sanity check; ensure all wires are marked as "instantiated"
+	if !solution.isValid() {
+		err := errors.New("solver didn't instantiate all wires")
+		log.Err(err).Send()
+		if opt.NoSolvePanic {
+			return solution, err
+		}
+		panic(err.Error())
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
+
+	return solution, nil
+
+}
+
+// initSolution allocates the solution, sets the witness values and computes the
+// negated inverses of the coefficients if the solver needs them and coefficientsNegInv
+// is nil.
+func (cs *SparseR1CS) initSolution(witness fr.Vector, opt backend.ProverConfig, coefficientsNegInv fr.Vector) (solution, fr.Vector, error) {
 	// set the slices holding the solution.values and monitoring which variables have been solved
 	nbVariables := cs.NbInternalVariables + len(cs.Secret) + len(cs.Public)
 
-	start := time.Now()
-
 	expectedWitnessSize := int(len(cs.Public) + len(cs.Secret))
 	if len(witness) != expectedWitnessSize {
-		return make(fr.Vector, nbVariables), fmt.Errorf(
+		return solution{values: make(fr.Vector, nbVariables)}, nil, fmt.Errorf(
 			"invalid witness size, got %d, expected %d = %d (public) + %d (secret)",
 			len(witness),
 			expectedWitnessSize,
@@ -96,8 +227,17 @@ func (cs *SparseR1CS) Solve(witness fr.Vector, opt backend.ProverConfig) (fr.Vec
 	// keep track of wire that have a value
 	solution, err := newSolution(nbVariables, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients, &cs.System.SymbolTable)
 	if err != nil {
-		return solution.values, err
+		return solution, nil, err
 	}
+	if err := checkCheckpoints(opt.Checkpoints, nbVariables); err != nil {
+		return solution, nil, err
+	}
+	if opt.SerialHints {
+		solution.hintMutex = new(sync.Mutex)
+	}
+	solution.checkHintOutputs = opt.HintOutputValidation
+	solution.valueBound, solution.onValueBound = opt.ValueBound, opt.ValueBoundCallback
+	solution.nbTasks = opt.SolverTasks
 
 	// solution.values = [publicInputs | secretInputs | internalVariables ] -> we fill publicInputs | secretInputs
 	copy(solution.values, witness)
@@ -109,62 +249,282 @@ func (cs *SparseR1CS) Solve(witness fr.Vector, opt backend.ProverConfig) (fr.Vec
 	// we instantiated all wires
 	solution.nbSolved += uint64(len(witness))
 
-	// defer log printing once all solution.values are computed
-	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
+	if coefficientsNegInv != nil {
+		if len(coefficientsNegInv) != len(cs.Coefficients) {
+			return solution, nil, fmt.Errorf("invalid number of precomputed coefficients, got %d, expected %d", len(coefficientsNegInv), len(cs.Coefficients))
+		}
+		return solution, coefficientsNegInv, nil
+	}
 
-	// batch invert the coefficients to avoid many divisions in the solver
+	// batch invert the coefficients to avoid many divisions in the solver;
+	// not needed if all qO are small powers of two (up to sign)
+	needNegInv := false
+	for i := 0; i < len(cs.Constraints) && !needNegInv; i++ {
+		needNegInv = cs.fastSolve(i) == 0
+	}
+	if needNegInv {
+		coefficientsNegInv = cs.PrecomputeNegInvCoefficients()
+	}
+
+	return solution, coefficientsNegInv, nil
+}
+
+// PrecomputeNegInvCoefficients returns the negated inverses -1/c of the coefficients of the
+// constraint system, used by the solver to compute the output wire of a constraint. It can
+// be passed to SolvePrecomputed to avoid the batch inversion when solving the same constraint
+// system many times. The entries of zero coefficients are zero.
+func (cs *SparseR1CS) PrecomputeNegInvCoefficients() []fr.Element {
 	coefficientsNegInv := fr.BatchInvert(cs.Coefficients)
 	for i := 0; i < len(coefficientsNegInv); i++ {
 		coefficientsNegInv[i].Neg(&coefficientsNegInv[i])
 	}
+	return coefficientsNegInv
+}
 
-	if err := cs.parallelSolve(&solution, coefficientsNegInv); err != nil {
-		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
-			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
-		} else {
-			log.Err(err).Send()
+// SolveCollectingErrors solves the constraint system like Solve, but doesn't stop at the
+// first unsatisfied constraint: it keeps solving and checking, and returns up to max
+// (all of them if max <= 0) unsatisfied constraints, sorted by constraint id.
+//
+// A wire that can't be solved is set to zero and marked as tainted, as is any wire solved from a
+// tainted one; constraints involving tainted wires are not checked, so that a single failure
+// isn't reported again by every constraint depending on it.
+//
+// This is a debugging tool; it runs sequentially and is much slower than Solve. The returned
+// error is non nil only if the solver couldn't run at all (e.g. invalid witness size).
+func (cs *SparseR1CS) SolveCollectingErrors(witness fr.Vector, opt backend.ProverConfig, max int) (fr.Vector, []UnsatisfiedConstraintError, error) {
+	solution, coefficientsNegInv, err := cs.initSolution(witness, opt, nil)
+	if err != nil {
+		return solution.values, nil, err
+	}
+
+	// defer log printing once all solution.values are computed
+	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
+
+	tainted := make([]bool, len(solution.values))
+	isTainted := func(c constraint.SparseR1C) bool {
+		return ((c.L.CoeffID() != 0 || c.M[0].CoeffID() != 0) && tainted[c.L.WireID()]) ||
+			((c.R.CoeffID() != 0 || c.M[1].CoeffID() != 0) && tainted[c.R.WireID()]) ||
+			(c.O.CoeffID() != 0 && tainted[c.O.WireID()])
+	}
+	// taint marks the wire (and the other outputs of its hint, if any) as tainted, setting
+	// the unsolved ones to zero
+	taint := func(wID int) {
+		wires := []int{wID}
+		if h, ok := cs.MHints[wID]; ok {
+			wires = h.Wires
+		}
+		for _, w := range wires {
+			if !solution.solved[w] {
+				solution.set(w, fr.Element{})
+			}
+			tainted[w] = true
 		}
-		return solution.values, err
 	}
 
-	// sanity check; ensure all wires are marked as "instantiated"
-	if !solution.isValid() {
-		log.Err(errors.New("solver didn't instantiate all wires")).Send()
-		panic("solver didn't instantiate all wires")
+	var errs []UnsatisfiedConstraintError
+	for _, level := range cs.Levels {
+		for _, i := range level {
+			c := cs.Constraints[i]
+			dependsOnTainted := isTainted(c)
+			var unsolved []int
+			for _, w := range [3]int{c.L.WireID(), c.R.WireID(), c.O.WireID()} {
+				if !solution.solved[w] {
+					unsolved = append(unsolved, w)
+				}
+			}
+
+			solveErr := cs.solveConstraint(i, c, cs.fastSolve(i), &solution, coefficientsNegInv)
+			if solveErr != nil || dependsOnTainted {
+				// the wires solved by this constraint depend on a failure
+				for _, w := range unsolved {
+					taint(w)
+				}
+				if !dependsOnTainted {
+					errs = append(errs, UnsatisfiedConstraintError{CID: i, Err: solveErr})
+				}
+			} else if err := cs.checkConstraint(c, &solution); err != nil {
+				if dID, ok := cs.MDebug[i]; ok {
+					errMsg := solution.logValue(cs.DebugInfo[dID])
+					errs = append(errs, UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg})
+				} else {
+					errs = append(errs, UnsatisfiedConstraintError{CID: i, Err: err})
+				}
+			}
+			if max > 0 && len(errs) >= max {
+				break
+			}
+		}
+		if max > 0 && len(errs) >= max {
+			break
+		}
 	}
 
-	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
+	sort.Slice(errs, func(i, j int) bool { return errs[i].CID < errs[j].CID })
 
-	return solution.values, nil
+	return solution.values, errs, nil
+}
+
+// QuickCheck solves the constraint system like Solve, but only checks the constraints of a
+// random sample of its levels, each level being checked with probability levelSampleRate.
+// Solving all the constraints is still needed to compute the wires of the checked ones.
+// The sample is drawn from rng, so that a seeded rng reproduces it.
+//
+// This is a cheap smoke test, e.g. for fuzzing loops: a nil error means the witness is
+// probably valid, not that it is. It runs sequentially.
+func (cs *SparseR1CS) QuickCheck(witness []fr.Element, opt backend.ProverConfig, levelSampleRate float64, rng *rand.Rand) error {
+	if !(levelSampleRate >= 0 && levelSampleRate <= 1) {
+		return fmt.Errorf("level sample rate must be in [0, 1], got %v", levelSampleRate)
+	}
+	if rng == nil {
+		return errors.New("nil rng")
+	}
+	solution, coefficientsNegInv, err := cs.initSolution(witness, opt, nil)
+	if err != nil {
+		return err
+	}
 
+	for _, level := range cs.Levels {
+		check := rng.Float64() < levelSampleRate
+		for _, i := range level {
+			if err := cs.solveConstraint(i, cs.Constraints[i], cs.fastSolve(i), &solution, coefficientsNegInv); err != nil {
+				return &UnsatisfiedConstraintError{CID: i, Err: err}
+			}
+		}
+		if !check {
+			continue
+		}
+		for _, i := range level {
+			if err := cs.checkConstraint(cs.Constraints[i], &solution); err != nil {
+				if dID, ok := cs.MDebug[i]; ok {
+					errMsg := solution.logValue(cs.DebugInfo[dID])
+					return &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
+				}
+				return &UnsatisfiedConstraintError{CID: i, Err: err}
+			}
+		}
+	}
+	return nil
 }
 
+// reverseCheckSolve solves the constraints sequentially, level by level, and only once
+// all wires are solved checks them, in descending index order.
+// See backend.WithReverseCheckOrder.
+func (cs *SparseR1CS) reverseCheckSolve(solution *solution, coefficientsNegInv fr.Vector) error {
+	for _, level := range cs.Levels {
+		for _, i := range level {
+			if err := cs.solveConstraint(i, cs.Constraints[i], cs.fastSolve(i), solution, coefficientsNegInv); err != nil {
+				return &UnsatisfiedConstraintError{CID: i, Err: err}
+			}
+		}
+	}
+	for i := len(cs.Constraints) - 1; i >= 0; i-- {
+		if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
+			if dID, ok := cs.MDebug[i]; ok {
+				errMsg := solution.logValue(cs.DebugInfo[dID])
+				return &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
+			}
+			return &UnsatisfiedConstraintError{CID: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// parallelSolve solves and checks the constraints level by level. The constraints tagged as
+// pure hint wiring (see constraint.SparseR1CSCore.TagHintWiring) are solved but not checked.
+//
+// Each level is split in tasks of about the same estimated cost, as many as CPUs if the level
+// is costly enough, unless the number of tasks is pinned (see backend.WithSolverTasks).
 func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv fr.Vector) error {
-	// minWorkPerCPU is the minimum target number of constraint a task should hold
-	// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
+	// minWorkPerCPU is the minimum target cost (see constraintCost) a task should hold
+	// in other words, if a level costs less than minWorkPerCPU, it will not be parallelized and executed
 	// sequentially without sync.
 	const minWorkPerCPU = 50.0
 
 	// cs.Levels has a list of levels, where all constraints in a level l(n) are independent
 	// and may only have dependencies on previous levels
 
+	// a single large level (fully parallel circuit) is split in contiguous chunks,
+	// without the worker pool
+	pinned := solution.nbTasks != 0
+	var costs []int
+	if len(cs.Levels) == 1 {
+		level := cs.Levels[0]
+		var totalCost int
+		costs, totalCost = cs.levelCosts(level, costs, pinned)
+		nbTasks := solution.nbTasks
+		if !pinned && float64(totalCost) > minWorkPerCPU {
+			nbTasks = singleLevelNbTasks(totalCost, minWorkPerCPU)
+		}
+		if nbTasks > len(level) {
+			nbTasks = len(level)
+		}
+		if nbTasks > 0 {
+			levelStart := solution.trace.Now()
+			var wg sync.WaitGroup
+			var errOnce sync.Once
+			var firstErr *UnsatisfiedConstraintError
+			splitLevel(level, costs, totalCost, nbTasks, func(task []int) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for _, i := range task {
+						if err := cs.solveConstraint(i, cs.Constraints[i], cs.fastSolve(i), solution, coefficientsNegInv); err != nil {
+							errOnce.Do(func() { firstErr = &UnsatisfiedConstraintError{CID: i, Err: err} })
+							return
+						}
+						if cs.IsHintWiring(i) {
+							continue
+						}
+						if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
+							errOnce.Do(func() {
+								if dID, ok := cs.MDebug[i]; ok {
+									errMsg := solution.logValue(cs.DebugInfo[dID])
+									firstErr = &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
+								} else {
+									firstErr = &UnsatisfiedConstraintError{CID: i, Err: err}
+								}
+							})
+							return
+						}
+					}
+				}()
+			})
+			wg.Wait()
+			if firstErr != nil {
+				return firstErr
+			}
+			solution.trace.AddLevel(0, len(level), levelStart)
+			return nil
+		}
+	}
+
+	// there are at least as many workers as tasks in a level, such that a worker exiting on
+	// an error of its task doesn't leave any task unsolved
+	nbWorkers := runtime.NumCPU()
+	if nbWorkers < solution.nbTasks {
+		nbWorkers = solution.nbTasks
+	}
+
 	var wg sync.WaitGroup
-	chTasks := make(chan []int, runtime.NumCPU())
-	chError := make(chan *UnsatisfiedConstraintError, runtime.NumCPU())
+	chTasks := make(chan []int, nbWorkers)
+	chError := make(chan *UnsatisfiedConstraintError, nbWorkers)
 
 	// start a worker pool
 	// each worker wait on chTasks
 	// a task is a slice of constraint indexes to be solved
-	for i := 0; i < runtime.NumCPU(); i++ {
+	for i := 0; i < nbWorkers; i++ {
 		go func() {
 			for t := range chTasks {
 				for _, i := range t {
 					// for each constraint in the task, solve it.
-					if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+					if err := cs.solveConstraint(i, cs.Constraints[i], cs.fastSolve(i), solution, coefficientsNegInv); err != nil {
 						chError <- &UnsatisfiedConstraintError{CID: i, Err: err}
 						wg.Done()
 						return
 					}
+					if cs.IsHintWiring(i) {
+						continue
+					}
 					if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
 						if dID, ok := cs.MDebug[i]; ok {
 							errMsg := solution.logValue(cs.DebugInfo[dID])
@@ -188,17 +548,36 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv fr.Ve
 	}()
 
 	// for each level, we push the tasks
-	for _, level := range cs.Levels {
+	for lID, level := range cs.Levels {
+		levelStart := solution.trace.Now()
+
+		var totalCost int
+		costs, totalCost = cs.levelCosts(level, costs, pinned)
 
-		// max CPU to use
-		maxCPU := float64(len(level)) / minWorkPerCPU
+		// number of tasks for this level is set to num cpus
+		// but if we don't have enough work for all our CPUS, it can be lower.
+		nbTasks := solution.nbTasks
+		if !pinned {
+			// max CPU to use
+			maxCPU := float64(totalCost) / minWorkPerCPU
+			nbTasks = runtime.NumCPU()
+			if maxTasks := int(math.Ceil(maxCPU)); nbTasks > maxTasks {
+				nbTasks = maxTasks
+			}
+		}
+		if nbTasks > len(level) {
+			nbTasks = len(level)
+		}
 
-		if maxCPU <= 1.0 {
+		if nbTasks <= 1 {
 			// we do it sequentially
 			for _, i := range level {
-				if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+				if err := cs.solveConstraint(i, cs.Constraints[i], cs.fastSolve(i), solution, coefficientsNegInv); err != nil {
 					return &UnsatisfiedConstraintError{CID: i, Err: err}
 				}
+				if cs.IsHintWiring(i) {
+					continue
+				}
 				if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
 					if dID, ok := cs.MDebug[i]; ok {
 						errMsg := solution.logValue(cs.DebugInfo[dID])
@@ -207,41 +586,16 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv fr.Ve
 					return &UnsatisfiedConstraintError{CID: i, Err: err}
 				}
 			}
+			solution.trace.AddLevel(lID, len(level), levelStart)
 			continue
 		}
 
-		// number of tasks for this level is set to num cpus
-		// but if we don't have enough work for all our CPUS, it can be lower.
-		nbTasks := runtime.NumCPU()
-		maxTasks := int(math.Ceil(maxCPU))
-		if nbTasks > maxTasks {
-			nbTasks = maxTasks
-		}
-		nbIterationsPerCpus := len(level) / nbTasks
-
-		// more CPUs than tasks: a CPU will work on exactly one iteration
-		// note: this depends on minWorkPerCPU constant
-		if nbIterationsPerCpus < 1 {
-			nbIterationsPerCpus = 1
-			nbTasks = len(level)
-		}
-
-		extraTasks := len(level) - (nbTasks * nbIterationsPerCpus)
-		extraTasksOffset := 0
-
-		for i := 0; i < nbTasks; i++ {
+		splitLevel(level, costs, totalCost, nbTasks, func(task []int) {
 			wg.Add(1)
-			_start := i*nbIterationsPerCpus + extraTasksOffset
-			_end := _start + nbIterationsPerCpus
-			if extraTasks > 0 {
-				_end++
-				extraTasks--
-				extraTasksOffset++
-			}
-			// since we're never pushing more than num CPU tasks
+			// since we're never pushing more tasks than workers
 			// we will never be blocked here
-			chTasks <- level[_start:_end]
-		}
+			chTasks <- task
+		})
 
 		// wait for the level to be done
 		wg.Wait()
@@ -249,11 +603,81 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv fr.Ve
 		if len(chError) > 0 {
 			return <-chError
 		}
+		solution.trace.AddLevel(lID, len(level), levelStart)
 	}
 
 	return nil
 }
 
+// estimated costs of solving and checking a constraint, relative to a linear one, to
+// balance the tasks of parallelSolve
+const (
+	costLinearConstraint = 1
+	costMulConstraint    = 2  // qM != 0
+	costHintConstraint   = 20 // may call a hint
+)
+
+// constraintCost returns the estimated cost of solving and checking the i-th constraint.
+// A constraint referencing a hint output is assumed to call the hint, although it may
+// only read the output solved by a previous level.
+//
+// The costs are computed once, when the constraints are added (or read), see levelCosts.
+func (cs *SparseR1CS) constraintCost(i int) uint8 {
+	c := &cs.Constraints[i]
+	for _, t := range [3]constraint.Term{c.L, c.R, c.O} {
+		if _, ok := cs.MHints[t.WireID()]; ok {
+			return costHintConstraint
+		}
+	}
+	if c.M[0].CoeffID() != constraint.CoeffIdZero {
+		return costMulConstraint
+	}
+	return costLinearConstraint
+}
+
+// levelCosts returns the costs of the constraints of level, see constraintCost, in buf
+// which is reused if large enough, and their sum. If unit is set, all the constraints cost 1,
+// for the task boundaries to only depend on the number of constraints.
+//
+// The costs are read from cs.costs, and computed only if they are not set (e.g. if the
+// constraints were not added through AddConstraint).
+func (cs *SparseR1CS) levelCosts(level []int, buf []int, unit bool) ([]int, int) {
+	buf = buf[:0]
+	total := 0
+	cached := len(cs.costs) == len(cs.Constraints)
+	for _, i := range level {
+		c := 1
+		switch {
+		case unit:
+		case cached:
+			c = int(cs.costs[i])
+		default:
+			c = int(cs.constraintCost(i))
+		}
+		buf = append(buf, c)
+		total += c
+	}
+	return buf, total
+}
+
+// splitLevel cuts level, of which the constraints have the given costs, in nbTasks
+// contiguous tasks of about the same cost, each holding at least one constraint, and calls
+// push with each of them in order. nbTasks must be in [1, len(level)].
+func splitLevel(level, costs []int, totalCost, nbTasks int, push func(task []int)) {
+	start, cost := 0, 0
+	for t := 0; t < nbTasks; t++ {
+		end := len(level)
+		if t != nbTasks-1 {
+			target := totalCost * (t + 1) / nbTasks
+			for end = start; end < len(level)-(nbTasks-t-1) && (end == start || cost < target); end++ {
+				cost += costs[end]
+			}
+		}
+		push(level[start:end])
+		start = end
+	}
+}
+
 // computeHints computes wires associated with a hint function, if any
 // if there is no remaining wire to solve, returns -1
 // else returns the wire position (L -> 0, R -> 1, O -> 2)
@@ -300,7 +724,14 @@ func (cs *SparseR1CS) computeHints(c constraint.SparseR1C, solution *solution) (
 // solveConstraint solve any unsolved wire in given constraint and update the solution
 // a SparseR1C may have up to one unsolved wire (excluding hints)
 // if it doesn't, then this function returns and does nothing
-func (cs *SparseR1CS) solveConstraint(c constraint.SparseR1C, solution *solution, coefficientsNegInv fr.Vector) error {
+//
+// fastSolve is the flag returned by fastSolveFlag(qO); if non zero, coefficientsNegInv is not used.
+// constraintID is the index of c, reported with the wires it solves beyond the bound of
+// backend.WithValueBoundCheck, if set.
+func (cs *SparseR1CS) solveConstraint(constraintID int, c constraint.SparseR1C, fastSolve int8, solution *solution, coefficientsNegInv fr.Vector) error {
+	if solution.valueBound != nil {
+		defer solution.checkValueBound(constraintID, solution.unsolvedWires(c.L.WireID(), c.R.WireID(), c.O.WireID()))
+	}
 
 	lro, err := cs.computeHints(c, solution)
 	if err != nil {
@@ -362,7 +793,21 @@ func (cs *SparseR1CS) solveConstraint(c constraint.SparseR1C, solution *solution
 
 	// o = - ((m0 * m1) + l + r + c.K) / c.O
 	o.Mul(&m0, &m1).Add(&o, &l).Add(&o, &r).Add(&o, &cs.Coefficients[c.K])
-	o.Mul(&o, &coefficientsNegInv[cID])
+	switch {
+	case fastSolve > 0:
+		// c.O == 2ᵏ
+		o.Neg(&o)
+		for i := int8(1); i < fastSolve; i++ {
+			o.Halve()
+		}
+	case fastSolve < 0:
+		// c.O == -2ᵏ
+		for i := int8(-1); i > fastSolve; i-- {
+			o.Halve()
+		}
+	default:
+		o.Mul(&o, &coefficientsNegInv[cID])
+	}
 
 	solution.set(vID, o)
 
@@ -382,6 +827,81 @@ func (cs *SparseR1CS) IsSolved(witness witness.Witness, opts ...backend.ProverOp
 	return err
 }
 
+// RunHints runs the solver hints on the given witness, without solving the constraints,
+// and returns the hint outputs indexed by wire ID.
+//
+// Hints are run in the order of their output wires, which is a dependency order: a hint
+// input which is the output of another hint is computed first. It returns an error if a
+// hint input depends on a wire which is solved by a constraint.
+func (cs *SparseR1CS) RunHints(witness fr.Vector, opt backend.ProverConfig) (map[int]fr.Element, error) {
+	nbVariables := cs.NbInternalVariables + len(cs.Secret) + len(cs.Public)
+
+	expectedWitnessSize := int(len(cs.Public) + len(cs.Secret))
+	if len(witness) != expectedWitnessSize {
+		return nil, fmt.Errorf(
+			"invalid witness size, got %d, expected %d = %d (public) + %d (secret)",
+			len(witness),
+			expectedWitnessSize,
+			len(cs.Public),
+			len(cs.Secret),
+		)
+	}
+
+	solution, err := newSolution(nbVariables, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients, &cs.System.SymbolTable)
+	if err != nil {
+		return nil, err
+	}
+	copy(solution.values, witness)
+	for i := 0; i < len(witness); i++ {
+		solution.solved[i] = true
+	}
+
+	wireIDs := make([]int, 0, len(cs.MHints))
+	for wID := range cs.MHints {
+		wireIDs = append(wireIDs, wID)
+	}
+	sort.Ints(wireIDs)
+
+	// checks that the inputs of a hint can be computed from the witness and other hints
+	checked := make(map[*constraint.Hint]struct{}, len(cs.MHints))
+	var checkInputs func(h *constraint.Hint) error
+	checkInputs = func(h *constraint.Hint) error {
+		if _, ok := checked[h]; ok {
+			return nil
+		}
+		checked[h] = struct{}{}
+		for _, in := range h.Inputs {
+			for _, t := range in {
+				if t.IsConstant() || solution.solved[t.WireID()] {
+					continue
+				}
+				parent, ok := cs.MHints[t.WireID()]
+				if !ok {
+					return fmt.Errorf("hint output wire %d depends on wire %d which is solved by a constraint", h.Wires[0], t.WireID())
+				}
+				if err := checkInputs(parent); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	outputs := make(map[int]fr.Element, len(cs.MHints))
+	for _, wID := range wireIDs {
+		h := cs.MHints[wID]
+		if err := checkInputs(h); err != nil {
+			return nil, err
+		}
+		if err := solution.solveWithHint(wID, h); err != nil {
+			return nil, err
+		}
+		outputs[wID] = solution.values[wID]
+	}
+
+	return outputs, nil
+}
+
 // GetConstraints return the list of SparseR1C and a coefficient resolver
 func (cs *SparseR1CS) GetConstraints() ([]constraint.SparseR1C, constraint.Resolver) {
 	return cs.Constraints, cs
@@ -408,6 +928,13 @@ func (cs *SparseR1CS) checkConstraint(c constraint.SparseR1C, solution *solution
 			cs.Coefficients[c.K].String(),
 		)
 	}
+	if c.RangeCheck {
+		// xb⋅(xb-1) == 0
+		b := &solution.values[c.R.WireID()]
+		if !b.IsZero() && !b.IsOne() {
+			return fmt.Errorf("range check gate: xb ∈ {0,1} → %s ∉ {0,1}", b.String())
+		}
+	}
 	return nil
 
 }
@@ -417,18 +944,179 @@ func (cs *SparseR1CS) GetNbCoefficients() int {
 	return len(cs.Coefficients)
 }
 
+// CoefficientOf returns the coefficient of the term t, in canonical form.
+// It panics if t.CoeffID() is not a valid index in cs.Coefficients.
+func (cs *SparseR1CS) CoefficientOf(t constraint.Term) big.Int {
+	var r big.Int
+	cs.Coefficients[t.CoeffID()].BigInt(&r)
+	return r
+}
+
+// UnusedCoefficients returns, in increasing order, the indexes in cs.Coefficients that
+// are referenced by no constraint, hint input or log entry. The reserved coefficients
+// (0, 1, 2, -1, -2) are always allocated and never reported.
+func (cs *SparseR1CS) UnusedCoefficients() []int {
+	used := make([]bool, len(cs.Coefficients))
+	useExpression := func(l constraint.LinearExpression) {
+		for _, t := range l {
+			used[t.CoeffID()] = true
+		}
+	}
+
+	for _, c := range cs.Constraints {
+		used[c.L.CoeffID()] = true
+		used[c.R.CoeffID()] = true
+		used[c.O.CoeffID()] = true
+		used[c.M[0].CoeffID()] = true
+		used[c.M[1].CoeffID()] = true
+		used[c.K] = true
+	}
+	for _, h := range cs.MHints {
+		for _, in := range h.Inputs {
+			useExpression(in)
+		}
+	}
+	for _, logs := range [][]constraint.LogEntry{cs.Logs, cs.DebugInfo} {
+		for i := range logs {
+			for _, l := range logs[i].ToResolve {
+				useExpression(l)
+			}
+		}
+	}
+
+	var unused []int
+	for i := constraint.CoeffIdMinusTwo + 1; i < len(used); i++ {
+		if !used[i] {
+			unused = append(unused, i)
+		}
+	}
+	return unused
+}
+
+// CompactCoefficients removes from cs.Coefficients the coefficients reported by
+// UnusedCoefficients, rewrites the coefficient IDs of the constraints, hint inputs and log
+// entries accordingly, and returns the number of coefficients removed. It is idempotent:
+// calling it again removes nothing and returns 0.
+//
+// The negated inverses returned by PrecomputeNegInvCoefficients before the compaction
+// must be computed again.
+func (cs *SparseR1CS) CompactCoefficients() int {
+	unused := cs.UnusedCoefficients()
+	if len(unused) == 0 {
+		return 0
+	}
+
+	// newID[i] is the ID of the i-th coefficient once compacted, if it is used
+	newID := make([]uint32, len(cs.Coefficients))
+	compacted := cs.Coefficients[:0]
+	for i, u := 0, 0; i < len(cs.Coefficients); i++ {
+		if u < len(unused) && unused[u] == i {
+			u++
+			continue
+		}
+		newID[i] = uint32(len(compacted))
+		compacted = append(compacted, cs.Coefficients[i])
+	}
+	cs.Coefficients = compacted
+	cs.mCoeffs = make(map[fr.Element]uint32, len(compacted))
+	for i := constraint.CoeffIdMinusTwo + 1; i < len(compacted); i++ {
+		cs.mCoeffs[compacted[i]] = uint32(i)
+	}
+
+	remap := func(t *constraint.Term) {
+		t.CID = newID[t.CID]
+	}
+	remapExpression := func(l constraint.LinearExpression) {
+		for j := range l {
+			remap(&l[j])
+		}
+	}
+	for i := range cs.Constraints {
+		c := &cs.Constraints[i]
+		remap(&c.L)
+		remap(&c.R)
+		remap(&c.O)
+		remap(&c.M[0])
+		remap(&c.M[1])
+		c.K = int(newID[c.K])
+	}
+	// the outputs of a hint share the same *constraint.Hint, of which the inputs must be
+	// rewritten once
+	remapped := make(map[*constraint.Hint]struct{}, len(cs.MHints))
+	for _, h := range cs.MHints {
+		if _, ok := remapped[h]; ok {
+			continue
+		}
+		remapped[h] = struct{}{}
+		for _, in := range h.Inputs {
+			remapExpression(in)
+		}
+	}
+	for _, logs := range [][]constraint.LogEntry{cs.Logs, cs.DebugInfo} {
+		for i := range logs {
+			for _, l := range logs[i].ToResolve {
+				remapExpression(l)
+			}
+		}
+	}
+
+	return len(unused)
+}
+
+// ConstantTerms returns the constant term qC of each constraint, in constraint order, as
+// canonical big integers in [0, r). The constants hardcoded in a circuit end up there.
+func (cs *SparseR1CS) ConstantTerms() []big.Int {
+	res := make([]big.Int, len(cs.Constraints))
+	for i, c := range cs.Constraints {
+		cs.Coefficients[c.K].BigInt(&res[i])
+	}
+	return res
+}
+
+// Equals returns true if cs and other have the same number of public, secret and internal
+// variables, the same constraints (coefficient and wire IDs) and the same coefficient values.
+// The solver metadata (levels, hints, logs and debug info) and the input names are not
+// compared.
+func (cs *SparseR1CS) Equals(other *SparseR1CS) bool {
+	if len(cs.Public) != len(other.Public) ||
+		len(cs.Secret) != len(other.Secret) ||
+		cs.NbInternalVariables != other.NbInternalVariables ||
+		len(cs.Constraints) != len(other.Constraints) ||
+		len(cs.Coefficients) != len(other.Coefficients) {
+		return false
+	}
+	for i := range cs.Constraints {
+		if cs.Constraints[i] != other.Constraints[i] {
+			return false
+		}
+	}
+	for i := range cs.Coefficients {
+		if !cs.Coefficients[i].Equal(&other.Coefficients[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // CurveID returns curve ID as defined in gnark-crypto (ecc.BLS12-377)
 func (cs *SparseR1CS) CurveID() ecc.ID {
 	return ecc.BLS12_377
 }
 
-// WriteTo encodes SparseR1CS into provided io.Writer using cbor
+// WriteTo encodes SparseR1CS into provided io.Writer: a version byte followed by its cbor encoding
+//
+// The encoding is deterministic: the core deterministic encoding sorts the keys of the
+// maps (MHints, MDebug, MHintsDependencies), and no part of the system has a custom
+// encoding iterating over a map.
 func (cs *SparseR1CS) WriteTo(w io.Writer) (int64, error) {
 	_w := ioutils.WriterCounter{W: w} // wraps writer to count the bytes written
 	enc, err := cbor.CoreDetEncOptions().EncMode()
 	if err != nil {
 		return 0, err
 	}
+	if _, err := _w.Write([]byte{serializationVersion}); err != nil {
+		return _w.N, err
+	}
 	encoder := enc.NewEncoder(&_w)
 
 	// encode our object
@@ -436,27 +1124,415 @@ func (cs *SparseR1CS) WriteTo(w io.Writer) (int64, error) {
 	return _w.N, err
 }
 
-// ReadFrom attempts to decode SparseR1CS from io.Reader using cbor
+// serializationVersion is the first byte written by WriteTo, before the cbor encoding of the
+// SparseR1CS. Streams without it (written before it was introduced) start with a cbor map header.
+// The circuit schema (System.Schema) is part of the encoding when set; a system without
+// schema encodes as it did before the schema was stored.
+const serializationVersion byte = 1
+
+// cborMajorTypeMap is the major type (3 most significant bits of the first byte) of a cbor map
+const cborMajorTypeMap = 5
+
+// maxSerializedElements is the default bound on the number of elements of the arrays and maps
+// of a serialized SparseR1CS
+const maxSerializedElements = 134217728
+
+// ReadFrom attempts to decode SparseR1CS from io.Reader using cbor.
+// It reads the current format, and the legacy one that had no version byte; other
+// versions are rejected.
 func (cs *SparseR1CS) ReadFrom(r io.Reader) (int64, error) {
+	return cs.ReadFromWithLimits(r, maxSerializedElements)
+}
+
+// ReadFromWithLimits is like ReadFrom but errors if an array or a map of the serialized
+// SparseR1CS (e.g. the constraints or the coefficients) declares more than maxElements elements.
+// It should be used with a tight bound when decoding untrusted input, to bound memory usage.
+//
+// maxElements must be in [16, 2147483647].
+func (cs *SparseR1CS) ReadFromWithLimits(r io.Reader, maxElements int) (int64, error) {
 	dm, err := cbor.DecOptions{
-		MaxArrayElements: 134217728,
-		MaxMapPairs:      134217728,
+		MaxArrayElements: maxElements,
+		MaxMapPairs:      maxElements,
 	}.DecMode()
 	if err != nil {
 		return 0, err
 	}
+
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return 0, err
+	}
+	nbHeaderBytes := int64(1)
+	switch {
+	case version[0] == serializationVersion:
+	case version[0]>>5 == cborMajorTypeMap:
+		// legacy format, with no version byte: the stream directly starts with the cbor map
+		// encoding the SparseR1CS struct, which is decoded as is.
+		r = io.MultiReader(bytes.NewReader(version[:]), r)
+		nbHeaderBytes = 0
+	default:
+		return 1, fmt.Errorf("unsupported SparseR1CS serialization version %d", version[0])
+	}
+
 	decoder := dm.NewDecoder(r)
 
 	// initialize coeff table
 	cs.CoeffTable = newCoeffTable(0)
 
 	if err := decoder.Decode(cs); err != nil {
-		return int64(decoder.NumBytesRead()), err
+		return nbHeaderBytes + int64(decoder.NumBytesRead()), err
 	}
 
 	if err := cs.CheckSerializationHeader(); err != nil {
-		return int64(decoder.NumBytesRead()), err
+		return nbHeaderBytes + int64(decoder.NumBytesRead()), err
+	}
+
+	cs.initFastSolve()
+	cs.initCosts()
+
+	return nbHeaderBytes + int64(decoder.NumBytesRead()), nil
+}
+
+// RevealSecret turns the secret input wire wireID into a public input, the last one.
+//
+// In the wire layout [public | secret | internal], the wire moves to the end of the public
+// inputs and the secret inputs preceding it shift by one; all the references to these
+// wires (constraints, hints, logs and debug info) are renumbered accordingly. The witness
+// of the resulting system is thus [public inputs, revealed value | other secret inputs].
+//
+// The circuit schema no longer matches the system and is cleared. Systems with a
+// commitment are not supported.
+func (cs *SparseR1CS) RevealSecret(wireID int) error {
+	nbPublic, nbSecret := len(cs.Public), len(cs.Secret)
+	if wireID < nbPublic || wireID >= nbPublic+nbSecret {
+		return fmt.Errorf("wire %d is not a secret input", wireID)
+	}
+	if cs.CommitmentInfo.Is() {
+		return errors.New("commitments are not supported")
+	}
+
+	w := uint32(wireID)
+	remap := func(vID uint32) uint32 {
+		switch {
+		case vID == w:
+			return uint32(nbPublic)
+		case vID >= uint32(nbPublic) && vID < w:
+			return vID + 1
+		default:
+			return vID
+		}
+	}
+	remapTerm := func(t *constraint.Term) {
+		if !t.IsConstant() {
+			t.VID = remap(t.VID)
+		}
+	}
+	// linear expressions of hints and logs may share their backing array
+	remappedTerms := make(map[*constraint.Term]struct{})
+	remapExpression := func(l constraint.LinearExpression) {
+		for i := range l {
+			if _, ok := remappedTerms[&l[i]]; ok {
+				continue
+			}
+			remappedTerms[&l[i]] = struct{}{}
+			remapTerm(&l[i])
+		}
+	}
+
+	for i := range cs.Constraints {
+		c := &cs.Constraints[i]
+		remapTerm(&c.L)
+		remapTerm(&c.R)
+		remapTerm(&c.O)
+		remapTerm(&c.M[0])
+		remapTerm(&c.M[1])
+	}
+
+	// several wires may map to the same hint
+	hints := make(map[int]*constraint.Hint, len(cs.MHints))
+	remapped := make(map[*constraint.Hint]struct{})
+	for wID, h := range cs.MHints {
+		hints[int(remap(uint32(wID)))] = h
+		if _, ok := remapped[h]; ok {
+			continue
+		}
+		remapped[h] = struct{}{}
+		for i := range h.Inputs {
+			remapExpression(h.Inputs[i])
+		}
+		for i := range h.Wires {
+			h.Wires[i] = int(remap(uint32(h.Wires[i])))
+		}
+	}
+	cs.MHints = hints
+
+	for _, logs := range [][]constraint.LogEntry{cs.Logs, cs.DebugInfo} {
+		for i := range logs {
+			for j := range logs[i].ToResolve {
+				remapExpression(logs[i].ToResolve[j])
+			}
+		}
+	}
+
+	name := cs.Secret[wireID-nbPublic]
+	cs.Secret = append(cs.Secret[:wireID-nbPublic], cs.Secret[wireID-nbPublic+1:]...)
+	cs.Public = append(cs.Public, name)
+	cs.Schema = nil
+
+	return nil
+}
+
+// Clone returns a deep copy of the constraint system, which can be mutated (see for
+// example RevealSecret) without affecting cs.
+func (cs *SparseR1CS) Clone() *SparseR1CS {
+	res := &SparseR1CS{
+		CoeffTable: cs.CoeffTable.clone(),
+		oFastSolve: append([]int8(nil), cs.oFastSolve...),
+		costs:      append([]uint8(nil), cs.costs...),
+	}
+	res.System = cs.System.CloneSystem()
+	res.Constraints = append([]constraint.SparseR1C(nil), cs.Constraints...)
+	if cs.MHintWiring != nil {
+		res.MHintWiring = make(map[int]struct{}, len(cs.MHintWiring))
+		for cID := range cs.MHintWiring {
+			res.MHintWiring[cID] = struct{}{}
+		}
+	}
+	return res
+}
+
+// ToR1CS lowers the SparseR1CS to an equivalent R1CS.
+//
+// Each gate qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xaxb) + qC == 0 becomes a single R1C, written such that
+// the wire the gate solves for appears only once, as the R1CS solver expects:
+//
+//	1 ⋅ (qL⋅xa + qR⋅xb + qO⋅xc + qC) == 0          if qM == 0
+//	xa ⋅ (qM⋅xb + qL) == -(qR⋅xb + qO⋅xc + qC)      if the gate solves xa (resp. xb)
+//	(qM⋅xa) ⋅ xb == -(qL⋅xa + qR⋅xb + qO⋅xc + qC)   otherwise
+//
+// R1CS linear expressions absorb the additions, so no auxiliary wire is needed. Constraint IDs
+// are preserved and wire IDs are shifted by one to make room for the R1CS constant wire; the
+// SparseR1CS witness is a valid R1CS witness and the R1CS solution is [1 | SparseR1CS solution].
+func (cs *SparseR1CS) ToR1CS() (*R1CS, error) {
+	if cs.CommitmentInfo.Is() {
+		return nil, errors.New("commitments are not supported")
+	}
+	for cID := range cs.Constraints {
+		if cs.Constraints[cID].RangeCheck {
+			// the boolean check would need a second R1C, and constraint IDs wouldn't be preserved
+			return nil, fmt.Errorf("constraint %d: range check gates are not supported", cID)
+		}
+	}
+
+	res := NewR1CS(len(cs.Constraints))
+	res.AddPublicVariable("1")
+	for i := 0; i < len(cs.Public); i++ {
+		res.AddPublicVariable(cs.Public[i])
+	}
+	for i := 0; i < len(cs.Secret); i++ {
+		res.AddSecretVariable(cs.Secret[i])
+	}
+	res.NbInternalVariables = cs.NbInternalVariables
+	res.SymbolTable = cs.SymbolTable
+	for k, v := range cs.MHintsDependencies {
+		res.MHintsDependencies[k] = v
+	}
+	for k, v := range cs.MDebug {
+		res.MDebug[k] = v
+	}
+
+	// term returns the R1CS term c⋅w where w is a SparseR1CS wire (-1 being the constant wire)
+	term := func(c *fr.Element, wireID int) constraint.Term {
+		var coeff constraint.Coeff
+		copy(coeff[:], c[:])
+		return res.MakeTerm(&coeff, wireID+1)
+	}
+	convert := func(l constraint.LinearExpression) constraint.LinearExpression {
+		r := make(constraint.LinearExpression, len(l))
+		for i, t := range l {
+			if t.IsConstant() {
+				r[i] = term(&cs.Coefficients[t.CID], -1)
+				r[i].MarkConstant()
+				continue
+			}
+			r[i] = term(&cs.Coefficients[t.CID], t.WireID())
+		}
+		return r
+	}
+	convertLogs := func(logs []constraint.LogEntry) []constraint.LogEntry {
+		r := make([]constraint.LogEntry, len(logs))
+		for i, l := range logs {
+			r[i] = l
+			r[i].ToResolve = make([]constraint.LinearExpression, len(l.ToResolve))
+			for j := range l.ToResolve {
+				r[i].ToResolve[j] = convert(l.ToResolve[j])
+			}
+		}
+		return r
+	}
+	res.Logs = convertLogs(cs.Logs)
+	res.DebugInfo = convertLogs(cs.DebugInfo)
+
+	// hints may output several wires, we keep the sharing of the *Hint
+	hints := make(map[*constraint.Hint]*constraint.Hint, len(cs.MHints))
+	for wID, h := range cs.MHints {
+		rh, ok := hints[h]
+		if !ok {
+			rh = &constraint.Hint{ID: h.ID}
+			rh.Inputs = make([]constraint.LinearExpression, len(h.Inputs))
+			for i := range h.Inputs {
+				rh.Inputs[i] = convert(h.Inputs[i])
+			}
+			rh.Wires = make([]int, len(h.Wires))
+			for i := range h.Wires {
+				rh.Wires[i] = h.Wires[i] + 1
+			}
+			hints[h] = rh
+		}
+		res.MHints[wID+1] = rh
+	}
+
+	// we replay the solver to know which wire each gate solves; inputs are set and
+	// hint outputs are solved when the solver first encounters them
+	nbInputs := len(cs.Public) + len(cs.Secret)
+	solved := make([]bool, nbInputs+cs.NbInternalVariables)
+	for i := 0; i < nbInputs; i++ {
+		solved[i] = true
+	}
+
+	type linearTerm struct {
+		coeff  fr.Element
+		wireID int
+	}
+	var lin []linearTerm
+	addTerm := func(coeff *fr.Element, wireID int) {
+		if coeff.IsZero() {
+			return
+		}
+		for i := range lin {
+			if lin[i].wireID == wireID {
+				lin[i].coeff.Add(&lin[i].coeff, coeff)
+				return
+			}
+		}
+		lin = append(lin, linearTerm{coeff: *coeff, wireID: wireID})
+	}
+	// takeTerm removes wireID from the linear part and returns its coefficient
+	takeTerm := func(wireID int) (coeff fr.Element) {
+		for i := range lin {
+			if lin[i].wireID == wireID {
+				coeff = lin[i].coeff
+				lin = append(lin[:i], lin[i+1:]...)
+				return
+			}
+		}
+		return
+	}
+	hasTerm := func(wireID int) bool {
+		for i := range lin {
+			if lin[i].wireID == wireID && !lin[i].coeff.IsZero() {
+				return true
+			}
+		}
+		return false
+	}
+	linearExpression := func(neg bool) constraint.LinearExpression {
+		r := make(constraint.LinearExpression, 0, len(lin))
+		for i := range lin {
+			if lin[i].coeff.IsZero() {
+				continue
+			}
+			c := lin[i].coeff
+			if neg {
+				c.Neg(&c)
+			}
+			r = append(r, term(&c, lin[i].wireID))
+		}
+		return r
+	}
+
+	var one fr.Element
+	one.SetOne()
+
+	for cID, c := range cs.Constraints {
+		var qM fr.Element
+		qM.Mul(&cs.Coefficients[c.M[0].CID], &cs.Coefficients[c.M[1].CID])
+		xa, xb := c.M[0].WireID(), c.M[1].WireID()
+
+		// linear part, the constant being on wire -1
+		lin = lin[:0]
+		addTerm(&cs.Coefficients[c.L.CID], c.L.WireID())
+		addTerm(&cs.Coefficients[c.R.CID], c.R.WireID())
+		addTerm(&cs.Coefficients[c.O.CID], c.O.WireID())
+		addTerm(&cs.Coefficients[c.K], -1)
+
+		// find the wire this gate solves, if any
+		toSolve := -1
+		markUnsolved := func(wireID int) error {
+			if wireID < 0 || solved[wireID] {
+				return nil
+			}
+			if _, ok := cs.MHints[wireID]; ok {
+				return nil
+			}
+			if toSolve != -1 && toSolve != wireID {
+				return fmt.Errorf("constraint %d has more than one unsolved wire", cID)
+			}
+			toSolve = wireID
+			return nil
+		}
+		for i := range lin {
+			if err := markUnsolved(lin[i].wireID); err != nil {
+				return nil, err
+			}
+		}
+		if !qM.IsZero() {
+			if err := markUnsolved(xa); err != nil {
+				return nil, err
+			}
+			if err := markUnsolved(xb); err != nil {
+				return nil, err
+			}
+		}
+		if toSolve != -1 {
+			solved[toSolve] = true
+		}
+		cantIsolate := fmt.Errorf("constraint %d: can't isolate the wire to solve", cID)
+
+		var r1c constraint.R1C
+		switch {
+		case qM.IsZero():
+			if toSolve != -1 && !hasTerm(toSolve) {
+				return nil, cantIsolate
+			}
+			r1c.L = constraint.LinearExpression{term(&one, -1)}
+			r1c.R = linearExpression(false)
+		case toSolve == -1 || (toSolve != xa && toSolve != xb):
+			if toSolve != -1 && !hasTerm(toSolve) {
+				return nil, cantIsolate
+			}
+			r1c.L = constraint.LinearExpression{term(&qM, xa)}
+			r1c.R = constraint.LinearExpression{term(&one, xb)}
+			r1c.O = linearExpression(true)
+		case xa == xb:
+			// qM⋅x² with x unsolved
+			return nil, cantIsolate
+		default:
+			x, y := xa, xb
+			if toSolve == xb {
+				x, y = xb, xa
+			}
+			qL := takeTerm(x)
+			r1c.L = constraint.LinearExpression{term(&one, x)}
+			r1c.R = constraint.LinearExpression{term(&qM, y)}
+			if !qL.IsZero() {
+				r1c.R = append(r1c.R, term(&qL, -1))
+			}
+			r1c.O = linearExpression(true)
+		}
+
+		res.AddConstraint(r1c)
 	}
 
-	return int64(decoder.NumBytesRead()), nil
+	return res, nil
 }
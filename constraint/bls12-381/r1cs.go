@@ -29,6 +29,7 @@ import (
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/internal/backend/ioutils"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
 	"github.com/consensys/gnark/profile"
 
@@ -79,26 +80,48 @@ func (cs *R1CS) AddConstraint(r1c constraint.R1C, debugInfo ...constraint.DebugI
 // witness = [publicWires | secretWires] (without the ONE_WIRE !)
 // returns  [publicWires | secretWires | internalWires ]
 func (cs *R1CS) Solve(witness, a, b, c fr.Vector, opt backend.ProverConfig) (fr.Vector, error) {
+	solution, err := cs.solve(witness, a, b, c, opt)
+	return solution.values, err
+}
+
+// SolveWithCheckpoints solves the system like Solve, and also returns the solved values of
+// the wires named by the backend.WithCheckpoints option of opt, or nil if it isn't set. If
+// solving fails, they are those of the checkpoints solved up to the failure.
+func (cs *R1CS) SolveWithCheckpoints(witness, a, b, c fr.Vector, opt backend.ProverConfig) (fr.Vector, map[string]fr.Element, error) {
+	solution, err := cs.solve(witness, a, b, c, opt)
+	return solution.values, solution.checkpoints(opt.Checkpoints), err
+}
+
+func (cs *R1CS) solve(witness, a, b, c fr.Vector, opt backend.ProverConfig) (solution, error) {
 	log := logger.Logger().With().Int("nbConstraints", len(cs.Constraints)).Str("backend", "groth16").Logger()
 
 	nbWires := len(cs.Public) + len(cs.Secret) + cs.NbInternalVariables
 	solution, err := newSolution(nbWires, opt.HintFunctions, cs.MHintsDependencies, cs.MHints, cs.Coefficients, &cs.System.SymbolTable)
 	if err != nil {
-		return make(fr.Vector, nbWires), err
+		return solution, err
 	}
+	if opt.SerialHints {
+		solution.hintMutex = new(sync.Mutex)
+	}
+	solution.checkHintOutputs = opt.HintOutputValidation
 	start := time.Now()
 
+	if err := checkCheckpoints(opt.Checkpoints, nbWires); err != nil {
+		log.Err(err).Send()
+		return solution, err
+	}
+
 	if len(witness) != len(cs.Public)-1+len(cs.Secret) { // - 1 for ONE_WIRE
 		err = fmt.Errorf("invalid witness size, got %d, expected %d = %d (public) + %d (secret)", len(witness), int(len(cs.Public)-1+len(cs.Secret)), len(cs.Public)-1, len(cs.Secret))
 		log.Err(err).Send()
-		return solution.values, err
+		return solution, err
 	}
 
 	// compute the wires and the a, b, c polynomials
 	if len(a) != len(cs.Constraints) || len(b) != len(cs.Constraints) || len(c) != len(cs.Constraints) {
 		err = errors.New("invalid input size: len(a, b, c) == len(Constraints)")
 		log.Err(err).Send()
-		return solution.values, err
+		return solution, err
 	}
 
 	solution.solved[0] = true // ONE_WIRE
@@ -116,24 +139,37 @@ func (cs *R1CS) Solve(witness, a, b, c fr.Vector, opt backend.ProverConfig) (fr.
 	// (or sooner, if a constraint is not satisfied)
 	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
 
-	if err := cs.parallelSolve(a, b, c, &solution); err != nil {
+	if opt.SolveTrace != nil {
+		solution.trace = constraint.NewSolveTrace()
+	}
+	err = cs.parallelSolve(a, b, c, &solution)
+	if opt.SolveTrace != nil {
+		if _, errTrace := solution.trace.WriteTo(opt.SolveTrace); errTrace != nil && err == nil {
+			err = fmt.Errorf("write solve trace: %w", errTrace)
+		}
+	}
+	if err != nil {
 		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
 			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
 		} else {
 			log.Err(err).Send()
 		}
-		return solution.values, err
+		return solution, err
 	}
 
 	// sanity check; ensure all wires are marked as "instantiated"
 	if !solution.isValid() {
-		log.Err(errors.New("solver didn't instantiate all wires")).Send()
-		panic("solver didn't instantiate all wires")
+		err := errors.New("solver didn't instantiate all wires")
+		log.Err(err).Send()
+		if opt.NoSolvePanic {
+			return solution, err
+		}
+		panic(err.Error())
 	}
 
 	log.Debug().Dur("took", time.Since(start)).Msg("constraint system solver done")
 
-	return solution.values, nil
+	return solution, nil
 }
 
 func (cs *R1CS) parallelSolve(a, b, c fr.Vector, solution *solution) error {
@@ -150,6 +186,35 @@ func (cs *R1CS) parallelSolve(a, b, c fr.Vector, solution *solution) error {
 	// then we check that the constraint is valid
 	// if a[i] * b[i] != c[i]; it means the constraint is not satisfied
 
+	// a single large level (fully parallel circuit) is split in contiguous chunks,
+	// without the worker pool
+	if len(cs.Levels) == 1 && float64(len(cs.Levels[0])) > minWorkPerCPU {
+		level := cs.Levels[0]
+		levelStart := solution.trace.Now()
+		var errOnce sync.Once
+		var firstErr *UnsatisfiedConstraintError
+		utils.Parallelize(len(level), func(start, end int) {
+			for _, i := range level[start:end] {
+				if err := cs.solveConstraint(cs.Constraints[i], solution, &a[i], &b[i], &c[i]); err != nil {
+					errOnce.Do(func() {
+						var debugInfo *string
+						if dID, ok := cs.MDebug[i]; ok {
+							debugInfo = new(string)
+							*debugInfo = solution.logValue(cs.DebugInfo[dID])
+						}
+						firstErr = &UnsatisfiedConstraintError{CID: i, Err: err, DebugInfo: debugInfo}
+					})
+					return
+				}
+			}
+		}, singleLevelNbTasks(len(level), minWorkPerCPU))
+		if firstErr != nil {
+			return firstErr
+		}
+		solution.trace.AddLevel(0, len(level), levelStart)
+		return nil
+	}
+
 	var wg sync.WaitGroup
 	chTasks := make(chan []int, runtime.NumCPU())
 	chError := make(chan *UnsatisfiedConstraintError, runtime.NumCPU())
@@ -185,7 +250,8 @@ func (cs *R1CS) parallelSolve(a, b, c fr.Vector, solution *solution) error {
 	}()
 
 	// for each level, we push the tasks
-	for _, level := range cs.Levels {
+	for lID, level := range cs.Levels {
+		levelStart := solution.trace.Now()
 
 		// max CPU to use
 		maxCPU := float64(len(level)) / minWorkPerCPU
@@ -202,6 +268,7 @@ func (cs *R1CS) parallelSolve(a, b, c fr.Vector, solution *solution) error {
 					return &UnsatisfiedConstraintError{CID: i, Err: err, DebugInfo: debugInfo}
 				}
 			}
+			solution.trace.AddLevel(lID, len(level), levelStart)
 			continue
 		}
 
@@ -244,6 +311,7 @@ func (cs *R1CS) parallelSolve(a, b, c fr.Vector, solution *solution) error {
 		if len(chError) > 0 {
 			return <-chError
 		}
+		solution.trace.AddLevel(lID, len(level), levelStart)
 	}
 
 	return nil
@@ -18,10 +18,17 @@ package cs_test
 
 import (
 	"bytes"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/internal/backend/circuits"
+	"math/big"
 	"reflect"
+	"runtime"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -130,6 +137,135 @@ func TestSerialization(t *testing.T) {
 	}
 }
 
+type schemaCircuit struct {
+	Root   frontend.Variable `gnark:"root"`
+	Square frontend.Variable `gnark:"square,public"`
+}
+
+func (circuit *schemaCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.Root, circuit.Root), circuit.Square)
+	return nil
+}
+
+func TestSerializationSchema(t *testing.T) {
+	for _, tc := range []struct {
+		builder frontend.NewBuilder
+		new     func() constraint.ConstraintSystem
+	}{
+		{r1cs.NewBuilder, func() constraint.ConstraintSystem { return &cs.R1CS{} }},
+		{scs.NewBuilder, func() constraint.ConstraintSystem { return &cs.SparseR1CS{} }},
+	} {
+		ccs, err := frontend.Compile(fr.Modulus(), tc.builder, &schemaCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		reconstructed := tc.new()
+
+		// the schema survives the round trip
+		var buf bytes.Buffer
+		if _, err := ccs.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("Schema")) {
+			t.Fatal("schema not encoded")
+		}
+		if _, err := reconstructed.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if reconstructed.GetSchema() == nil || !reflect.DeepEqual(reconstructed.GetSchema(), ccs.GetSchema()) {
+			t.Fatalf("schema mismatch: got %v, expected %v", reconstructed.GetSchema(), ccs.GetSchema())
+		}
+
+		// without schema, the field is not encoded
+		ccs.SetSchema(nil)
+		buf.Reset()
+		if _, err := ccs.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(buf.Bytes(), []byte("Schema")) {
+			t.Fatal("nil schema encoded")
+		}
+		reconstructed = tc.new()
+		if _, err := reconstructed.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if reconstructed.GetSchema() != nil {
+			t.Fatal("expected no schema")
+		}
+	}
+}
+
+func TestSparseR1CSToR1CS(t *testing.T) {
+	curve := ecc.BLS12_381
+
+	for name := range circuits.Circuits {
+		t.Run(name, func(t *testing.T) {
+			tc := circuits.Circuits[name]
+			supported := false
+			for _, c := range tc.Curves {
+				supported = supported || c == curve
+			}
+			if !supported {
+				t.Skip("circuit not supported on this curve")
+			}
+
+			ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, tc.Circuit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spr := ccs.(*cs.SparseR1CS)
+			if testing.Short() && spr.GetNbConstraints() > 50 {
+				return
+			}
+			r1cs, err := spr.ToR1CS()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r1cs.GetNbConstraints() != spr.GetNbConstraints() {
+				t.Fatal("constraint count mismatch")
+			}
+
+			opt, err := backend.NewProverConfig(backend.WithHints(tc.HintFunctions...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, assignment := range tc.ValidAssignments {
+				w, err := frontend.NewWitness(assignment, fr.Modulus())
+				if err != nil {
+					t.Fatal(err)
+				}
+				v := w.Vector().(fr.Vector)
+				sprSolution, err := spr.Solve(v, opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				a := make(fr.Vector, r1cs.GetNbConstraints())
+				b := make(fr.Vector, r1cs.GetNbConstraints())
+				c := make(fr.Vector, r1cs.GetNbConstraints())
+				r1csSolution, err := r1cs.Solve(v, a, b, c, opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// [1 | SparseR1CS solution]
+				if !r1csSolution[0].IsOne() || !reflect.DeepEqual(r1csSolution[1:], sprSolution) {
+					t.Fatal("solutions mismatch")
+				}
+			}
+
+			for _, assignment := range tc.InvalidAssignments {
+				w, err := frontend.NewWitness(assignment, fr.Modulus())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := r1cs.IsSolved(w, backend.WithHints(tc.HintFunctions...)); err == nil {
+					t.Fatal("invalid witness solved the R1CS")
+				}
+			}
+		})
+	}
+}
+
 const n = 10000
 
 type circuit struct {
@@ -166,3 +302,414 @@ func BenchmarkSolve(b *testing.B) {
 		_ = ccs.IsSolved(witness)
 	}
 }
+
+// BenchmarkSolveSparse solves a SparseR1CS where all gates have qO == -1
+func BenchmarkSolveSparse(b *testing.B) {
+
+	var c circuit
+	ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, &c)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var w circuit
+	w.X = 1
+	w.Y = 1
+	witness, err := frontend.NewWitness(&w, fr.Modulus())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ccs.IsSolved(witness)
+	}
+}
+
+// bitsCircuit has a single level of independent constraints X[i] ∈ {0, 1}
+type bitsCircuit struct {
+	X [200]frontend.Variable
+}
+
+func (circuit *bitsCircuit) Define(api frontend.API) error {
+	for i := range circuit.X {
+		api.AssertIsBoolean(circuit.X[i])
+	}
+	return nil
+}
+
+func TestSolveSingleLevel(t *testing.T) {
+	var good, bad bitsCircuit
+	for i := range good.X {
+		good.X[i] = i % 2
+		bad.X[i] = i % 2
+	}
+	bad.X[150] = 2
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(fr.Modulus(), newBuilder, &bitsCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var levels [][]int
+		switch tccs := ccs.(type) {
+		case *cs.R1CS:
+			levels = tccs.Levels
+		case *cs.SparseR1CS:
+			levels = tccs.Levels
+		}
+		if len(levels) != 1 {
+			t.Fatalf("expected a single level, got %d", len(levels))
+		}
+		w, err := frontend.NewWitness(&good, fr.Modulus())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ccs.IsSolved(w); err != nil {
+			t.Fatal(err)
+		}
+		w, err = frontend.NewWitness(&bad, fr.Modulus())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ccs.IsSolved(w); err == nil {
+			t.Fatal("expected an unsatisfied constraint")
+		}
+	}
+}
+
+// checkpointCircuit computes Z = (X*Y)², of which X*Y is the first internal wire
+type checkpointCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *checkpointCircuit) Define(api frontend.API) error {
+	xy := api.Mul(circuit.X, circuit.Y)
+	api.AssertIsEqual(api.Mul(xy, xy), circuit.Z)
+	return nil
+}
+
+func TestSolveWithCheckpoints(t *testing.T) {
+	w, err := frontend.NewWitness(&checkpointCircuit{X: 3, Y: 5, Z: 225}, fr.Modulus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness := w.Vector().(fr.Vector)
+	w, err = frontend.NewWitness(&checkpointCircuit{X: 3, Y: 5, Z: 224}, fr.Modulus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	badWitness := w.Vector().(fr.Vector)
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(fr.Modulus(), newBuilder, &checkpointCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		xy := ccs.GetNbPublicVariables() + ccs.GetNbSecretVariables()
+		solve := func(witness fr.Vector, opts ...backend.ProverOption) (map[string]fr.Element, error) {
+			opt, err := backend.NewProverConfig(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch tccs := ccs.(type) {
+			case *cs.R1CS:
+				n := tccs.GetNbConstraints()
+				_, checkpoints, err := tccs.SolveWithCheckpoints(witness, make(fr.Vector, n), make(fr.Vector, n), make(fr.Vector, n), opt)
+				return checkpoints, err
+			case *cs.SparseR1CS:
+				_, checkpoints, err := tccs.SolveWithCheckpoints(witness, opt)
+				return checkpoints, err
+			}
+			panic("unexpected constraint system")
+		}
+
+		var expected fr.Element
+		expected.SetUint64(15)
+		checkpoints, err := solve(witness, backend.WithCheckpoints(map[string]int{"xy": xy}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := checkpoints["xy"]; !ok || !v.Equal(&expected) {
+			t.Fatalf("checkpoint xy: expected %s, got %s", expected.String(), v.String())
+		}
+
+		// the checkpoints solved before the unsatisfied constraint are returned
+		checkpoints, err = solve(badWitness, backend.WithCheckpoints(map[string]int{"xy": xy}))
+		if err == nil {
+			t.Fatal("expected an unsatisfied constraint")
+		}
+		if v, ok := checkpoints["xy"]; !ok || !v.Equal(&expected) {
+			t.Fatalf("checkpoint xy: expected %s, got %s", expected.String(), v.String())
+		}
+
+		// no option, no checkpoint
+		if checkpoints, err := solve(witness); err != nil || checkpoints != nil {
+			t.Fatalf("expected no checkpoint, got %v (%v)", checkpoints, err)
+		}
+
+		// out of the system
+		if _, err := solve(witness, backend.WithCheckpoints(map[string]int{"out": ccs.GetNbInternalVariables() + xy})); err == nil {
+			t.Fatal("expected an error for a wire out of the system")
+		}
+		if _, err := backend.NewProverConfig(backend.WithCheckpoints(map[string]int{"out": -1})); err == nil {
+			t.Fatal("expected an error for a negative wire ID")
+		}
+	}
+}
+
+// squareBitsCircuit has a level of squares X[i]², followed by a level of constraints
+// X[i]² ∈ {0, 1}
+type squareBitsCircuit struct {
+	X [200]frontend.Variable
+}
+
+func (circuit *squareBitsCircuit) Define(api frontend.API) error {
+	for i := range circuit.X {
+		api.AssertIsBoolean(api.Mul(circuit.X[i], circuit.X[i]))
+	}
+	return nil
+}
+
+func TestSolveSolverTasks(t *testing.T) {
+	var goodBits, badBits bitsCircuit
+	var goodSquares, badSquares squareBitsCircuit
+	for i := range goodBits.X {
+		goodBits.X[i], badBits.X[i] = i%2, i%2
+		goodSquares.X[i], badSquares.X[i] = i%2, i%2
+	}
+	badBits.X[150], badSquares.X[150] = 2, 2
+
+	for _, tc := range []struct {
+		circuit, good, bad frontend.Circuit
+	}{
+		{&bitsCircuit{}, &goodBits, &badBits},
+		{&squareBitsCircuit{}, &goodSquares, &badSquares},
+	} {
+		ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, tc.circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		good, err := frontend.NewWitness(tc.good, fr.Modulus())
+		if err != nil {
+			t.Fatal(err)
+		}
+		bad, err := frontend.NewWitness(tc.bad, fr.Modulus())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// more tasks than CPUs, or than constraints in a level, are fine
+		for _, n := range []int{1, 3, 7, 1000} {
+			if err := ccs.IsSolved(good, backend.WithSolverTasks(n)); err != nil {
+				t.Fatalf("%d tasks: %v", n, err)
+			}
+			if err := ccs.IsSolved(bad, backend.WithSolverTasks(n)); err == nil {
+				t.Fatalf("%d tasks: expected an unsatisfied constraint", n)
+			}
+		}
+	}
+	if _, err := backend.NewProverConfig(backend.WithSolverTasks(0)); err == nil {
+		t.Fatal("expected an error for 0 tasks")
+	}
+}
+
+// solveCircuit is a synthetic circuit with len(widths) levels of independent
+// multiplications; level i contains widths[i] constraints, each depending on
+// two wires of level i-1.
+type solveCircuit struct {
+	widths []int
+	X      []frontend.Variable
+}
+
+func (circuit *solveCircuit) Define(api frontend.API) error {
+	prev := circuit.X
+	for _, width := range circuit.widths {
+		next := make([]frontend.Variable, width)
+		for j := range next {
+			next[j] = api.Mul(prev[j%len(prev)], prev[(j+1)%len(prev)])
+		}
+		prev = next
+	}
+	return nil
+}
+
+// newSolveCircuit returns the circuit and a valid assignment for the given level widths.
+func newSolveCircuit(widths []int) (*solveCircuit, *solveCircuit) {
+	c := solveCircuit{widths: widths, X: make([]frontend.Variable, widths[0])}
+	w := solveCircuit{widths: widths, X: make([]frontend.Variable, widths[0])}
+	for i := range w.X {
+		w.X[i] = i + 2
+	}
+	return &c, &w
+}
+
+// repeat returns a slice of n times width.
+func repeat(width, n int) []int {
+	widths := make([]int, n)
+	for i := range widths {
+		widths[i] = width
+	}
+	return widths
+}
+
+// BenchmarkSolveSynthetic measures the SparseR1CS solver throughput on synthetic
+// constraint systems of ~2¹⁴ constraints with different level width distributions.
+func BenchmarkSolveSynthetic(b *testing.B) {
+	benchmarkSolveSynthetic(b)
+}
+
+// BenchmarkSolveSyntheticPinned is BenchmarkSolveSynthetic with the levels split in 4 tasks
+// of the same number of constraints (see backend.WithSolverTasks), whatever the number of
+// CPUs and the cost of the constraints. The work distribution is then the same on each run
+// and on each machine.
+func BenchmarkSolveSyntheticPinned(b *testing.B) {
+	benchmarkSolveSynthetic(b, backend.WithSolverTasks(4))
+}
+
+func benchmarkSolveSynthetic(b *testing.B, opts ...backend.ProverOption) {
+	benchmarks := []struct {
+		name   string
+		widths []int
+	}{
+		{"narrow", repeat(4, 1<<12)},
+		{"wide", repeat(1<<10, 16)},
+		{"mixed", append(repeat(1<<12, 2), repeat(16, 1<<9)...)},
+		{"single-level", []int{1 << 14}},
+	}
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			c, w := newSolveCircuit(bb.widths)
+			ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, c)
+			if err != nil {
+				b.Fatal(err)
+			}
+			witness, err := frontend.NewWitness(w, fr.Modulus())
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := ccs.IsSolved(witness, opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(ccs.GetNbConstraints()), "constraints")
+		})
+	}
+}
+
+// inverseHint returns the inverse of its input, with an exponentiation, to stand for an
+// expensive hint
+func inverseHint(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Exp(inputs[0], new(big.Int).Sub(q, big.NewInt(2)), q)
+	return nil
+}
+
+// mixedCostCircuit has a single level starting with a few hint calls, followed by many
+// linear constraints
+type mixedCostCircuit struct {
+	X [1 << 12]frontend.Variable
+}
+
+func (circuit *mixedCostCircuit) Define(api frontend.API) error {
+	for i := 0; i < 16; i++ {
+		inv, err := api.Compiler().NewHint(inverseHint, 1, circuit.X[i])
+		if err != nil {
+			return err
+		}
+		api.Add(inv[0], circuit.X[i])
+	}
+	for i := 16; i < len(circuit.X); i++ {
+		api.Add(circuit.X[i], circuit.X[i-1])
+	}
+	return nil
+}
+
+// BenchmarkSolveMixedCost measures the SparseR1CS solver on a level mixing expensive hint
+// constraints and cheap linear ones, which the solver balances between its tasks by their
+// estimated cost rather than their number. The split=count baseline pins as many tasks as
+// CPUs, with boundaries depending only on the number of constraints (see
+// backend.WithSolverTasks).
+func BenchmarkSolveMixedCost(b *testing.B) {
+	ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, &mixedCostCircuit{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var w mixedCostCircuit
+	for i := range w.X {
+		w.X[i] = i + 1
+	}
+	witness, err := frontend.NewWitness(&w, fr.Modulus())
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, split := range []string{"cost", "count"} {
+		opts := []backend.ProverOption{backend.WithHints(inverseHint)}
+		if split == "count" {
+			opts = append(opts, backend.WithSolverTasks(runtime.NumCPU()))
+		}
+		b.Run("split="+split, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := ccs.IsSolved(witness, opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func pairHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Set(inputs[0])
+	outputs[1].Set(inputs[0])
+	return nil
+}
+
+// hintWiringCircuit duplicates each X[i] with a hint; the two outputs being equal is pure
+// hint wiring, re-verified by the first output being equal to X[i]
+type hintWiringCircuit struct {
+	mark bool
+	X    [1 << 10]frontend.Variable
+}
+
+func (circuit *hintWiringCircuit) Define(api frontend.API) error {
+	for i := range circuit.X {
+		out, err := api.Compiler().NewHint(pairHint, 2, circuit.X[i])
+		if err != nil {
+			return err
+		}
+		if circuit.mark {
+			api.Compiler().MarkHintWiring(out...)
+		}
+		api.AssertIsEqual(out[0], out[1])
+		api.AssertIsEqual(out[0], circuit.X[i])
+	}
+	return nil
+}
+
+// BenchmarkSolveHintWiring measures the SparseR1CS solver on a hint-heavy circuit, with and
+// without its wiring constraints tagged (see frontend.Compiler.MarkHintWiring).
+func BenchmarkSolveHintWiring(b *testing.B) {
+	var w hintWiringCircuit
+	for i := range w.X {
+		w.X[i] = i + 1
+	}
+	witness, err := frontend.NewWitness(&w, fr.Modulus())
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, mark := range []bool{false, true} {
+		b.Run(fmt.Sprintf("mark=%t", mark), func(b *testing.B) {
+			ccs, err := frontend.Compile(fr.Modulus(), scs.NewBuilder, &hintWiringCircuit{mark: mark})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := ccs.IsSolved(witness, backend.WithHints(pairHint)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -23,9 +23,12 @@ import (
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/debug"
 	"github.com/rs/zerolog"
+	"math"
 	"math/big"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
@@ -40,6 +43,25 @@ type solution struct {
 	mHintsFunctions      map[hint.ID]hint.Function // maps hintID to hint function
 	mHints               map[int]*constraint.Hint  // maps wireID to hint
 	st                   *debug.SymbolTable
+
+	// hintMutex, if set, serializes the hint function calls (see backend.WithSerialHints)
+	hintMutex *sync.Mutex
+
+	// trace, if set, records the solving time of the levels (see backend.WithSolveTrace)
+	trace *constraint.SolveTrace
+
+	// checkHintOutputs, if set, rejects hint outputs which are not reduced modulo the
+	// field modulus (see backend.WithHintOutputValidation)
+	checkHintOutputs bool
+
+	// valueBound, if set, is the bound beyond which the solved wires are reported to
+	// onValueBound (see backend.WithValueBoundCheck)
+	valueBound   *big.Int
+	onValueBound func(constraintID, wireID int, v *big.Int)
+
+	// nbTasks, if set, pins the number of tasks each level is split in by the SparseR1CS
+	// solver (see backend.WithSolverTasks)
+	nbTasks int
 }
 
 func newSolution(nbWires int, hintFunctions map[hint.ID]hint.Function, hintsDependencies map[hint.ID]string, mHints map[int]*constraint.Hint, coefficients []fr.Element, st *debug.SymbolTable) (solution, error) {
@@ -78,6 +100,50 @@ func (s *solution) set(id int, value fr.Element) {
 	// s.nbSolved++
 }
 
+// unsolvedWires returns the distinct wires among wIDs which are not solved yet, a hint output
+// standing for all the outputs of its hint, for checkValueBound to report those solved by a
+// constraint
+func (s *solution) unsolvedWires(wIDs ...int) []int {
+	var res []int
+	add := func(wID int) {
+		for _, w := range res {
+			if w == wID {
+				return
+			}
+		}
+		res = append(res, wID)
+	}
+	for _, wID := range wIDs {
+		if s.solved[wID] {
+			continue
+		}
+		if h, ok := s.mHints[wID]; ok {
+			for _, w := range h.Wires {
+				add(w)
+			}
+		} else {
+			add(wID)
+		}
+	}
+	return res
+}
+
+// checkValueBound calls onValueBound for each solved wire among wIDs, as returned by
+// unsolvedWires before solving the constraint cID, of which the value exceeds valueBound
+func (s *solution) checkValueBound(cID int, wIDs []int) {
+	var v big.Int
+	for _, wID := range wIDs {
+		if !s.solved[wID] {
+			// the constraint failed before solving it, or doesn't reference it
+			continue
+		}
+		s.values[wID].BigInt(&v)
+		if v.Cmp(s.valueBound) > 0 {
+			s.onValueBound(cID, wID, new(big.Int).Set(&v))
+		}
+	}
+}
+
 func (s *solution) isValid() bool {
 	return int(s.nbSolved) == len(s.values)
 }
@@ -198,7 +264,21 @@ func (s *solution) solveWithHint(vID int, h *constraint.Hint) error {
 		v.BigInt(inputs[i])
 	}
 
+	if s.hintMutex != nil {
+		s.hintMutex.Lock()
+	}
 	err := f(q, inputs, outputs)
+	if s.hintMutex != nil {
+		s.hintMutex.Unlock()
+	}
+
+	if s.checkHintOutputs && err == nil {
+		for i := range outputs {
+			if outputs[i].Sign() < 0 || outputs[i].Cmp(q) >= 0 {
+				return fmt.Errorf("hint %s returned %s for output %d (wire %d), which is not reduced modulo %s", hint.Name(f), outputs[i].String(), i, h.Wires[i], q.String())
+			}
+		}
+	}
 
 	var v fr.Element
 	for i := range outputs {
@@ -281,6 +361,32 @@ func (s *solution) logValue(log constraint.LogEntry) string {
 	return fmt.Sprintf(log.Format, toResolve...)
 }
 
+// checkCheckpoints returns an error if a wire ID of checkpoints (name → wire ID, see
+// backend.WithCheckpoints) is out of the nbWires wires of the system
+func checkCheckpoints(checkpoints map[string]int, nbWires int) error {
+	for name, wID := range checkpoints {
+		if wID < 0 || wID >= nbWires {
+			return fmt.Errorf("checkpoint %q: wire %d out of the %d wires of the system", name, wID, nbWires)
+		}
+	}
+	return nil
+}
+
+// checkpoints returns the values of the solved wires among checkpoints (name → wire ID), or
+// nil if checkpoints is nil. Wires the solver didn't reach are not in the map.
+func (s *solution) checkpoints(checkpoints map[string]int) map[string]fr.Element {
+	if checkpoints == nil {
+		return nil
+	}
+	res := make(map[string]fr.Element, len(checkpoints))
+	for name, wID := range checkpoints {
+		if wID < len(s.solved) && s.solved[wID] {
+			res[name] = s.values[wID]
+		}
+	}
+	return res
+}
+
 // UnsatisfiedConstraintError wraps an error with useful metadata on the unsatisfied constraint
 type UnsatisfiedConstraintError struct {
 	Err       error
@@ -294,3 +400,14 @@ func (r *UnsatisfiedConstraintError) Error() string {
 	}
 	return fmt.Sprintf("constraint #%d is not satisfied: %s", r.CID, r.Err.Error())
 }
+
+// singleLevelNbTasks returns the number of tasks a level of n independent constraints
+// is split in by the solvers, such that each task holds at least minWorkPerCPU constraints.
+// The SparseR1CS solver passes the estimated cost of the level instead of n.
+func singleLevelNbTasks(n int, minWorkPerCPU float64) int {
+	nbTasks := runtime.NumCPU()
+	if maxTasks := int(math.Ceil(float64(n) / minWorkPerCPU)); nbTasks > maxTasks {
+		nbTasks = maxTasks
+	}
+	return nbTasks
+}
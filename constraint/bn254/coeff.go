@@ -46,6 +46,18 @@ func newCoeffTable(capacity int) CoeffTable {
 
 }
 
+// clone returns a deep copy of the coefficient table
+func (ct *CoeffTable) clone() CoeffTable {
+	r := CoeffTable{
+		Coefficients: append([]fr.Element(nil), ct.Coefficients...),
+		mCoeffs:      make(map[fr.Element]uint32, len(ct.mCoeffs)),
+	}
+	for k, v := range ct.mCoeffs {
+		r.mCoeffs[k] = v
+	}
+	return r
+}
+
 func (ct *CoeffTable) MakeTerm(coeff *constraint.Coeff, variableID int) constraint.Term {
 	c := (*fr.Element)(coeff[:])
 	var cID uint32
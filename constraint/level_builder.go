@@ -82,3 +82,24 @@ func (system *System) processWire(wireID uint32, maxLevel *int) {
 	// it's the missing wire
 	system.lbOutputs = append(system.lbOutputs, wireID)
 }
+
+// LevelsCapped returns system.Levels where each level wider than maxWidth is split
+// into sequential sub-levels of at most maxWidth constraints. Constraints within a
+// level are independent, so the sub-levels can be solved one after the other in any
+// order; this bounds the number of constraints solved concurrently.
+//
+// If maxWidth <= 0, system.Levels is returned as is.
+func (system *System) LevelsCapped(maxWidth int) [][]int {
+	if maxWidth <= 0 {
+		return system.Levels
+	}
+	levels := make([][]int, 0, len(system.Levels))
+	for _, level := range system.Levels {
+		for len(level) > maxWidth {
+			levels = append(levels, level[:maxWidth:maxWidth])
+			level = level[maxWidth:]
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
@@ -16,6 +16,8 @@ package constraint
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -33,6 +35,10 @@ type SparseR1CS interface {
 	// See StringBuilder for more info.
 	// ! this is an experimental API.
 	GetConstraints() ([]SparseR1C, Resolver)
+
+	// TagHintWiring tags the constraints of which all the wires are among the given hint
+	// outputs as pure hint wiring, see frontend.Compiler.MarkHintWiring.
+	TagHintWiring(wires map[int]struct{}) (int, error)
 }
 
 // R1CS describes a set of SparseR1C constraint
@@ -40,6 +46,10 @@ type SparseR1CS interface {
 type SparseR1CSCore struct {
 	System
 	Constraints []SparseR1C
+
+	// constraints tagged as pure hint wiring, solved but not checked by the solver; not
+	// encoded if empty, which leaves the encoding of the other systems unchanged
+	MHintWiring map[int]struct{} `cbor:",omitempty"`
 }
 
 // GetNbConstraints returns the number of constraints
@@ -51,6 +61,53 @@ func (cs *SparseR1CSCore) UpdateLevel(cID int, c Iterable) {
 	cs.updateLevel(cID, c)
 }
 
+// TagHintWiring tags as pure hint wiring the constraints of which all the wires with a
+// non-zero coefficient are among the hint outputs wires, and returns the number of
+// constraints tagged. The solver solves the tagged constraints but doesn't check them, see
+// frontend.Compiler.MarkHintWiring. It returns an error if a wire is not a hint output.
+func (cs *SparseR1CSCore) TagHintWiring(wires map[int]struct{}) (int, error) {
+	for wID := range wires {
+		if _, ok := cs.MHints[wID]; !ok {
+			return 0, fmt.Errorf("wire %d marked as hint wiring is not a hint output", wID)
+		}
+	}
+	isMarked := func(t Term) bool {
+		_, ok := wires[t.WireID()]
+		return ok
+	}
+
+	nbTagged := 0
+	for cID, c := range cs.Constraints {
+		if c.RangeCheck {
+			// the boolean check of the gate is not wiring
+			continue
+		}
+		if (c.L.CoeffID() != CoeffIdZero || c.M[0].CoeffID() != CoeffIdZero) && !isMarked(c.L) ||
+			(c.R.CoeffID() != CoeffIdZero || c.M[1].CoeffID() != CoeffIdZero) && !isMarked(c.R) ||
+			c.O.CoeffID() != CoeffIdZero && !isMarked(c.O) {
+			continue
+		}
+		if cs.MHintWiring == nil {
+			cs.MHintWiring = make(map[int]struct{})
+		}
+		if _, ok := cs.MHintWiring[cID]; !ok {
+			cs.MHintWiring[cID] = struct{}{}
+			nbTagged++
+		}
+	}
+	return nbTagged, nil
+}
+
+// IsHintWiring returns true if the constraint cID is tagged as pure hint wiring, see
+// TagHintWiring.
+func (cs *SparseR1CSCore) IsHintWiring(cID int) bool {
+	if len(cs.MHintWiring) == 0 {
+		return false
+	}
+	_, ok := cs.MHintWiring[cID]
+	return ok
+}
+
 func (system *SparseR1CSCore) CheckUnconstrainedWires() error {
 	// TODO @gbotrel add unit test for that.
 
@@ -129,6 +186,313 @@ func (system *SparseR1CSCore) CheckUnconstrainedWires() error {
 	return errors.New(sbb.String())
 }
 
+// ConnectedComponents partitions the constraints according to the connected components of
+// the wire dependency graph: two constraints are in the same component if they (transitively)
+// share a wire, public inputs included. Wires computed by a hint are linked to the hint inputs.
+//
+// Components are ordered by their first constraint, and each component lists its constraint
+// indexes in increasing order.
+func (system *SparseR1CSCore) ConnectedComponents() [][]int {
+	nbWires := system.GetNbPublicVariables() + system.GetNbSecretVariables() + system.GetNbInternalVariables()
+
+	// union-find over the wires
+	parent := make([]int, nbWires)
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(x int) int {
+		for parent[x] != x {
+			parent[x] = parent[parent[x]]
+			x = parent[x]
+		}
+		return x
+	}
+	union := func(x, y int) {
+		rx, ry := find(x), find(y)
+		if rx != ry {
+			parent[ry] = rx
+		}
+	}
+
+	for wID, h := range system.MHints {
+		for _, in := range h.Inputs {
+			for _, t := range in {
+				if !t.IsConstant() {
+					union(wID, t.WireID())
+				}
+			}
+		}
+	}
+
+	// wire of each constraint, -1 if the constraint has no wire
+	cWire := make([]int, len(system.Constraints))
+	for cID, c := range system.Constraints {
+		cWire[cID] = -1
+		for _, t := range [...]Term{c.L, c.R, c.M[0], c.M[1], c.O} {
+			if t.CoeffID() == CoeffIdZero {
+				continue
+			}
+			if cWire[cID] == -1 {
+				cWire[cID] = t.WireID()
+			} else {
+				union(cWire[cID], t.WireID())
+			}
+		}
+	}
+
+	var components [][]int
+	componentOf := make(map[int]int) // root wire -> index in components
+	for cID, wID := range cWire {
+		if wID == -1 {
+			// constant constraint, on its own
+			components = append(components, []int{cID})
+			continue
+		}
+		root := find(wID)
+		if i, ok := componentOf[root]; ok {
+			components[i] = append(components[i], cID)
+			continue
+		}
+		componentOf[root] = len(components)
+		components = append(components, []int{cID})
+	}
+
+	return components
+}
+
+// WireIncidence returns, for each wire referenced by a constraint, the indexes of the
+// constraints referencing it (in L, R, O or M with a non zero coefficient), in increasing
+// order. Wires which aren't referenced, for example hint outputs only used as hint inputs,
+// have no entry.
+//
+// The map is built in a pass over the constraints, so callers should keep it rather than
+// call WireIncidence per wire.
+func (system *SparseR1CSCore) WireIncidence() map[int][]int {
+	incidence := make(map[int][]int)
+	for cID, c := range system.Constraints {
+		for _, t := range [...]Term{c.L, c.R, c.M[0], c.M[1], c.O} {
+			if t.CoeffID() == CoeffIdZero {
+				continue
+			}
+			wID := t.WireID()
+			if cIDs := incidence[wID]; len(cIDs) == 0 || cIDs[len(cIDs)-1] != cID {
+				incidence[wID] = append(cIDs, cID)
+			}
+		}
+	}
+	return incidence
+}
+
+// MaxFanInWire returns the wire consumed by the most constraints, and their number, see
+// WireIncidence. The constraint solving an internal wire which is not a hint output, the
+// first referencing it, is not counted as consuming it; the inputs and the hint outputs are
+// consumed by all the constraints referencing them. Ties go to the smallest wire ID. It
+// returns -1, 0 if no wire is referenced.
+//
+// The constraints consuming a hub wire can't be solved before it, so a large fan-in tells
+// which intermediate value to restructure for the solver levels to be wider.
+func (system *SparseR1CSCore) MaxFanInWire() (wireID, fanIn int) {
+	nbInputs := system.GetNbPublicVariables() + system.GetNbSecretVariables()
+	wireID = -1
+	for wID, cIDs := range system.WireIncidence() {
+		n := len(cIDs)
+		if _, isHint := system.MHints[wID]; wID >= nbInputs && !isHint {
+			n--
+		}
+		if n > fanIn || (n == fanIn && wireID != -1 && wID < wireID) {
+			wireID, fanIn = wID, n
+		}
+	}
+	return
+}
+
+// PublicWireIDs returns the IDs of the public wires, that is [0, NbPublicVariables), in
+// the order of the public witness. The name of the public wire wID, as declared in the
+// circuit (see frontend/schema), is returned by PublicWireNames; after solving, its
+// value is solution[wID].
+func (system *SparseR1CSCore) PublicWireIDs() []int {
+	ids := make([]int, system.GetNbPublicVariables())
+	for i := range ids {
+		ids[i] = i
+	}
+	return ids
+}
+
+// PublicWireNames maps the IDs of the public wires (see PublicWireIDs) to their names as
+// declared in the circuit (see frontend/schema), to extract named results from a solution.
+// A wire without a name, in a system built without schema, is not in the map.
+func (system *SparseR1CSCore) PublicWireNames() map[int]string {
+	names := make(map[int]string, len(system.Public))
+	for wID, name := range system.Public {
+		if name != "" {
+			names[wID] = name
+		}
+	}
+	return names
+}
+
+// HintLevels returns the indexes of the levels (see System.Levels) containing at least
+// one constraint that, when solved, calls a hint. Hints whose outputs are only used as
+// inputs of other hints are solved along with those, and are counted in their level.
+func (system *SparseR1CSCore) HintLevels() []int {
+	solved := make(map[*Hint]struct{})
+	var markSolved func(h *Hint)
+	markSolved = func(h *Hint) {
+		solved[h] = struct{}{}
+		for _, in := range h.Inputs {
+			for _, t := range in {
+				if t.IsConstant() {
+					continue
+				}
+				if hIn, ok := system.MHints[t.WireID()]; ok {
+					if _, ok := solved[hIn]; !ok {
+						markSolved(hIn)
+					}
+				}
+			}
+		}
+	}
+
+	var levels []int
+	for lID, level := range system.Levels {
+		hasHint := false
+		for _, cID := range level {
+			wireIterator := system.Constraints[cID].WireIterator()
+			for wID := wireIterator(); wID != -1; wID = wireIterator() {
+				h, ok := system.MHints[wID]
+				if !ok {
+					continue
+				}
+				// the first constraint using a hint output solves the hint
+				if _, ok := solved[h]; !ok {
+					markSolved(h)
+					hasHint = true
+				}
+			}
+		}
+		if hasHint {
+			levels = append(levels, lID)
+		}
+	}
+	return levels
+}
+
+// WriteDOT writes the dependency graph of the constraints in Graphviz DOT format: there
+// is a node per constraint, filled with a color depending on its level, and an edge from
+// the constraint solving a wire to each constraint using it. Wires solved by nested hints
+// (hints only used as inputs of other hints) are not linked.
+//
+// If maxNodes > 0 and the system has more constraints, only the maxNodes first constraints
+// (in solving order) are output, and the others are summarized in a single node.
+func (system *SparseR1CSCore) WriteDOT(w io.Writer, maxNodes int) error {
+	nbConstraints := len(system.Constraints)
+
+	var sbb strings.Builder
+	sbb.WriteString("digraph constraints {\n")
+	sbb.WriteString("\tnode [shape=box, style=filled, colorscheme=set312];\n")
+
+	nbNodes := system.walkDependencies(maxNodes, func(cID, lID int) {
+		sbb.WriteString("\tc" + strconv.Itoa(cID) + " [label=\"" + strconv.Itoa(cID) + " (level " + strconv.Itoa(lID) + ")\", fillcolor=" + strconv.Itoa(lID%12+1) + "];\n")
+	}, func(from, to int) {
+		sbb.WriteString("\tc" + strconv.Itoa(from) + " -> c" + strconv.Itoa(to) + ";\n")
+	})
+	if nbNodes < nbConstraints {
+		sbb.WriteString("\tmore [shape=plaintext, style=\"\", label=\"" + strconv.Itoa(nbConstraints-nbNodes) + " more constraints\"];\n")
+	}
+	sbb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sbb.String())
+	return err
+}
+
+// DependencyMetrics returns statistics on the dependency graph of the constraints output
+// by WriteDOT: its number of nodes (constraints) and edges, and the average and maximum
+// number of parents (fan-in) and children (fan-out) of a constraint. Constraints with a
+// high fan-out delay all their children to the next levels, and long chains of constraints
+// with a fan-in and fan-out of 1 can't be parallelized.
+func (system *SparseR1CSCore) DependencyMetrics() (nbNodes, nbEdges int, avgFanIn, avgFanOut float64, maxFanIn, maxFanOut int) {
+	fanIn := make([]int, len(system.Constraints))
+	fanOut := make([]int, len(system.Constraints))
+	nbNodes = system.walkDependencies(0, func(int, int) {}, func(from, to int) {
+		nbEdges++
+		fanOut[from]++
+		fanIn[to]++
+	})
+	for i := range fanIn {
+		if fanIn[i] > maxFanIn {
+			maxFanIn = fanIn[i]
+		}
+		if fanOut[i] > maxFanOut {
+			maxFanOut = fanOut[i]
+		}
+	}
+	if nbNodes != 0 {
+		// each edge is the fan-in of a node and the fan-out of another
+		avgFanIn = float64(nbEdges) / float64(nbNodes)
+		avgFanOut = avgFanIn
+	}
+	return
+}
+
+// walkDependencies calls node for the maxNodes first constraints in solving order (all of
+// them if maxNodes <= 0), with their level, and edge for each of their dependencies on
+// another constraint, solving a wire they use. It returns the number of nodes walked.
+func (system *SparseR1CSCore) walkDependencies(maxNodes int, node func(cID, lID int), edge func(from, to int)) int {
+	nbInputs := system.GetNbPublicVariables() + system.GetNbSecretVariables()
+	nbConstraints := len(system.Constraints)
+	if maxNodes <= 0 || maxNodes > nbConstraints {
+		maxNodes = nbConstraints
+	}
+
+	// solvedBy maps a wire to the constraint solving it
+	solvedBy := make(map[int]int)
+	nbNodes := 0
+	for lID, level := range system.Levels {
+		for _, cID := range level {
+			if nbNodes == maxNodes {
+				return nbNodes
+			}
+			nbNodes++
+			node(cID, lID)
+
+			var outputs, parents []int
+			wireIterator := system.Constraints[cID].WireIterator()
+			for wID := wireIterator(); wID != -1; wID = wireIterator() {
+				if wID < nbInputs {
+					continue
+				}
+				if from, ok := solvedBy[wID]; ok {
+					if from != cID && !containsInt(parents, from) {
+						parents = append(parents, from)
+						edge(from, cID)
+					}
+					continue
+				}
+				if h, ok := system.MHints[wID]; ok {
+					outputs = append(outputs, h.Wires...)
+				} else {
+					outputs = append(outputs, wID)
+				}
+			}
+			for _, wID := range outputs {
+				if _, ok := solvedBy[wID]; !ok {
+					solvedBy[wID] = cID
+				}
+			}
+		}
+	}
+	return nbNodes
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
 // SparseR1C used to compute the wires
 // L+R+M[0]M[1]+O+k=0
 // if a Term is zero, it means the field doesn't exist (ex M=[0,0] means there is no multiplicative term)
@@ -136,6 +500,12 @@ type SparseR1C struct {
 	L, R, O Term
 	M       [2]Term
 	K       int // stores only the ID of the constant term that is used
+
+	// RangeCheck, if set, tags the constraint as a range check gate: the wire of R must also
+	// be boolean, xb⋅(xb-1) == 0, which PLONK enforces with a dedicated selector instead of
+	// a separate constraint (see frontend.Compiler.RangeCheck). Not encoded if unset, which
+	// leaves the encoding of the other constraints unchanged.
+	RangeCheck bool `cbor:",omitempty"`
 }
 
 // WireIterator implements constraint.Iterable
@@ -157,7 +527,8 @@ func (c *SparseR1C) WireIterator() func() int {
 	}
 }
 
-// String formats the constraint as qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xaxb) + qC == 0
+// String formats the constraint as qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xaxb) + qC == 0, followed by
+// ∧ xb ∈ {0,1} for a range check gate
 func (c *SparseR1C) String(r Resolver) string {
 	sbb := NewStringBuilder(r)
 	sbb.WriteTerm(c.L)
@@ -179,5 +550,10 @@ func (c *SparseR1C) String(r Resolver) string {
 	sbb.WriteString(" + ")
 	sbb.WriteString(r.CoeffToString(c.K))
 	sbb.WriteString(" == 0")
+	if c.RangeCheck {
+		sbb.WriteString(" ∧ ")
+		sbb.WriteString(sbb.VariableToString(c.R.WireID()))
+		sbb.WriteString(" ∈ {0,1}")
+	}
 	return sbb.String()
 }
@@ -1,10 +1,36 @@
 package constraint_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	cs "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
 )
 
 func ExampleSparseR1CS_GetConstraints() {
@@ -62,3 +88,1746 @@ func ExampleSparseR1CS_GetConstraints() {
 	// 0 + 0 + -1⋅v0 + 1⋅(X×X) + 0 == 0
 	// 5⋅X + v0 + -1⋅Y + 5 == 0
 }
+
+func TestSparseR1CSSolveOutputCoefficients(t *testing.T) {
+	// X² + qO⋅v + 5 == 0, with qO among the fast solved coefficients (±2ᵏ) and others
+	for _, qO := range []int64{1, -1, 2, -2, 4, -8, 128, 256, -256, 3, -5} {
+		scs := cs.NewSparseR1CS(0)
+		X := scs.AddSecretVariable("X")
+		v := scs.AddInternalVariable()
+
+		cZero := scs.FromInterface(0)
+		cOne := scs.FromInterface(1)
+		cO := scs.FromInterface(qO)
+		cFive := scs.FromInterface(5)
+
+		scs.AddConstraint(constraint.SparseR1C{
+			L: scs.MakeTerm(&cZero, X),
+			R: scs.MakeTerm(&cZero, X),
+			O: scs.MakeTerm(&cO, v),
+			M: [2]constraint.Term{
+				scs.MakeTerm(&cOne, X),
+				scs.MakeTerm(&cOne, X),
+			},
+			K: int(scs.MakeTerm(&cFive, 0).CID),
+		})
+
+		// the flags are recomputed when deserializing
+		var buf bytes.Buffer
+		if _, err := scs.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var reconstructed cs.SparseR1CS
+		if _, err := reconstructed.ReadFrom(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, s := range []*cs.SparseR1CS{scs, &reconstructed} {
+			solution, err := s.Solve(fr.Vector{fr.NewElement(3)}, backend.ProverConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// qO⋅v == -14
+			var expected, got fr.Element
+			expected.SetInt64(-14)
+			got.SetInt64(qO)
+			got.Mul(&got, &solution[v])
+			if !got.Equal(&expected) {
+				t.Fatalf("qO = %d: wrong solution", qO)
+			}
+		}
+	}
+}
+
+// two independent statements: x³ + x + 5 == y and a⋅b == c
+type disjointCircuit struct {
+	X, A, B frontend.Variable
+	Y, C    frontend.Variable `gnark:",public"`
+}
+
+func (circuit *disjointCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(circuit.Y, api.Add(x3, circuit.X, 5))
+	api.AssertIsEqual(circuit.C, api.Mul(circuit.A, circuit.B))
+	return nil
+}
+
+// same as disjointCircuit, but both statements use the public input y
+type linkedCircuit struct {
+	disjointCircuit
+}
+
+func (circuit *linkedCircuit) Define(api frontend.API) error {
+	if err := circuit.disjointCircuit.Define(api); err != nil {
+		return err
+	}
+	api.AssertIsDifferent(circuit.Y, circuit.A)
+	return nil
+}
+
+func TestSparseR1CSConnectedComponents(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &disjointCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	components := spr.ConnectedComponents()
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(components))
+	}
+
+	// each constraint is in exactly one component, and components don't share a wire
+	seen := make([]bool, spr.GetNbConstraints())
+	wires := make([]map[int]struct{}, len(components))
+	for i, component := range components {
+		wires[i] = make(map[int]struct{})
+		for _, cID := range component {
+			if seen[cID] {
+				t.Fatalf("constraint %d in several components", cID)
+			}
+			seen[cID] = true
+			c := spr.Constraints[cID]
+			for _, term := range []constraint.Term{c.L, c.R, c.M[0], c.M[1], c.O} {
+				if term.CoeffID() != constraint.CoeffIdZero {
+					wires[i][term.WireID()] = struct{}{}
+				}
+			}
+		}
+	}
+	for i := range seen {
+		if !seen[i] {
+			t.Fatalf("constraint %d in no component", i)
+		}
+	}
+	for wID := range wires[0] {
+		if _, ok := wires[1][wID]; ok {
+			t.Fatalf("wire %d shared by the components", wID)
+		}
+	}
+
+	// a public input linking the statements merges the components
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &linkedCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ccs.(*cs.SparseR1CS).ConnectedComponents()); n != 1 {
+		t.Fatalf("expected 1 component, got %d", n)
+	}
+}
+
+func TestSparseR1CSUnusedCoefficients(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &refSparseCircuit{nbConstraints: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	if unused := spr.UnusedCoefficients(); len(unused) != 0 {
+		t.Fatalf("expected no unused coefficient, got %v", unused)
+	}
+
+	// a coefficient no term references
+	var c fr.Element
+	c.SetUint64(42)
+	spr.Coefficients = append(spr.Coefficients, c)
+	expected := []int{len(spr.Coefficients) - 1}
+	if unused := spr.UnusedCoefficients(); !reflect.DeepEqual(unused, expected) {
+		t.Fatalf("expected unused coefficients %v, got %v", expected, unused)
+	}
+}
+
+func TestSparseR1CSCompactCoefficients(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &constantCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	w, err := frontend.NewWitness(&constantCircuit{X: 1, Y: 1234568}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness := w.Vector().(fr.Vector)
+	expected, err := spr.Solve(witness, backend.ProverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// move the constraints to copies of the non reserved coefficients, appended to the
+	// table, leaving the coefficients only the constraints reference unused
+	offset := uint32(len(spr.Coefficients) - constraint.CoeffIdMinusTwo - 1)
+	spr.Coefficients = append(spr.Coefficients, spr.Coefficients[constraint.CoeffIdMinusTwo+1:]...)
+	move := func(t *constraint.Term) {
+		if t.CoeffID() > constraint.CoeffIdMinusTwo {
+			t.CID += offset
+		}
+	}
+	for i := range spr.Constraints {
+		c := &spr.Constraints[i]
+		move(&c.L)
+		move(&c.R)
+		move(&c.O)
+		move(&c.M[0])
+		move(&c.M[1])
+		if c.K > constraint.CoeffIdMinusTwo {
+			c.K += int(offset)
+		}
+	}
+	unused := spr.UnusedCoefficients()
+	if len(unused) == 0 {
+		t.Fatal("expected unused coefficients")
+	}
+	nbCoefficients := len(spr.Coefficients)
+
+	if n := spr.CompactCoefficients(); n != len(unused) {
+		t.Fatalf("expected %d coefficients removed, got %d", len(unused), n)
+	}
+	if len(spr.Coefficients) != nbCoefficients-len(unused) {
+		t.Fatalf("expected %d coefficients, got %d", nbCoefficients-len(unused), len(spr.Coefficients))
+	}
+	if unused := spr.UnusedCoefficients(); len(unused) != 0 {
+		t.Fatalf("expected no unused coefficient after compaction, got %v", unused)
+	}
+	if n := spr.CompactCoefficients(); n != 0 {
+		t.Fatalf("expected a second compaction to remove nothing, got %d", n)
+	}
+
+	solution, err := spr.Solve(witness, backend.ProverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(solution, expected) {
+		t.Fatal("compaction changed the solution")
+	}
+}
+
+// constantCircuit adds a hardcoded constant to X
+type constantCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *constantCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(circuit.X, 1234567), circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSConstantTerms(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &constantCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	constants := spr.ConstantTerms()
+	if len(constants) != len(spr.Constraints) {
+		t.Fatalf("expected %d constant terms, got %d", len(spr.Constraints), len(constants))
+	}
+	found := false
+	for i := range constants {
+		found = found || constants[i].Cmp(big.NewInt(1234567)) == 0
+	}
+	if !found {
+		t.Fatalf("the hardcoded constant is not a constant term: %v", constants)
+	}
+}
+
+func TestSparseR1CSEquals(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &revealCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	var buf bytes.Buffer
+	if _, err := spr.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var reconstructed cs.SparseR1CS
+	if _, err := reconstructed.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !spr.Equals(&reconstructed) || !reconstructed.Equals(spr) {
+		t.Fatal("deserialized constraint system differs")
+	}
+
+	// the levels are not compared
+	reconstructed.Levels = nil
+	if !spr.Equals(&reconstructed) {
+		t.Fatal("levels should not be compared")
+	}
+
+	reconstructed.Coefficients[len(reconstructed.Coefficients)-1].SetUint64(42)
+	if spr.Equals(&reconstructed) {
+		t.Fatal("constraint systems with different coefficients are equal")
+	}
+}
+
+func TestSparseR1CSNoSolvePanic(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &refSparseCircuit{nbConstraints: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(&refSparseCircuit{X: 2, Y: 256}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	if err := spr.IsSolved(w); err != nil {
+		t.Fatal(err)
+	}
+
+	// an internal wire no constraint solves
+	spr.NbInternalVariables++
+
+	err = spr.IsSolved(w, backend.WithNoSolvePanic())
+	if err == nil || !strings.Contains(err.Error(), "didn't instantiate all wires") {
+		t.Fatalf("expected an uninstantiated wires error, got %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected the solver to panic without WithNoSolvePanic")
+		}
+	}()
+	_ = spr.IsSolved(w)
+}
+
+// overflowHint returns its input plus the field modulus, which is reduced to the input
+func overflowHint(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Add(inputs[0], q)
+	return nil
+}
+
+// overflowHintCircuit checks that the output of overflowHint, once reduced, is X
+type overflowHintCircuit struct {
+	X frontend.Variable
+}
+
+func (circuit *overflowHintCircuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(overflowHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], circuit.X)
+	return nil
+}
+
+func TestHintOutputValidation(t *testing.T) {
+	w, err := frontend.NewWitness(&overflowHintCircuit{X: 1}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, builder := range map[string]frontend.NewBuilder{"r1cs": r1cs.NewBuilder, "scs": scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &overflowHintCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// r+1 is silently reduced by default
+		if err := ccs.IsSolved(w, backend.WithHints(overflowHint)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		err = ccs.IsSolved(w, backend.WithHints(overflowHint), backend.WithHintOutputValidation())
+		if err == nil {
+			t.Fatalf("%s: expected a non reduced hint output error", name)
+		}
+		// the R1CS solver reports the debug info of the constraint instead of the hint error
+		var unsatisfiedErr *cs.UnsatisfiedConstraintError
+		if errors.As(err, &unsatisfiedErr) {
+			err = unsatisfiedErr.Err
+		}
+		if !strings.Contains(err.Error(), "overflowHint") || !strings.Contains(err.Error(), "not reduced") {
+			t.Fatalf("%s: expected a non reduced hint output error, got %v", name, err)
+		}
+	}
+}
+
+func TestSparseR1CSReadFromWithLimits(t *testing.T) {
+	// a valid constraint system with ~100 constraints
+	circuit := refSparseCircuit{nbConstraints: 100}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := ccs.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	serialized := buf.Bytes()
+
+	var reconstructed cs.SparseR1CS
+	if _, err := reconstructed.ReadFromWithLimits(bytes.NewReader(serialized), 1024); err != nil {
+		t.Fatal(err)
+	}
+	var errLimit *cbor.MaxArrayElementsError
+	if _, err := reconstructed.ReadFromWithLimits(bytes.NewReader(serialized), 16); !errors.As(err, &errLimit) {
+		t.Fatalf("expected a MaxArrayElementsError, got %v", err)
+	}
+
+	// a stream declaring a huge array of constraints must fail without allocating it:
+	// {"Constraints": [2147483647 elements...]}
+	var crafted bytes.Buffer
+	crafted.WriteByte(0xa1)                            // map, 1 pair
+	crafted.WriteByte(0x60 + byte(len("Constraints"))) // text string
+	crafted.WriteString("Constraints")
+	crafted.Write([]byte{0x9a, 0x7f, 0xff, 0xff, 0xff}) // array, 2³¹-1 elements
+	if _, err := reconstructed.ReadFromWithLimits(&crafted, 1024); !errors.As(err, &errLimit) {
+		t.Fatalf("expected a MaxArrayElementsError, got %v", err)
+	}
+}
+
+func TestSparseR1CSReadFromVersions(t *testing.T) {
+	// serialized refSparseCircuit{nbConstraints: 3}, in the current format
+	fixture, err := os.ReadFile("testdata/sparse_r1cs_bn254_v1.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &refSparseCircuit{nbConstraints: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ccs.(*cs.SparseR1CS)
+	w, err := frontend.NewWitness(&refSparseCircuit{X: 2, Y: 256}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(serialized []byte) {
+		t.Helper()
+		var reconstructed cs.SparseR1CS
+		n, err := reconstructed.ReadFrom(bytes.NewReader(serialized))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(len(serialized)) {
+			t.Fatalf("read %d bytes, expected %d", n, len(serialized))
+		}
+		if !reflect.DeepEqual(reconstructed.Constraints, expected.Constraints) ||
+			!reflect.DeepEqual(reconstructed.Coefficients, expected.Coefficients) {
+			t.Fatal("constraint systems differ")
+		}
+		if err := reconstructed.IsSolved(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(fixture)
+
+	// legacy format, without the version byte
+	check(fixture[1:])
+
+	// unknown version
+	bumped := append([]byte{fixture[0] + 1}, fixture[1:]...)
+	var reconstructed cs.SparseR1CS
+	if _, err := reconstructed.ReadFrom(bytes.NewReader(bumped)); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("expected an unsupported version error, got %v", err)
+	}
+}
+
+// manyHintsCircuit fills the maps of the constraint system (hints, debug info)
+type manyHintsCircuit struct {
+	X [32]frontend.Variable
+}
+
+func (circuit *manyHintsCircuit) Define(api frontend.API) error {
+	for i := range circuit.X {
+		res, err := api.Compiler().NewHint(splitHint, 3, circuit.X[i])
+		if err != nil {
+			return err
+		}
+		api.AssertIsEqual(api.Sub(res[2], res[1]), 1)
+		api.AssertIsEqual(res[0], circuit.X[i])
+	}
+	return nil
+}
+
+func TestSparseR1CSWriteToDeterministic(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &manyHintsCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	if len(spr.MHints) < 64 || len(spr.MDebug) < 32 {
+		t.Fatalf("expected populated maps, got %d hints and %d debug infos", len(spr.MHints), len(spr.MDebug))
+	}
+
+	var buf bytes.Buffer
+	if _, err := spr.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte(nil), buf.Bytes()...)
+
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		if _, err := spr.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Fatalf("serialization %d differs", i)
+		}
+	}
+
+	// a decoded copy, with freshly built maps, serializes to the same bytes
+	var reconstructed cs.SparseR1CS
+	if _, err := reconstructed.ReadFrom(bytes.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if _, err := reconstructed.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatal("serialization of the decoded system differs")
+	}
+}
+
+type coeffCircuit struct {
+	coeff fr.Element
+	X     frontend.Variable
+	Y     frontend.Variable `gnark:",public"`
+}
+
+func (circuit *coeffCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.coeff), circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSCoefficientOf(t *testing.T) {
+	var circuit coeffCircuit
+	if _, err := circuit.coeff.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	var minusOne big.Int
+	minusOne.Sub(fr.Modulus(), big.NewInt(1))
+	for cID, expected := range map[uint32]*big.Int{
+		constraint.CoeffIdZero:     big.NewInt(0),
+		constraint.CoeffIdOne:      big.NewInt(1),
+		constraint.CoeffIdMinusOne: &minusOne,
+	} {
+		if c := spr.CoefficientOf(constraint.Term{CID: cID}); c.Cmp(expected) != 0 {
+			t.Fatalf("coefficient %d: expected %s, got %s", cID, expected, c.String())
+		}
+	}
+
+	// the random coefficient (or its opposite) appears in a constraint
+	var coeff, negCoeff big.Int
+	circuit.coeff.BigInt(&coeff)
+	negCoeff.Sub(fr.Modulus(), &coeff)
+	found := false
+	for _, c := range spr.Constraints {
+		for _, term := range []constraint.Term{c.L, c.R, c.O} {
+			if term.CoeffID() <= constraint.CoeffIdMinusTwo {
+				continue
+			}
+			var direct big.Int
+			spr.Coefficients[term.CoeffID()].BigInt(&direct)
+			v := spr.CoefficientOf(term)
+			if v.Cmp(&direct) != 0 {
+				t.Fatalf("coefficient %d: expected %s, got %s", term.CoeffID(), direct.String(), v.String())
+			}
+			found = found || v.Cmp(&coeff) == 0 || v.Cmp(&negCoeff) == 0
+		}
+	}
+	if !found {
+		t.Fatal("random coefficient not found")
+	}
+}
+
+func incrementHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Add(inputs[0], big.NewInt(1))
+	return nil
+}
+
+func doubleHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Lsh(inputs[0], 1)
+	return nil
+}
+
+// chainedHintCircuit computes x+1 and 2⋅(x+1) with two hints, the first feeding the second
+type chainedHintCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *chainedHintCircuit) Define(api frontend.API) error {
+	incremented, err := api.Compiler().NewHint(incrementHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	doubled, err := api.Compiler().NewHint(doubleHint, 1, incremented[0])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Add(circuit.X, incremented[0], doubled[0]), circuit.Y)
+	return nil
+}
+
+// constrainedHintCircuit runs a hint on a wire solved by a constraint
+type constrainedHintCircuit struct {
+	chainedHintCircuit
+}
+
+func (circuit *constrainedHintCircuit) Define(api frontend.API) error {
+	incremented, err := api.Compiler().NewHint(incrementHint, 1, api.Mul(circuit.X, circuit.X))
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Add(circuit.X, incremented[0]), circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSRunHints(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &chainedHintCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	// the public output is wrong: RunHints doesn't check the constraints
+	w, err := frontend.NewWitness(&chainedHintCircuit{X: 5, Y: 0}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt, err := backend.NewProverConfig(backend.WithHints(incrementHint, doubleHint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := spr.RunHints(w.Vector().(fr.Vector), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 hint outputs, got %d", len(outputs))
+	}
+	expected := map[uint64]bool{6: true, 12: true}
+	for wID, v := range outputs {
+		if !v.IsUint64() || !expected[v.Uint64()] {
+			t.Fatalf("unexpected value %s for wire %d", v.String(), wID)
+		}
+		delete(expected, v.Uint64())
+	}
+
+	// missing hint function
+	opt, err = backend.NewProverConfig(backend.WithHints(incrementHint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spr.RunHints(w.Vector().(fr.Vector), opt); err == nil {
+		t.Fatal("expected an error for a missing hint function")
+	}
+
+	// a hint depending on a constraint can't be run
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &constrainedHintCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt, err = backend.NewProverConfig(backend.WithHints(incrementHint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ccs.(*cs.SparseR1CS).RunHints(w.Vector().(fr.Vector), opt); err == nil {
+		t.Fatal("expected an error for a hint depending on a constraint")
+	}
+}
+
+// wideCircuit has a first level of len(X) independent constraints
+type wideCircuit struct {
+	X [50]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *wideCircuit) Define(api frontend.API) error {
+	sum := frontend.Variable(0)
+	for i := range circuit.X {
+		sum = api.Add(sum, api.Mul(circuit.X[i], circuit.X[i]))
+	}
+	api.AssertIsEqual(sum, circuit.Y)
+	return nil
+}
+
+func TestSystemLevelsCapped(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &wideCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	const maxWidth = 8
+	if len(spr.Levels[0]) <= maxWidth {
+		t.Fatalf("expected a level wider than %d, got %d", maxWidth, len(spr.Levels[0]))
+	}
+
+	// each sub-level is within a single level, and the solve order is unchanged
+	capped := spr.LevelsCapped(maxWidth)
+	levelOf := make(map[int]int)
+	var order []int
+	for l, level := range spr.Levels {
+		for _, cID := range level {
+			levelOf[cID] = l
+			order = append(order, cID)
+		}
+	}
+	i := 0
+	for _, level := range capped {
+		if len(level) == 0 || len(level) > maxWidth {
+			t.Fatalf("sub-level of width %d", len(level))
+		}
+		for _, cID := range level {
+			if levelOf[cID] != levelOf[level[0]] {
+				t.Fatal("sub-level mixes constraints of different levels")
+			}
+			if order[i] != cID {
+				t.Fatalf("constraint %d solved out of order", cID)
+			}
+			i++
+		}
+	}
+	if i != len(order) {
+		t.Fatalf("expected %d constraints, got %d", len(order), i)
+	}
+
+	if len(spr.LevelsCapped(0)) != len(spr.Levels) {
+		t.Fatal("a non positive width should not split levels")
+	}
+}
+
+// the compile capacity is only a hint; the level builder (see System.updateLevel) grows its
+// wire levels and System.Levels as constraints and wires are added, so an undersized capacity
+// can't make the levels index out of range
+func TestSystemLevelsUndersizedCapacity(t *testing.T) {
+	circuit := &refSparseCircuit{nbConstraints: 64}
+	expected, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit, frontend.WithCapacity(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	if spr.GetNbConstraints() <= 1 {
+		t.Fatalf("expected more constraints than the capacity, got %d", spr.GetNbConstraints())
+	}
+	if !reflect.DeepEqual(spr.Levels, expected.(*cs.SparseR1CS).Levels) {
+		t.Fatal("levels differ")
+	}
+
+	y := new(big.Int).Exp(big.NewInt(2), new(big.Int).Lsh(big.NewInt(1), 64), ecc.BN254.ScalarField())
+	w, err := frontend.NewWitness(&refSparseCircuit{X: 2, Y: y}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spr.IsSolved(w); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSolveTrace(t *testing.T) {
+	y := new(big.Int).Exp(big.NewInt(2), new(big.Int).Lsh(big.NewInt(1), 64), ecc.BN254.ScalarField())
+	w, err := frontend.NewWitness(&refSparseCircuit{X: 2, Y: y}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, builder := range map[string]frontend.NewBuilder{"scs": scs.NewBuilder, "r1cs": r1cs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &refSparseCircuit{nbConstraints: 64})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var levels [][]int
+		switch ccs := ccs.(type) {
+		case *cs.SparseR1CS:
+			levels = ccs.Levels
+		case *cs.R1CS:
+			levels = ccs.Levels
+		}
+
+		var buf bytes.Buffer
+		if err := ccs.IsSolved(w, backend.WithSolveTrace(&buf)); err != nil {
+			t.Fatal(err)
+		}
+		var trace struct {
+			TraceEvents []struct {
+				Name string
+				Ph   string
+				Ts   float64
+				Dur  float64
+				Args map[string]int
+			}
+		}
+		if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+			t.Fatalf("%s: invalid trace: %v", name, err)
+		}
+		if len(trace.TraceEvents) != len(levels) {
+			t.Fatalf("%s: expected %d events, got %d", name, len(levels), len(trace.TraceEvents))
+		}
+		for i, e := range trace.TraceEvents {
+			if e.Name != fmt.Sprintf("level %d", i) || e.Ph != "X" || e.Dur < 0 {
+				t.Fatalf("%s: unexpected event %+v", name, e)
+			}
+			if e.Args["level"] != i || e.Args["constraints"] != len(levels[i]) {
+				t.Fatalf("%s: unexpected event args %v", name, e.Args)
+			}
+		}
+	}
+}
+
+type refSparseCircuit struct {
+	nbConstraints int
+	X             frontend.Variable
+	Y             frontend.Variable `gnark:",public"`
+}
+
+func (circuit *refSparseCircuit) Define(api frontend.API) error {
+	for i := 0; i < circuit.nbConstraints; i++ {
+		circuit.X = api.Mul(circuit.X, circuit.X)
+	}
+	api.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSPrecomputeNegInvCoefficients(t *testing.T) {
+	// X² + 3⋅v + 5 == 0; qO = 3 is solved with the negated inverse of the coefficient
+	spr := cs.NewSparseR1CS(0)
+	X := spr.AddSecretVariable("X")
+	v := spr.AddInternalVariable()
+
+	cZero := spr.FromInterface(0)
+	cOne := spr.FromInterface(1)
+	cThree := spr.FromInterface(3)
+	cFive := spr.FromInterface(5)
+
+	spr.AddConstraint(constraint.SparseR1C{
+		L: spr.MakeTerm(&cZero, X),
+		R: spr.MakeTerm(&cZero, X),
+		O: spr.MakeTerm(&cThree, v),
+		M: [2]constraint.Term{
+			spr.MakeTerm(&cOne, X),
+			spr.MakeTerm(&cOne, X),
+		},
+		K: int(spr.MakeTerm(&cFive, 0).CID),
+	})
+
+	witness := fr.Vector{fr.NewElement(3)}
+	expected, err := spr.Solve(witness, backend.ProverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coefficientsNegInv := spr.PrecomputeNegInvCoefficients()
+	if len(coefficientsNegInv) != len(spr.Coefficients) {
+		t.Fatalf("expected %d coefficients, got %d", len(spr.Coefficients), len(coefficientsNegInv))
+	}
+	// the zero coefficient has a zero entry
+	if !coefficientsNegInv[constraint.CoeffIdZero].IsZero() {
+		t.Fatal("expected a zero entry for the zero coefficient")
+	}
+	for i := 0; i < 2; i++ {
+		values, err := spr.SolvePrecomputed(witness, backend.ProverConfig{}, coefficientsNegInv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Fatal("solving with precomputed coefficients gives a different solution")
+		}
+	}
+
+	// the precomputed coefficients are used in place of the computed ones: wrong ones give
+	// a wrong solution
+	wrong := make(fr.Vector, len(coefficientsNegInv))
+	if _, err := spr.SolvePrecomputed(witness, backend.ProverConfig{}, wrong); err == nil {
+		t.Fatal("expected wrong precomputed coefficients to give an unsatisfied constraint")
+	}
+
+	if _, err := spr.SolvePrecomputed(witness, backend.ProverConfig{}, wrong[1:]); err == nil {
+		t.Fatal("expected an error for a wrong number of precomputed coefficients")
+	}
+}
+
+func TestSparseR1CSReverseCheckOrder(t *testing.T) {
+	var wide wideCircuit
+	for i := range wide.X {
+		wide.X[i] = 1
+	}
+	wide.Y = len(wide.X)
+
+	for _, tc := range []struct {
+		circuit, assignment frontend.Circuit
+	}{
+		{&wideCircuit{}, &wide},
+		{&refSparseCircuit{nbConstraints: 3}, &refSparseCircuit{X: 2, Y: 256}},
+	} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, tc.circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spr := ccs.(*cs.SparseR1CS)
+		w, err := frontend.NewWitness(tc.assignment, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness := w.Vector().(fr.Vector)
+
+		opt, err := backend.NewProverConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := spr.Solve(witness, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt, err = backend.NewProverConfig(backend.WithReverseCheckOrder())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := spr.Solve(witness, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Fatal("solutions differ")
+		}
+
+		// a wrong public output is caught as well
+		witness[0].SetUint64(42)
+		if _, err := spr.Solve(witness, opt); err == nil {
+			t.Fatal("expected an unsatisfied constraint")
+		}
+	}
+}
+
+// squaredHintCircuit calls a hint on X², its output being squared again
+type squaredHintCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squaredHintCircuit) Define(api frontend.API) error {
+	incremented, err := api.Compiler().NewHint(incrementHint, 1, api.Mul(circuit.X, circuit.X))
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Mul(incremented[0], incremented[0]), circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSHintLevels(t *testing.T) {
+	// level 0: X² ; level 1: (X²+1)², solving the hint ; level 2: the assertion
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &squaredHintCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+	if levels := spr.HintLevels(); !reflect.DeepEqual(levels, []int{1}) {
+		t.Fatalf("expected hints in level 1, got %v (levels: %v)", levels, spr.Levels)
+	}
+
+	// chained hints are solved by the first constraint using them
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &chainedHintCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr = ccs.(*cs.SparseR1CS)
+	levels := spr.HintLevels()
+	if len(levels) == 0 || levels[0] != 0 {
+		t.Fatalf("expected hints in level 0, got %v", levels)
+	}
+
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &wideCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels := ccs.(*cs.SparseR1CS).HintLevels(); len(levels) != 0 {
+		t.Fatalf("expected no hint level, got %v", levels)
+	}
+}
+
+// nbActiveHints counts the running calls to unsafeHint
+var nbActiveHints int32
+
+// hintOverlap, if set, makes the calls to unsafeHint wait until two of them run at the same
+// time, so that the concurrent calls are detected regardless of the scheduling
+var hintOverlap struct {
+	sync.Mutex
+	c chan struct{}
+}
+
+// unsafeHint returns its input, and fails if it is called concurrently
+func unsafeHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	defer atomic.AddInt32(&nbActiveHints, -1)
+	n := atomic.AddInt32(&nbActiveHints, 1)
+	if hintOverlap.c != nil {
+		hintOverlap.Lock()
+		if n > 1 {
+			select {
+			case <-hintOverlap.c:
+			default:
+				close(hintOverlap.c)
+			}
+		}
+		hintOverlap.Unlock()
+		select {
+		case <-hintOverlap.c:
+		case <-time.After(time.Minute):
+			return errors.New("no concurrent hint call")
+		}
+	}
+	if n != 1 {
+		return errors.New("concurrent hint call")
+	}
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+// unsafeHintCircuit has a single level of independent constraints calling unsafeHint
+type unsafeHintCircuit struct {
+	X [200]frontend.Variable
+}
+
+func (circuit *unsafeHintCircuit) Define(api frontend.API) error {
+	for i := range circuit.X {
+		res, err := api.Compiler().NewHint(unsafeHint, 1, circuit.X[i])
+		if err != nil {
+			return err
+		}
+		api.AssertIsEqual(res[0], circuit.X[i])
+	}
+	return nil
+}
+
+func TestSolveSerialHints(t *testing.T) {
+	var assignment unsafeHintCircuit
+	for i := range assignment.X {
+		assignment.X[i] = i
+	}
+	w, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), newBuilder, &unsafeHintCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ccs.IsSolved(w, backend.WithHints(unsafeHint), backend.WithSerialHints()); err != nil {
+			t.Fatal(err)
+		}
+
+		// concurrent calls can only happen with several CPUs
+		if runtime.NumCPU() > 1 {
+			hintOverlap.c = make(chan struct{})
+			err := ccs.IsSolved(w, backend.WithHints(unsafeHint))
+			hintOverlap.c = nil
+			if err == nil || !strings.Contains(err.Error(), "concurrent hint call") {
+				t.Fatalf("expected concurrent hint calls, got %v", err)
+			}
+		}
+	}
+}
+
+// namedOutputCircuit has a named public output Out = X³
+type namedOutputCircuit struct {
+	In  frontend.Variable `gnark:"in,public"`
+	X   frontend.Variable
+	Out frontend.Variable `gnark:"out,public"`
+}
+
+func (circuit *namedOutputCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Out)
+	api.AssertIsEqual(api.Add(circuit.X, 1), circuit.In)
+	return nil
+}
+
+func TestSparseR1CSPublicWireIDs(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &namedOutputCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	ids := spr.PublicWireIDs()
+	if !reflect.DeepEqual(ids, []int{0, 1}) {
+		t.Fatalf("unexpected public wire IDs %v", ids)
+	}
+
+	w, err := frontend.NewWitness(&namedOutputCircuit{In: 4, X: 3, Out: 27}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt, err := backend.NewProverConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := spr.Solve(w.Vector().(fr.Vector), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := spr.PublicWireNames()
+	if !reflect.DeepEqual(names, map[int]string{0: "in", 1: "out"}) {
+		t.Fatalf("unexpected public wire names %v", names)
+	}
+	outputs := make(map[string]uint64)
+	for _, wID := range ids {
+		outputs[names[wID]] = solution[wID].Uint64()
+	}
+	if !reflect.DeepEqual(outputs, map[string]uint64{"in": 4, "out": 27}) {
+		t.Fatalf("unexpected public outputs %v", outputs)
+	}
+}
+
+// forkCircuit computes X² then uses it in two branches, joined in the output
+type forkCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *forkCircuit) Define(api frontend.API) error {
+	x2 := api.Mul(circuit.X, circuit.X)
+	left := api.Mul(x2, circuit.X)
+	right := api.Mul(x2, x2)
+	api.AssertIsEqual(api.Mul(left, right), circuit.Y)
+	return nil
+}
+
+// checkDOT checks that dot is a well formed digraph made of node and edge statements,
+// and returns the declared nodes and the edges
+func checkDOT(t *testing.T, dot string) (nodes map[string]bool, edges [][2]string) {
+	lines := strings.Split(strings.TrimSpace(dot), "\n")
+	if len(lines) < 2 || lines[0] != "digraph constraints {" || lines[len(lines)-1] != "}" {
+		t.Fatalf("not a digraph:\n%s", dot)
+	}
+	nodeRe := regexp.MustCompile(`^\t(\w+) \[.*\];$`)
+	edgeRe := regexp.MustCompile(`^\t(\w+) -> (\w+);$`)
+	nodes = make(map[string]bool)
+	for _, l := range lines[1 : len(lines)-1] {
+		if m := edgeRe.FindStringSubmatch(l); m != nil {
+			edges = append(edges, [2]string{m[1], m[2]})
+		} else if m := nodeRe.FindStringSubmatch(l); m != nil {
+			if m[1] != "node" { // default attributes
+				nodes[m[1]] = true
+			}
+		} else {
+			t.Fatalf("invalid statement %q", l)
+		}
+	}
+	for _, e := range edges {
+		if !nodes[e[0]] || !nodes[e[1]] {
+			t.Fatalf("edge %s -> %s links an undeclared node", e[0], e[1])
+		}
+	}
+	return
+}
+
+func TestSparseR1CSLevelsDeterministic(t *testing.T) {
+	// the levels are built sequentially, in constraint order, while compiling
+	expected := [][]int{{0}, {1, 2}, {3}, {4}}
+	for i := 0; i < 3; i++ {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &forkCircuit{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if levels := ccs.(*cs.SparseR1CS).Levels; !reflect.DeepEqual(levels, expected) {
+			t.Fatalf("expected levels %v, got %v", expected, levels)
+		}
+	}
+}
+
+func TestSparseR1CSWriteDOT(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &forkCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	var buf bytes.Buffer
+	if err := spr.WriteDOT(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	nodes, edges := checkDOT(t, buf.String())
+	if len(nodes) != len(spr.Constraints) {
+		t.Fatalf("expected %d nodes, got %d", len(spr.Constraints), len(nodes))
+	}
+	// X² feeds both branches
+	fanOut := make(map[string]int)
+	for _, e := range edges {
+		fanOut[e[0]]++
+	}
+	if fanOut["c0"] != 2 {
+		t.Fatalf("expected 2 edges from the first constraint, got %d:\n%s", fanOut["c0"], buf.String())
+	}
+
+	// capped output
+	buf.Reset()
+	if err := spr.WriteDOT(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	nodes, _ = checkDOT(t, buf.String())
+	if len(nodes) != 3 || !nodes["more"] {
+		t.Fatalf("expected 2 nodes and a summary, got:\n%s", buf.String())
+	}
+}
+
+func TestSparseR1CSDependencyMetrics(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &forkCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	// X² -> X³, X⁴ -> X⁷ -> X⁷ == Y
+	nbNodes, nbEdges, avgFanIn, avgFanOut, maxFanIn, maxFanOut := spr.DependencyMetrics()
+	if nbNodes != len(spr.Constraints) || nbNodes != 5 {
+		t.Fatalf("expected 5 nodes, got %d", nbNodes)
+	}
+	if nbEdges != 5 {
+		t.Fatalf("expected 5 edges, got %d", nbEdges)
+	}
+	if avgFanIn != 1 || avgFanOut != 1 {
+		t.Fatalf("expected average fan-in and fan-out of 1, got %f and %f", avgFanIn, avgFanOut)
+	}
+	if maxFanIn != 2 || maxFanOut != 2 {
+		t.Fatalf("expected maximum fan-in and fan-out of 2, got %d and %d", maxFanIn, maxFanOut)
+	}
+}
+
+func splitHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	for i := range outputs {
+		outputs[i].Add(inputs[0], big.NewInt(int64(i)))
+	}
+	return nil
+}
+
+// multiOutputHintCircuit calls a 3-outputs hint twice and a single output hint once
+type multiOutputHintCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *multiOutputHintCircuit) Define(api frontend.API) error {
+	a, err := api.Compiler().NewHint(splitHint, 3, circuit.X)
+	if err != nil {
+		return err
+	}
+	b, err := api.Compiler().NewHint(splitHint, 3, api.Mul(circuit.X, circuit.X))
+	if err != nil {
+		return err
+	}
+	c, err := api.Compiler().NewHint(incrementHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Add(a[0], a[1], a[2], b[0], b[1], b[2], c[0]), circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSHintOutputCounts(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &multiOutputHintCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := ccs.(*cs.SparseR1CS).HintOutputCounts()
+	expected := map[hint.ID]int{
+		hint.UUID(splitHint):     6,
+		hint.UUID(incrementHint): 1,
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &wideCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts := ccs.(*cs.SparseR1CS).HintOutputCounts(); len(counts) != 0 {
+		t.Fatalf("expected no hint, got %v", counts)
+	}
+}
+
+type threeFailuresCircuit struct {
+	X, Y, Z frontend.Variable
+}
+
+func (circuit *threeFailuresCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X), 4)
+	api.AssertIsEqual(api.Mul(circuit.Y, circuit.Y), 9)
+	api.AssertIsEqual(api.Mul(circuit.Z, circuit.Z), 16)
+	return nil
+}
+
+var errFailingHint = errors.New("failing hint")
+
+func failingHint(_ *big.Int, _ []*big.Int, _ []*big.Int) error {
+	return errFailingHint
+}
+
+type failingHintCircuit struct {
+	X frontend.Variable
+}
+
+func (circuit *failingHintCircuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(failingHint, 1, circuit.X)
+	if err != nil {
+		return err
+	}
+	// depends on the failing hint, must not be reported
+	api.AssertIsEqual(api.Mul(res[0], res[0]), circuit.X)
+	api.AssertIsEqual(circuit.X, 2)
+	return nil
+}
+
+func TestSparseR1CSSolveCollectingErrors(t *testing.T) {
+	solve := func(circuit, assignment frontend.Circuit, max int, opts ...backend.ProverOption) (fr.Vector, []cs.UnsatisfiedConstraintError, *cs.SparseR1CS) {
+		t.Helper()
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spr := ccs.(*cs.SparseR1CS)
+		w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt, err := backend.NewProverConfig(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, errs, err := spr.SolveCollectingErrors(w.Vector().(fr.Vector), opt, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return values, errs, spr
+	}
+
+	// satisfied system: same solution as Solve
+	values, errs, spr := solve(&threeFailuresCircuit{}, &threeFailuresCircuit{X: 2, Y: 3, Z: 4}, 0)
+	if len(errs) != 0 {
+		t.Fatalf("expected no error, got %v", errs)
+	}
+	w, err := frontend.NewWitness(&threeFailuresCircuit{X: 2, Y: 3, Z: 4}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt, err := backend.NewProverConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := spr.Solve(w.Vector().(fr.Vector), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, values) {
+		t.Fatal("solutions differ")
+	}
+
+	// all three failures are reported, each with its own constraint
+	_, errs, _ = solve(&threeFailuresCircuit{}, &threeFailuresCircuit{X: 1, Y: 1, Z: 1}, 0)
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 unsatisfied constraints, got %d", len(errs))
+	}
+	for i := 1; i < len(errs); i++ {
+		if errs[i].CID <= errs[i-1].CID {
+			t.Fatalf("constraints not distinct or not sorted: %v", errs)
+		}
+	}
+	_, errs, _ = solve(&threeFailuresCircuit{}, &threeFailuresCircuit{X: 1, Y: 3, Z: 1}, 0)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 unsatisfied constraints, got %d", len(errs))
+	}
+
+	// max bounds the number of reported failures
+	_, errs, _ = solve(&threeFailuresCircuit{}, &threeFailuresCircuit{X: 1, Y: 1, Z: 1}, 2)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 unsatisfied constraints, got %d", len(errs))
+	}
+
+	// constraints depending on a wire that couldn't be solved are skipped
+	_, errs, _ = solve(&failingHintCircuit{}, &failingHintCircuit{X: 3}, 0, backend.WithHints(failingHint))
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 unsatisfied constraints, got %d: %v", len(errs), errs)
+	}
+	if !errors.Is(errs[0].Err, errFailingHint) && !errors.Is(errs[1].Err, errFailingHint) {
+		t.Fatalf("expected the hint error to be reported, got %v", errs)
+	}
+}
+
+type revealCircuit struct {
+	P       frontend.Variable `gnark:",public"`
+	A, B, C frontend.Variable
+}
+
+func (circuit *revealCircuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(incrementHint, 1, circuit.B)
+	if err != nil {
+		return err
+	}
+	api.Println("b+1", res[0], "b", circuit.B)
+	api.AssertIsEqual(api.Add(api.Mul(circuit.A, res[0]), api.Mul(circuit.B, circuit.C)), circuit.P)
+	return nil
+}
+
+func TestSparseR1CSRevealSecret(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &revealCircuit{})
+	assert.NoError(err)
+	spr := ccs.(*cs.SparseR1CS)
+
+	// wires: P | A, B, C | internal
+	assert.Error(spr.RevealSecret(0), "public wire")
+	assert.Error(spr.RevealSecret(4), "internal wire")
+	assert.NoError(spr.RevealSecret(2))
+	assert.Equal([]string{"P", "B"}, spr.Public)
+	assert.Equal([]string{"A", "C"}, spr.Secret)
+
+	newWitness := func(values ...uint64) witness.Witness {
+		w, err := witness.New(ecc.BN254.ScalarField())
+		assert.NoError(err)
+		ch := make(chan any, len(values))
+		for _, v := range values {
+			ch <- v
+		}
+		close(ch)
+		assert.NoError(w.Fill(2, len(values)-2, ch))
+		return w
+	}
+
+	// P = A(B+1) + BC, with B revealed
+	fullWitness := newWitness(23, 3, 2, 5)
+	assert.NoError(spr.IsSolved(fullWitness, backend.WithHints(incrementHint)))
+	assert.Error(spr.IsSolved(newWitness(23, 4, 2, 5), backend.WithHints(incrementHint)))
+
+	srs, err := test.NewKZGSRS(spr)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(spr, srs)
+	assert.NoError(err)
+	proof, err := plonk.Prove(spr, pk, fullWitness, backend.WithHints(incrementHint))
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+
+	// wrong revealed value
+	publicWitness, err = newWitness(23, 4, 2, 5).Public()
+	assert.NoError(err)
+	assert.Error(plonk.Verify(proof, vk, publicWitness))
+}
+
+func TestSparseR1CSClone(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &revealCircuit{})
+	assert.NoError(err)
+	spr := ccs.(*cs.SparseR1CS)
+	serialize := func(spr *cs.SparseR1CS) []byte {
+		var buf bytes.Buffer
+		_, err := spr.WriteTo(&buf)
+		assert.NoError(err)
+		return buf.Bytes()
+	}
+	original := serialize(spr)
+
+	clone := spr.Clone()
+	assert.Equal(original, serialize(clone))
+
+	// mutate the clone in place
+	assert.NoError(clone.RevealSecret(2))
+	clone.Coefficients[constraint.CoeffIdOne].SetUint64(42)
+	clone.Constraints[0].K = constraint.CoeffIdTwo
+	clone.Levels[0][0]++
+	for _, h := range clone.MHints {
+		h.Wires[0]++
+	}
+	clone.MDebug[-1] = 0
+	clone.Logs[0].Format = "mutated"
+
+	assert.Equal(original, serialize(spr))
+	assert.Equal([]string{"A", "B", "C"}, spr.Secret)
+
+	// P = A(B+1) + BC
+	w, err := frontend.NewWitness(&revealCircuit{P: 23, A: 2, B: 3, C: 5}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	assert.NoError(spr.IsSolved(w, backend.WithHints(incrementHint)))
+}
+
+func TestSparseR1CSQuickCheck(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &forkCircuit{})
+	assert.NoError(err)
+	spr := ccs.(*cs.SparseR1CS)
+	opt, err := backend.NewProverConfig()
+	assert.NoError(err)
+	witness := func(y int) fr.Vector {
+		w, err := frontend.NewWitness(&forkCircuit{X: 2, Y: y}, ecc.BN254.ScalarField())
+		assert.NoError(err)
+		return w.Vector().(fr.Vector)
+	}
+
+	rng := rand.New(rand.NewSource(42))
+	// X⁷ == Y
+	assert.NoError(spr.QuickCheck(witness(128), opt, 1, rng))
+
+	// only the last level fails
+	broken := witness(127)
+	assert.Error(spr.QuickCheck(broken, opt, 1, rng))
+	assert.NoError(spr.QuickCheck(broken, opt, 0, rng))
+
+	// the same seed draws the same samples
+	sample := func(seed int64) []bool {
+		rng := rand.New(rand.NewSource(seed))
+		caught := make([]bool, 64)
+		for i := range caught {
+			caught[i] = spr.QuickCheck(broken, opt, 0.5, rng) != nil
+		}
+		return caught
+	}
+	caught := sample(42)
+	assert.Equal(caught, sample(42))
+	assert.Contains(caught, true, "broken witness never caught")
+	assert.Contains(caught, false, "broken witness always caught")
+
+	assert.Error(spr.QuickCheck(broken, opt, 1.5, rng))
+	assert.Error(spr.QuickCheck(broken, opt, 1, nil))
+}
+
+func TestSparseR1CSWireIncidence(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &forkCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	incidence := ccs.(*cs.SparseR1CS).WireIncidence()
+
+	// Y, X, X², X³, X⁴, X⁷
+	expected := map[int][]int{0: {4}, 1: {0, 1}, 2: {0, 1, 2}, 3: {1, 3}, 4: {2, 3}, 5: {3, 4}}
+	if !reflect.DeepEqual(incidence, expected) {
+		t.Fatalf("expected incidence %v, got %v", expected, incidence)
+	}
+}
+
+// starCircuit consumes the hub X² in each of the S[i]⋅X² products
+type starCircuit struct {
+	X frontend.Variable
+	S [20]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *starCircuit) Define(api frontend.API) error {
+	hub := api.Mul(circuit.X, circuit.X)
+	for i := range circuit.S {
+		api.AssertIsEqual(api.Mul(hub, circuit.S[i]), circuit.S[i])
+	}
+	return nil
+}
+
+func TestSparseR1CSMaxFanInWire(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &starCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	hubID := spr.GetNbPublicVariables() + spr.GetNbSecretVariables()
+	wireID, fanIn := spr.MaxFanInWire()
+	if wireID != hubID || fanIn != len(starCircuit{}.S) {
+		t.Fatalf("expected hub wire %d with fan-in %d, got wire %d with fan-in %d", hubID, len(starCircuit{}.S), wireID, fanIn)
+	}
+
+	// Y, X, X², X³, X⁴, X⁷: X² is consumed by the constraints of X³ and X⁴, and X by the
+	// constraints of X² and X³
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &forkCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wireID, fanIn := ccs.(*cs.SparseR1CS).MaxFanInWire(); wireID != 1 || fanIn != 2 {
+		t.Fatalf("expected wire 1 with fan-in 2, got wire %d with fan-in %d", wireID, fanIn)
+	}
+
+	var empty cs.SparseR1CS
+	if wireID, fanIn := empty.MaxFanInWire(); wireID != -1 || fanIn != 0 {
+		t.Fatalf("expected no wire, got wire %d with fan-in %d", wireID, fanIn)
+	}
+}
+
+func pairHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].Set(inputs[0])
+	outputs[1].Set(inputs[0])
+	return nil
+}
+
+// hintWiringCircuit duplicates X with a hint; the two outputs being equal is pure hint
+// wiring, re-verified by the first output being equal to X
+type hintWiringCircuit struct {
+	mark      bool
+	markInput bool
+	X         frontend.Variable `gnark:",public"`
+}
+
+func (circuit *hintWiringCircuit) Define(api frontend.API) error {
+	out, err := api.Compiler().NewHint(pairHint, 2, circuit.X)
+	if err != nil {
+		return err
+	}
+	if circuit.mark {
+		api.Compiler().MarkHintWiring(out...)
+	}
+	if circuit.markInput {
+		api.Compiler().MarkHintWiring(circuit.X)
+	}
+	api.AssertIsEqual(out[0], out[1])
+	api.AssertIsEqual(out[0], circuit.X)
+	return nil
+}
+
+func TestSparseR1CSHintWiring(t *testing.T) {
+	compile := func(circuit *hintWiringCircuit) *cs.SparseR1CS {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs.(*cs.SparseR1CS)
+	}
+	solve := func(spr *cs.SparseR1CS, h hint.Function, opts ...backend.ProverOption) error {
+		opt, err := backend.NewProverConfig(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt.HintFunctions[hint.UUID(pairHint)] = h
+		_, err = spr.Solve(fr.Vector{fr.NewElement(3)}, opt)
+		return err
+	}
+	// skewed outputs break the wiring only, shifted ones also break the downstream constraint
+	skewed := func(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		outputs[0].Set(inputs[0])
+		outputs[1].Add(inputs[0], big.NewInt(1))
+		return nil
+	}
+	shifted := func(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		outputs[0].Add(inputs[0], big.NewInt(1))
+		outputs[1].Add(inputs[0], big.NewInt(1))
+		return nil
+	}
+
+	unmarked := compile(&hintWiringCircuit{})
+	if len(unmarked.MHintWiring) != 0 {
+		t.Fatalf("expected no tagged constraint, got %v", unmarked.MHintWiring)
+	}
+	marked := compile(&hintWiringCircuit{mark: true})
+	if len(marked.MHintWiring) != 1 {
+		t.Fatalf("expected a single tagged constraint, got %v", marked.MHintWiring)
+	}
+
+	for _, spr := range []*cs.SparseR1CS{unmarked, marked} {
+		if err := solve(spr, pairHint); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the tagged constraint is not checked by the solver, but by the debugging solvers
+	if err := solve(unmarked, skewed); err == nil {
+		t.Fatal("expected the wiring constraint to fail")
+	}
+	if err := solve(marked, skewed); err != nil {
+		t.Fatalf("expected the wiring constraint to be skipped, got %v", err)
+	}
+	if err := solve(marked, skewed, backend.WithReverseCheckOrder()); err == nil {
+		t.Fatal("expected the reverse check solver to check the wiring constraint")
+	}
+
+	// the downstream constraint catches the bad hint
+	err := solve(marked, shifted)
+	var unsatisfied *cs.UnsatisfiedConstraintError
+	if !errors.As(err, &unsatisfied) {
+		t.Fatalf("expected an unsatisfied constraint, got %v", err)
+	}
+	if marked.IsHintWiring(unsatisfied.CID) {
+		t.Fatalf("constraint #%d reported unsatisfied is tagged", unsatisfied.CID)
+	}
+
+	// the tags are serialized
+	var buf bytes.Buffer
+	if _, err := marked.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var reconstructed cs.SparseR1CS
+	if _, err := reconstructed.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reconstructed.MHintWiring, marked.MHintWiring) {
+		t.Fatalf("expected tags %v, got %v", marked.MHintWiring, reconstructed.MHintWiring)
+	}
+
+	// only hint outputs can be marked
+	if _, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &hintWiringCircuit{markInput: true}); err == nil {
+		t.Fatal("expected an error marking an input as hint wiring")
+	}
+}
+
+// cubeCircuit computes X³ through X²
+type cubeCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubeCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(api.Mul(circuit.X, circuit.X), circuit.X), circuit.Y)
+	return nil
+}
+
+func TestSparseR1CSValueBoundCheck(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &cubeCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spr := ccs.(*cs.SparseR1CS)
+
+	type report struct {
+		cID, wID int
+		v        *big.Int
+	}
+	// X² = 10⁶ is below the bound 2²⁰, X³ = 10⁹ above
+	bound := big.NewInt(1 << 20)
+	solve := func(x int64) []report {
+		var reports []report
+		opt, err := backend.NewProverConfig(backend.WithValueBoundCheck(bound, func(cID, wID int, v *big.Int) {
+			reports = append(reports, report{cID, wID, v})
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := frontend.NewWitness(&cubeCircuit{X: x, Y: x * x * x}, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := spr.Solve(w.Vector().(fr.Vector), opt); err != nil {
+			t.Fatal(err)
+		}
+		return reports
+	}
+
+	if reports := solve(10); len(reports) != 0 {
+		t.Fatalf("expected no value above the bound, got %v", reports)
+	}
+	reports := solve(1000)
+	if len(reports) != 1 {
+		t.Fatalf("expected a single value above the bound, got %v", reports)
+	}
+	if reports[0].v.Cmp(big.NewInt(1000000000)) != 0 {
+		t.Fatalf("expected X³ to be reported, got %s", reports[0].v)
+	}
+	if wID := spr.Constraints[reports[0].cID].O.WireID(); wID != reports[0].wID {
+		t.Fatalf("constraint #%d solves wire %d, not %d", reports[0].cID, wID, reports[0].wID)
+	}
+
+	if _, err := backend.NewProverConfig(backend.WithValueBoundCheck(big.NewInt(-1), func(int, int, *big.Int) {})); err == nil {
+		t.Fatal("expected an error for a negative bound")
+	}
+	if _, err := backend.NewProverConfig(backend.WithValueBoundCheck(bound, nil)); err == nil {
+		t.Fatal("expected an error for a nil callback")
+	}
+}
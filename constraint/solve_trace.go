@@ -0,0 +1,76 @@
+package constraint
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SolveTrace records the solving time of each level of a constraint system, see
+// backend.WithSolveTrace. A nil *SolveTrace records nothing.
+type SolveTrace struct {
+	start  time.Time
+	events []traceEvent
+}
+
+// traceEvent is a complete event ("ph": "X") of the Chrome trace event format; timestamps
+// and durations are in microseconds.
+type traceEvent struct {
+	Name     string         `json:"name"`
+	Category string         `json:"cat"`
+	Phase    string         `json:"ph"`
+	Ts       float64        `json:"ts"`
+	Dur      float64        `json:"dur"`
+	Pid      int            `json:"pid"`
+	Tid      int            `json:"tid"`
+	Args     map[string]int `json:"args"`
+}
+
+// NewSolveTrace returns an empty trace, whose timestamps are relative to now
+func NewSolveTrace() *SolveTrace {
+	return &SolveTrace{start: time.Now()}
+}
+
+// Now returns the current time if t records events, and the zero time otherwise
+func (t *SolveTrace) Now() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// AddLevel records that the level lID, made of nbConstraints constraints, was solved
+// from start until now.
+func (t *SolveTrace) AddLevel(lID, nbConstraints int, start time.Time) {
+	if t == nil {
+		return
+	}
+	end := time.Now()
+	t.events = append(t.events, traceEvent{
+		Name:     "level " + strconv.Itoa(lID),
+		Category: "solve",
+		Phase:    "X",
+		Ts:       float64(start.Sub(t.start).Nanoseconds()) / 1e3,
+		Dur:      float64(end.Sub(start).Nanoseconds()) / 1e3,
+		Args:     map[string]int{"level": lID, "constraints": nbConstraints},
+	})
+}
+
+// WriteTo writes the trace in the JSON object format of the Chrome trace event format,
+// which can be loaded in chrome://tracing or https://ui.perfetto.dev
+func (t *SolveTrace) WriteTo(w io.Writer) (int64, error) {
+	events := []traceEvent{}
+	if t != nil && t.events != nil {
+		events = t.events
+	}
+	data, err := json.Marshal(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{events, "ms"})
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
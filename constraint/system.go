@@ -12,6 +12,7 @@ import (
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/debug"
+	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/tinyfield"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
@@ -66,6 +67,12 @@ type ConstraintSystem interface {
 	// CheckUnconstrainedWires returns and error if the constraint system has wires that are not uniquely constrained.
 	// This is experimental.
 	CheckUnconstrainedWires() error
+
+	// GetSchema returns the schema of the compiled circuit, or nil if it is unknown
+	GetSchema() *schema.Schema
+
+	// SetSchema sets the schema of the circuit, called by frontend.Compile
+	SetSchema(s *schema.Schema)
 }
 
 type Iterable interface {
@@ -126,6 +133,10 @@ type System struct {
 	lbHints     map[*Hint]struct{} `cbor:"-"` // hints we processed in current round
 
 	CommitmentInfo Commitment
+
+	// Schema of the circuit the system was compiled from, to parse its JSON witnesses. Not
+	// encoded if nil, which leaves the encoding of systems without schema unchanged.
+	Schema *schema.Schema `cbor:",omitempty"`
 }
 
 // NewSystem initialize the common structure among constraint system
@@ -143,6 +154,86 @@ func NewSystem(scalarField *big.Int) System {
 	}
 }
 
+// CloneSystem returns a deep copy of the system; hints shared by several wires remain
+// shared in the copy. The Schema is immutable once compiled and is not copied.
+func (system *System) CloneSystem() System {
+	r := *system
+	r.Public = append([]string(nil), system.Public...)
+	r.Secret = append([]string(nil), system.Secret...)
+	r.Logs = cloneLogs(system.Logs)
+	r.DebugInfo = cloneLogs(system.DebugInfo)
+	r.SymbolTable = system.SymbolTable.Clone()
+
+	r.MDebug = make(map[int]int, len(system.MDebug))
+	for k, v := range system.MDebug {
+		r.MDebug[k] = v
+	}
+	r.MHintsDependencies = make(map[hint.ID]string, len(system.MHintsDependencies))
+	for k, v := range system.MHintsDependencies {
+		r.MHintsDependencies[k] = v
+	}
+	hints := make(map[*Hint]*Hint, len(system.MHints))
+	cloneHint := func(h *Hint) *Hint {
+		if c, ok := hints[h]; ok {
+			return c
+		}
+		c := &Hint{ID: h.ID, Inputs: cloneExpressions(h.Inputs), Wires: append([]int(nil), h.Wires...)}
+		hints[h] = c
+		return c
+	}
+	r.MHints = make(map[int]*Hint, len(system.MHints))
+	for k, h := range system.MHints {
+		r.MHints[k] = cloneHint(h)
+	}
+
+	r.Levels = make([][]int, len(system.Levels))
+	for i := range system.Levels {
+		r.Levels[i] = append([]int(nil), system.Levels[i]...)
+	}
+
+	if system.q != nil {
+		r.q = new(big.Int).Set(system.q)
+	}
+	r.lbWireLevel = append([]int(nil), system.lbWireLevel...)
+	r.lbOutputs = append([]uint32(nil), system.lbOutputs...)
+	r.lbHints = make(map[*Hint]struct{}, len(system.lbHints))
+	for h := range system.lbHints {
+		r.lbHints[cloneHint(h)] = struct{}{}
+	}
+
+	r.CommitmentInfo.Committed = append([]int(nil), system.CommitmentInfo.Committed...)
+	r.CommitmentInfo.CommittedAndCommitment = append([]int(nil), system.CommitmentInfo.CommittedAndCommitment...)
+
+	return r
+}
+
+func cloneExpressions(l []LinearExpression) []LinearExpression {
+	if l == nil {
+		return nil
+	}
+	r := make([]LinearExpression, len(l))
+	for i := range l {
+		r[i] = l[i].Clone()
+	}
+	return r
+}
+
+func cloneLogs(logs []LogEntry) []LogEntry {
+	if logs == nil {
+		return nil
+	}
+	r := make([]LogEntry, len(logs))
+	for i, l := range logs {
+		r[i] = LogEntry{
+			Caller:    l.Caller,
+			Format:    l.Format,
+			ToResolve: cloneExpressions(l.ToResolve),
+			Stack:     append([]int(nil), l.Stack...),
+		}
+	}
+	return r
+}
+
 func (system *System) GetNbSecretVariables() int {
 	return len(system.Secret)
 }
@@ -271,6 +362,32 @@ func (system *System) AttachDebugInfo(debugInfo DebugInfo, constraintID []int) {
 	}
 }
 
+// HintOutputCounts returns, for each hint function, the total number of wires
+// computed by its calls in the system, that is the number of wires the solver
+// obtains from it.
+func (system *System) HintOutputCounts() map[hint.ID]int {
+	counts := make(map[hint.ID]int, len(system.MHintsDependencies))
+	seen := make(map[*Hint]struct{})
+	for _, h := range system.MHints {
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+		counts[h.ID] += len(h.Wires)
+	}
+	return counts
+}
+
+// GetSchema returns the schema of the compiled circuit, or nil if it is unknown
+func (system *System) GetSchema() *schema.Schema {
+	return system.Schema
+}
+
+// SetSchema sets the schema of the circuit
+func (system *System) SetSchema(s *schema.Schema) {
+	system.Schema = s
+}
+
 // VariableToString implements Resolver
 func (system *System) VariableToString(vID int) string {
 	nbPublic := system.GetNbPublicVariables()
@@ -20,6 +20,23 @@ func NewSymbolTable() SymbolTable {
 	}
 }
 
+// Clone returns a deep copy of the symbol table
+func (st *SymbolTable) Clone() SymbolTable {
+	r := SymbolTable{
+		Locations:  append([]Location(nil), st.Locations...),
+		Functions:  append([]Function(nil), st.Functions...),
+		mFunctions: make(map[string]int, len(st.mFunctions)),
+		mLocations: make(map[uint64]int, len(st.mLocations)),
+	}
+	for k, v := range st.mFunctions {
+		r.mFunctions[k] = v
+	}
+	for k, v := range st.mLocations {
+		r.mLocations[k] = v
+	}
+	return r
+}
+
 func (st *SymbolTable) CollectStack() []int {
 	var r []int
 	if Debug {
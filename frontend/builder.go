@@ -22,6 +22,23 @@ type Compiler interface {
 	// This returns true if the v is a constant and v == 0 || v == 1.
 	IsBoolean(v Variable) bool
 
+	// MarkHintWiring marks the hint outputs v such that, once compiled, the constraints of
+	// which all the wires are among them are tagged as pure hint wiring: the PLONK constraint
+	// solver solves them but doesn't check them. Their correctness becomes the hint's
+	// responsibility, and must be re-verified by the constraints consuming its outputs, which
+	// are checked. The proof of an unsatisfied tagged constraint still fails to verify.
+	//
+	// This is a micro-optimization for hint-heavy circuits. It is a no-op for R1CS and in the
+	// test engine, which check all the constraints. Constants in v are ignored.
+	MarkHintWiring(v ...Variable)
+
+	// RangeCheck asserts that v < 2ⁿᵇᴮⁱᵗˢ and returns its nbBits bits in little endian.
+	//
+	// With PLONK, it compiles to nbBits range check gates, which check the booleanity of the
+	// bits with a dedicated selector instead of separate constraints. R1CS and the test
+	// engine fall back to api.ToBinary, as do constants and nbBits >= FieldBitLen.
+	RangeCheck(v Variable, nbBits int) []Variable
+
 	// NewHint initializes internal variables whose value will be evaluated
 	// using the provided hint function at run time from the inputs. Inputs must
 	// be either variables or convertible to *big.Int. The function returns an
@@ -60,7 +60,19 @@ func Compile(field *big.Int, newBuilder NewBuilder, circuit Circuit, opts ...Com
 	}
 
 	// compile the circuit into its final form
-	return builder.Compile()
+	ccs, err := builder.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the schema of the circuit, to parse witnesses matching its fields
+	s, err := schema.New(circuit, tVariable)
+	if err != nil {
+		return nil, fmt.Errorf("circuit schema: %w", err)
+	}
+	ccs.SetSchema(s)
+
+	return ccs, nil
 }
 
 func parseCircuit(builder Builder, circuit Circuit) (err error) {
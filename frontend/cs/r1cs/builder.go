@@ -203,6 +203,19 @@ func (builder *builder) getLinearExpression(_l interface{}) constraint.LinearExp
 	return L
 }
 
+// MarkHintWiring is a no-op: the R1CS solver checks all the constraints. See
+// frontend.Compiler.MarkHintWiring.
+func (builder *builder) MarkHintWiring(v ...frontend.Variable) {}
+
+// RangeCheck falls back to api.ToBinary: R1CS has no range check gate. See
+// frontend.Compiler.RangeCheck.
+func (builder *builder) RangeCheck(v frontend.Variable, nbBits int) []frontend.Variable {
+	if nbBits <= 0 {
+		panic("invalid nbBits")
+	}
+	return builder.ToBinary(v, nbBits)
+}
+
 // MarkBoolean sets (but do not **constraint**!) v to be boolean
 // This is useful in scenarios where a variable is known to be boolean through a constraint
 // that is not api.AssertIsBoolean. If v is a constant, this is a no-op.
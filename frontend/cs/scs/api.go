@@ -192,6 +192,51 @@ func (builder *scs) ToBinary(i1 frontend.Variable, n ...int) []frontend.Variable
 	return bits.ToBinary(builder, i1, bits.WithNbDigits(nbBits))
 }
 
+// RangeCheck asserts that v < 2ⁿᵇᴮⁱᵗˢ and returns its bits in little endian, see
+// frontend.Compiler.RangeCheck.
+//
+// The bits are accumulated from the most significant one, accᵢ = 2⋅accᵢ₊₁ + bᵢ, in range check
+// gates that also check that bᵢ is boolean, the last accumulator being v: that's nbBits gates,
+// instead of about 2⋅nbBits plain gates for api.ToBinary.
+func (builder *scs) RangeCheck(v frontend.Variable, nbBits int) []frontend.Variable {
+	if nbBits <= 0 {
+		panic("invalid nbBits")
+	}
+	if _, ok := builder.ConstantValue(v); ok || nbBits == 1 || nbBits >= builder.cs.FieldBitLen() {
+		return builder.ToBinary(v, nbBits)
+	}
+	t := v.(expr.TermToRefactor)
+
+	res, err := builder.NewHint(bits.NBits, nbBits, v)
+	if err != nil {
+		panic(err)
+	}
+	debug := builder.newDebugInfo("rangeCheck", t, fmt.Sprintf(" < 2^%d", nbBits))
+
+	// the most significant bit is alone in its gate, for its boolean check
+	msb := res[nbBits-1].(expr.TermToRefactor)
+	builder.addRangeCheckConstraint(msb, msb, msb, constraint.CoeffIdZero, constraint.CoeffIdZero, constraint.CoeffIdZero, debug)
+	builder.MarkBoolean(msb)
+
+	acc := msb
+	for i := nbBits - 2; i >= 0; i-- {
+		b := res[i].(expr.TermToRefactor)
+		builder.MarkBoolean(b)
+		if i == 0 {
+			// 2⋅acc + b - v == 0
+			var mCoef big.Int
+			mCoef.Neg(&builder.st.Coeffs[t.CID])
+			builder.addRangeCheckConstraint(acc, b, t, constraint.CoeffIdTwo, constraint.CoeffIdOne, builder.st.CoeffID(&mCoef), debug)
+			break
+		}
+		next := builder.newInternalVariable()
+		builder.addRangeCheckConstraint(acc, b, next, constraint.CoeffIdTwo, constraint.CoeffIdOne, constraint.CoeffIdMinusOne, debug)
+		acc = next
+	}
+
+	return res
+}
+
 // FromBinary packs b, seen as a fr.Element in little endian
 func (builder *scs) FromBinary(b ...frontend.Variable) frontend.Variable {
 	return bits.FromBinary(builder, b)
@@ -56,6 +56,9 @@ type scs struct {
 	// map for recording boolean constrained variables (to not constrain them twice)
 	mtBooleans map[int]struct{}
 
+	// hint outputs marked as pure hint wiring, see MarkHintWiring
+	mtHintWiring map[int]struct{}
+
 	q *big.Int
 }
 
@@ -64,9 +67,10 @@ type scs struct {
 // TODO @gbotrel restore capacity option!
 func newBuilder(field *big.Int, config frontend.CompileConfig) *scs {
 	builder := scs{
-		mtBooleans: make(map[int]struct{}),
-		st:         cs.NewCoeffTable(),
-		config:     config,
+		mtBooleans:   make(map[int]struct{}),
+		mtHintWiring: make(map[int]struct{}),
+		st:           cs.NewCoeffTable(),
+		config:       config,
 	}
 
 	curve := utils.FieldToCurve(field)
@@ -139,6 +143,21 @@ func (builder *scs) addPlonkConstraint(xa, xb, xc expr.TermToRefactor, qL, qR, q
 	builder.cs.AddConstraint(constraint.SparseR1C{L: L, R: R, O: O, M: [2]constraint.Term{U, V}, K: K.CoeffID()}, debug...)
 }
 
+// addRangeCheckConstraint adds the range check gate qL⋅xa + qR⋅xb + qO⋅xc == 0 ∧ xb ∈ {0,1}, see
+// constraint.SparseR1C.RangeCheck
+func (builder *scs) addRangeCheckConstraint(xa, xb, xc expr.TermToRefactor, qL, qR, qO int, debug ...constraint.DebugInfo) {
+	xa.SetCoeffID(qL)
+	xb.SetCoeffID(qR)
+	xc.SetCoeffID(qO)
+
+	L := builder.TOREFACTORMakeTerm(&builder.st.Coeffs[xa.CID], xa.VID)
+	R := builder.TOREFACTORMakeTerm(&builder.st.Coeffs[xb.CID], xb.VID)
+	O := builder.TOREFACTORMakeTerm(&builder.st.Coeffs[xc.CID], xc.VID)
+	U := builder.TOREFACTORMakeTerm(&builder.st.Coeffs[constraint.CoeffIdZero], xa.VID)
+	V := builder.TOREFACTORMakeTerm(&builder.st.Coeffs[constraint.CoeffIdZero], xb.VID)
+	builder.cs.AddConstraint(constraint.SparseR1C{L: L, R: R, O: O, M: [2]constraint.Term{U, V}, K: constraint.CoeffIdZero, RangeCheck: true}, debug...)
+}
+
 // newInternalVariable creates a new wire, appends it on the list of wires of the circuit, sets
 // the wire's id to the number of wires, and returns it
 func (builder *scs) newInternalVariable() expr.TermToRefactor {
@@ -212,6 +231,17 @@ func (builder *scs) MarkBoolean(v frontend.Variable) {
 	builder.mtBooleans[int(v.(expr.TermToRefactor).CID|(int(v.(expr.TermToRefactor).VID)<<32))] = struct{}{} // TODO @gbotrel fixme this is sketchy
 }
 
+// MarkHintWiring marks the hint outputs v, of which the constraints they are the only wires
+// of are tagged at compile time as pure hint wiring, not checked by the solver. See
+// frontend.Compiler.MarkHintWiring.
+func (builder *scs) MarkHintWiring(v ...frontend.Variable) {
+	for _, vv := range v {
+		if t, ok := vv.(expr.TermToRefactor); ok {
+			builder.mtHintWiring[t.VID] = struct{}{}
+		}
+	}
+}
+
 var tVariable reflect.Type
 
 func init() {
@@ -233,6 +263,14 @@ func (builder *scs) Compile() (constraint.ConstraintSystem, error) {
 		}
 	}
 
+	if len(builder.mtHintWiring) != 0 {
+		nbTagged, err := builder.cs.TagHintWiring(builder.mtHintWiring)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug().Int("nbConstraints", nbTagged).Msg("tagged pure hint wiring constraints")
+	}
+
 	return builder.cs, nil
 }
 
@@ -283,11 +283,9 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	pk.G2.Beta = g2PointsAff[len(B)+0]
 	pk.G2.Delta = g2PointsAff[len(B)+1]
 
-	// sets vk: [δ]2, [γ]2, -[δ]2, -[γ]2
+	// sets vk: [δ]2, [γ]2
 	vk.G2.Delta = g2PointsAff[len(B)+1]
 	vk.G2.Gamma = g2PointsAff[len(B)+2]
-	vk.G2.deltaNeg.Neg(&vk.G2.Delta)
-	vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
 
 	// ---------------------------------------------------------------------------------------------
 	// Pairing: vk.e
@@ -298,8 +296,8 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	vk.G1.Beta = pk.G1.Beta
 	vk.G1.Delta = pk.G1.Delta
 
-	vk.e, err = curve.Pair([]curve.G1Affine{pk.G1.Alpha}, []curve.G2Affine{pk.G2.Beta})
-	if err != nil {
+	// vk.e, -[δ]2, -[γ]2
+	if err := vk.Precompute(); err != nil {
 		return err
 	}
 	// set domain
@@ -571,11 +569,39 @@ func (pk *ProvingKey) IsDifferent(_other interface{}) bool {
 	return true
 }
 
+// MatchingKeys reports whether pk and vk were generated by the same Setup: they must share
+// [α]1, [β]2 and [δ]2, and [δ]1 of pk must match [δ]2 of vk, that is
+// e([δ]1, [1]2) == e([1]1, [δ]2).
+func MatchingKeys(pk *ProvingKey, vk *VerifyingKey) bool {
+	if !pk.G1.Alpha.Equal(&vk.G1.Alpha) || !pk.G2.Beta.Equal(&vk.G2.Beta) || !pk.G2.Delta.Equal(&vk.G2.Delta) {
+		return false
+	}
+	_, _, g1, g2 := curve.Generators()
+	var deltaNeg curve.G1Affine
+	deltaNeg.Neg(&pk.G1.Delta)
+	ok, err := curve.PairingCheck([]curve.G1Affine{deltaNeg, g1}, []curve.G2Affine{g2, vk.G2.Delta})
+	return err == nil && ok
+}
+
 // CurveID returns the curveID
 func (pk *ProvingKey) CurveID() ecc.ID {
 	return curve.ID
 }
 
+// Precompute computes and caches in the key the values Verify uses which only depend on
+// the key: e(α, β), -[δ]2 and -[γ]2. Setup and ReadFrom call it; it must be called again
+// if the points of the key are modified.
+func (vk *VerifyingKey) Precompute() error {
+	var err error
+	vk.e, err = curve.Pair([]curve.G1Affine{vk.G1.Alpha}, []curve.G2Affine{vk.G2.Beta})
+	if err != nil {
+		return err
+	}
+	vk.G2.deltaNeg.Neg(&vk.G2.Delta)
+	vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
+	return nil
+}
+
 // CurveID returns the curveID
 func (vk *VerifyingKey) CurveID() ecc.ID {
 	return curve.ID
@@ -606,6 +632,25 @@ func (pk *ProvingKey) NbG2() int {
 	return 2 + len(pk.G2.B)
 }
 
+// ProvingKeySizes returns the number of G1 and G2 elements of the ProvingKey Setup would
+// compute for r1cs, that is, pk.NbG1() and pk.NbG2(), without running it.
+//
+// Wires not appearing in any L (resp. R) of the constraints give points at infinity, which
+// are filtered out of pk.G1.A (resp. pk.G1.B and pk.G2.B).
+func ProvingKeySizes(r1cs *cs.R1CS) (nbG1, nbG2 int) {
+	nbWires := r1cs.NbInternalVariables + r1cs.GetNbPublicVariables() + r1cs.GetNbSecretVariables()
+	nbPrivateWires := r1cs.GetNbSecretVariables() + r1cs.NbInternalVariables - r1cs.CommitmentInfo.NbPrivateCommitted
+	if r1cs.CommitmentInfo.Is() {
+		nbPrivateWires--
+	}
+	domain := fft.NewDomain(uint64(len(r1cs.Constraints)))
+	nbZeroesA, nbZeroesB := dummyInfinityCount(r1cs)
+
+	nbG1 = 3 + (nbWires - nbZeroesA) + (nbWires - nbZeroesB) + int(domain.Cardinality) + nbPrivateWires
+	nbG2 = 2 + (nbWires - nbZeroesB)
+	return
+}
+
 // bitRerverse permutation as in fft.BitReverse , but with []curve.G1Affine
 func bitReverse(a []curve.G1Affine) {
 	n := uint(len(a))
@@ -17,6 +17,7 @@
 package groth16
 
 import (
+	"bytes"
 	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"io"
 )
@@ -55,6 +56,26 @@ func (proof *Proof) writeTo(w io.Writer, raw bool) (int64, error) {
 	return enc.BytesWritten(), nil
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler
+//
+// The points are compressed if the proof was generated with backend.WithCompressedProofOutput,
+// and uncompressed otherwise. A compressed proof is half the size of an uncompressed one, at the
+// cost of a square root per point when decoding it.
+func (proof *Proof) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := proof.writeTo(&buf, !proof.compressed); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// it accepts both compressed and uncompressed encodings.
+func (proof *Proof) UnmarshalBinary(data []byte) error {
+	_, err := proof.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
 // ReadFrom attempts to decode a Proof from reader
 // Proof must be encoded through WriteTo (compressed) or WriteRawTo (uncompressed)
 func (proof *Proof) ReadFrom(r io.Reader) (n int64, err error) {
@@ -171,13 +192,9 @@ func (vk *VerifyingKey) readFrom(r io.Reader, decOptions ...func(*curve.Decoder)
 	}
 
 	// recompute vk.e (e(α, β)) and  -[δ]2, -[γ]2
-	var err error
-	vk.e, err = curve.Pair([]curve.G1Affine{vk.G1.Alpha}, []curve.G2Affine{vk.G2.Beta})
-	if err != nil {
+	if err := vk.Precompute(); err != nil {
 		return dec.BytesRead(), err
 	}
-	vk.G2.deltaNeg.Neg(&vk.G2.Delta)
-	vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
 
 	return dec.BytesRead(), nil
 }
@@ -19,11 +19,19 @@ package plonk
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
 
+	"bytes"
 	"errors"
+	"fmt"
 	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
 	"io"
 )
 
+// serializationVersion is the first byte written by VerifyingKey.WriteTo, and thus by
+// ProvingKey.WriteTo which starts with the verifying key. Keys written before it was
+// introduced start with the most significant byte of the domain size, always 0, and have no
+// range check gates selector (Qrange); they are still read, with no range check gates.
+const serializationVersion byte = 1
+
 // WriteTo writes binary encoding of Proof to w without point compression
 func (proof *Proof) WriteRawTo(w io.Writer) (int64, error) {
 	return proof.writeTo(w, curve.RawEncoding())
@@ -126,6 +134,7 @@ func (pk *ProvingKey) WriteTo(w io.Writer) (n int64, err error) {
 		([]fr.Element)(pk.S1Canonical),
 		([]fr.Element)(pk.S2Canonical),
 		([]fr.Element)(pk.S3Canonical),
+		([]fr.Element)(pk.Qrange),
 		pk.Permutation,
 	}
 
@@ -141,7 +150,7 @@ func (pk *ProvingKey) WriteTo(w io.Writer) (n int64, err error) {
 // ReadFrom reads from binary representation in r into ProvingKey
 func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
 	pk.Vk = &VerifyingKey{}
-	n, err := pk.Vk.ReadFrom(r)
+	n, legacy, err := pk.Vk.readFrom(r)
 	if err != nil {
 		return n, err
 	}
@@ -171,8 +180,11 @@ func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
 		(*[]fr.Element)(&pk.S1Canonical),
 		(*[]fr.Element)(&pk.S2Canonical),
 		(*[]fr.Element)(&pk.S3Canonical),
-		&pk.Permutation,
 	}
+	if !legacy {
+		toDecode = append(toDecode, (*[]fr.Element)(&pk.Qrange))
+	}
+	toDecode = append(toDecode, &pk.Permutation)
 
 	for _, v := range toDecode {
 		if err := dec.Decode(v); err != nil {
@@ -180,14 +192,22 @@ func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
 		}
 	}
 
+	if legacy || len(pk.Qrange) == 0 {
+		// no range check gates
+		pk.Qrange = nil
+	}
+
 	pk.computeLagrangeCosetPolys()
 
 	return n + dec.BytesRead(), nil
 
 }
 
-// WriteTo writes binary encoding of VerifyingKey to w
+// WriteTo writes binary encoding of VerifyingKey to w: a version byte followed by its fields
 func (vk *VerifyingKey) WriteTo(w io.Writer) (n int64, err error) {
+	if _, err := w.Write([]byte{serializationVersion}); err != nil {
+		return 0, err
+	}
 	enc := curve.NewEncoder(w)
 
 	toEncode := []interface{}{
@@ -204,19 +224,46 @@ func (vk *VerifyingKey) WriteTo(w io.Writer) (n int64, err error) {
 		&vk.Qm,
 		&vk.Qo,
 		&vk.Qk,
+		&vk.Qrange,
 	}
 
 	for _, v := range toEncode {
 		if err := enc.Encode(v); err != nil {
-			return enc.BytesWritten(), err
+			return 1 + enc.BytesWritten(), err
 		}
 	}
 
-	return enc.BytesWritten(), nil
+	return 1 + enc.BytesWritten(), nil
 }
 
-// ReadFrom reads from binary representation in r into VerifyingKey
+// ReadFrom reads from binary representation in r into VerifyingKey.
+// It reads the current format, and the legacy one that had no version byte; other
+// versions are rejected.
 func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
+	n, _, err := vk.readFrom(r)
+	return n, err
+}
+
+// readFrom is ReadFrom, and also reports whether vk was in the legacy format, with no
+// version byte and no Qrange.
+func (vk *VerifyingKey) readFrom(r io.Reader) (int64, bool, error) {
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return 0, false, err
+	}
+	nbHeaderBytes := int64(1)
+	legacy := false
+	switch version[0] {
+	case serializationVersion:
+	case 0:
+		// legacy format: the byte read is the first one of vk.Size
+		r = io.MultiReader(bytes.NewReader(version[:]), r)
+		nbHeaderBytes = 0
+		legacy = true
+	default:
+		return 1, false, fmt.Errorf("unsupported verifying key serialization version %d", version[0])
+	}
+
 	dec := curve.NewDecoder(r)
 	toDecode := []interface{}{
 		&vk.Size,
@@ -233,12 +280,17 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 		&vk.Qo,
 		&vk.Qk,
 	}
+	if legacy {
+		vk.Qrange = curve.G1Affine{}
+	} else {
+		toDecode = append(toDecode, &vk.Qrange)
+	}
 
 	for _, v := range toDecode {
 		if err := dec.Decode(v); err != nil {
-			return dec.BytesRead(), err
+			return nbHeaderBytes + dec.BytesRead(), legacy, err
 		}
 	}
 
-	return dec.BytesRead(), nil
+	return nbHeaderBytes + dec.BytesRead(), legacy, nil
 }
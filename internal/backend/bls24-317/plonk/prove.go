@@ -18,6 +18,7 @@ package plonk
 
 import (
 	"crypto/sha256"
+	"fmt"
 	"math/big"
 	"runtime"
 	"sync"
@@ -61,21 +62,9 @@ type Proof struct {
 // Prove from the public data
 func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness fr.Vector, opt backend.ProverConfig) (*Proof, error) {
 
-	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "plonk").Logger()
-	start := time.Now()
-	// pick a hash function that will be used to derive the challenges
-	hFunc := sha256.New()
-
-	// create a transcript manager to apply Fiat Shamir
-	fs := fiatshamir.NewTranscript(hFunc, "gamma", "beta", "alpha", "zeta")
-
-	// result
-	proof := &Proof{}
-
 	// compute the constraint system solution
-	var solution []fr.Element
-	var err error
-	if solution, err = spr.Solve(fullWitness, opt); err != nil {
+	solution, err := spr.Solve(fullWitness, opt)
+	if err != nil {
 		if !opt.Force {
 			return nil, err
 		} else {
@@ -89,6 +78,35 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness fr.Vector, opt backen
 		}
 	}
 
+	return ProveSolved(spr, pk, solution, opt)
+}
+
+// ProveSolved is the same as Prove, from a solution returned by spr.Solve, which starts
+// with the full witness. If opt.SolutionChecksum is set, it first checks that it matches
+// ChecksumSolution(solution), which detects a solution corrupted after it was solved.
+func ProveSolved(spr *cs.SparseR1CS, pk *ProvingKey, solution fr.Vector, opt backend.ProverConfig) (*Proof, error) {
+	nbVariables := spr.NbInternalVariables + len(spr.Public) + len(spr.Secret)
+	if len(solution) != nbVariables {
+		return nil, fmt.Errorf("invalid solution size, got %d, expected %d", len(solution), nbVariables)
+	}
+	if opt.SolutionChecksum != nil {
+		if checksum := ChecksumSolution(solution); checksum != *opt.SolutionChecksum {
+			return nil, fmt.Errorf("solution checksum mismatch: got %#x, expected %#x", checksum, *opt.SolutionChecksum)
+		}
+	}
+	fullWitness := solution[:len(spr.Public)+len(spr.Secret)]
+
+	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "plonk").Logger()
+	start := time.Now()
+	// pick a hash function that will be used to derive the challenges
+	hFunc := sha256.New()
+
+	// create a transcript manager to apply Fiat Shamir
+	fs := fiatshamir.NewTranscript(hFunc, "gamma", "beta", "alpha", "zeta")
+
+	// result
+	proof := &Proof{}
+
 	// query l, r, o in Lagrange basis, not blinded
 	evaluationLDomainSmall, evaluationRDomainSmall, evaluationODomainSmall := evaluateLROSmallDomain(spr, pk, solution)
 
@@ -262,19 +280,30 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness fr.Vector, opt backen
 		return one
 	}
 
-	// 0 , 1,  2,  3,  4,  5,  6, 7,  8,  9, 10, 11, 12, 13, 14
-	// l , r , o, id, s1, s2, s3, z, zs, ql, qr, qm, qo, qk,lone
+	// qrange⋅r⋅(r-1), the range check gates
+	frange := func(fqrange, r fr.Element) fr.Element {
+		one := fr.One()
+		one.Sub(&r, &one).Mul(&one, &r).Mul(&one, &fqrange)
+		return one
+	}
+
+	// 0 , 1,  2,  3,  4,  5,  6, 7,  8,  9, 10, 11, 12, 13, 14,     15
+	// l , r , o, id, s1, s2, s3, z, zs, ql, qr, qm, qo, qk,lone, qrange (if any)
 	fm := func(x ...fr.Element) fr.Element {
 
 		a := fic(x[9], x[10], x[11], x[12], x[13], x[0], x[1], x[2])
 		b := fo(x[0], x[1], x[2], x[3], x[4], x[5], x[6], x[7], x[8])
 		c := fone(x[7], x[14])
+		if len(x) > 15 {
+			d := frange(x[15], x[1])
+			c.Add(&c, d.Mul(&d, &alpha))
+		}
 
 		c.Mul(&c, &alpha).Add(&c, &b).Mul(&c, &alpha).Add(&c, &a)
 
 		return c
 	}
-	testEval, err := iop.Evaluate(fm, iop.Form{Basis: iop.LagrangeCoset, Layout: iop.BitReverse},
+	polys := []*iop.Polynomial{
 		bwliop,
 		bwriop,
 		bwoiop,
@@ -290,7 +319,11 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness fr.Vector, opt backen
 		wqoiop,
 		wqkiop,
 		wloneiop,
-	)
+	}
+	if pk.lQrange != nil {
+		polys = append(polys, iop.NewPolynomial(&pk.lQrange, lagrangeCosetBitReversed))
+	}
+	testEval, err := iop.Evaluate(fm, iop.Form{Basis: iop.LagrangeCoset, Layout: iop.BitReverse}, polys...)
 	if err != nil {
 		return nil, err
 	}
@@ -544,6 +577,7 @@ func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.El
 // α²*L₁(ζ)*Z(X)
 // + α*( (l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*Z(μζ)*s3(X) - Z(X)*(l(ζ)+β*id1(ζ)+γ)*(r(ζ)+β*id2(ζ)+γ)*(o(ζ)+β*id3(ζ)+γ))
 // + l(ζ)*Ql(X) + l(ζ)r(ζ)*Qm(X) + r(ζ)*Qr(X) + o(ζ)*Qo(X) + Qk(X)
+// + α³*r(ζ)(r(ζ)-1)*Qrange(X), if the system has range check gates
 func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu fr.Element, blindedZCanonical []fr.Element, pk *ProvingKey) []fr.Element {
 
 	// first part: individual constraints
@@ -592,6 +626,14 @@ func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta,
 						Mul(&lagrangeZeta, &alpha).
 						Mul(&lagrangeZeta, &pk.Domain[0].CardinalityInv) // (1/n)*α²*L₁(ζ)
 
+	// fourth part r(ζ)(r(ζ)-1)*α³*Qrange
+	var rangeZeta fr.Element
+	rangeZeta.Sub(&rZeta, &one).
+		Mul(&rangeZeta, &rZeta).
+		Mul(&rangeZeta, &alpha).
+		Mul(&rangeZeta, &alpha).
+		Mul(&rangeZeta, &alpha) // α³*r(ζ)(r(ζ)-1)
+
 	linPol := make([]fr.Element, len(blindedZCanonical))
 	copy(linPol, blindedZCanonical)
 
@@ -626,9 +668,34 @@ func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta,
 				linPol[i].Add(&linPol[i], &t0) // linPol = linPol + o(ζ)*Qo(X) + Qk(X)
 			}
 
+			if i < len(pk.Qrange) {
+				t0.Mul(&pk.Qrange[i], &rangeZeta)
+				linPol[i].Add(&linPol[i], &t0) // linPol = linPol + α³*r(ζ)(r(ζ)-1)*Qrange(X)
+			}
+
 			t0.Mul(&blindedZCanonical[i], &lagrangeZeta)
 			linPol[i].Add(&linPol[i], &t0) // finish the computation
 		}
 	})
 	return linPol
 }
+
+// ChecksumSolution returns a fast, non cryptographic, hash of values, to detect a
+// solution vector corrupted between spr.Solve and ProveSolved (see backend.WithSolutionChecksum).
+//
+// Each limb is xor-ed into the state, which is then multiplied by the 64-bit FNV prime;
+// both steps are bijective, so that changing a single element changes the checksum.
+func ChecksumSolution(values []fr.Element) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := range values {
+		for _, limb := range values[i] {
+			h ^= limb
+			h *= prime64
+		}
+	}
+	return h
+}
@@ -17,12 +17,19 @@
 package plonk
 
 import (
+	"context"
 	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/iop"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/internal/utils"
 
 	kzgg "github.com/consensys/gnark-crypto/kzg"
 )
@@ -33,6 +40,7 @@ import (
 // * qr, qm, qo prepended with as many zeroes as there are public inputs.
 // * qk, prepended with as many zeroes as public inputs, to be completed by the prover
 // with the list of public inputs.
+// * qrange, the range check gates selector, if the system has any
 // * sigma_1, sigma_2, sigma_3 in both basis
 // * the copy constraint permutation
 type ProvingKey struct {
@@ -47,6 +55,10 @@ type ProvingKey struct {
 	// qr,ql,qm,qo (in lagrange coset basis) --> these are not serialized, but computed from Ql, Qr, Qm, Qo once.
 	lQl, lQr, lQm, lQo []fr.Element
 
+	// Qrange (in canonical basis) selects the range check gates, see constraint.SparseR1C.RangeCheck.
+	// It is nil if the system has none, and lQrange is its lagrange coset version (not serialized).
+	Qrange, lQrange []fr.Element
+
 	// LQk (CQk) qk in Lagrange basis (canonical basis), prepended with as many zeroes as public inputs.
 	// Storing LQk in Lagrange basis saves a fft...
 	CQk, LQk []fr.Element
@@ -91,10 +103,82 @@ type VerifyingKey struct {
 	// Commitments to ql, qr, qm, qo prepended with as many zeroes (ones for l) as there are public inputs.
 	// In particular Qk is not complete.
 	Ql, Qr, Qm, Qo, Qk kzg.Digest
+
+	// Qrange commitment to the range check gates selector, the point at infinity if the
+	// system has none.
+	Qrange kzg.Digest
 }
 
 // Setup sets proving and verifying keys
-func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error) {
+func Setup(spr *cs.SparseR1CS, srs *kzg.SRS, opts ...backend.SetupOption) (*ProvingKey, *VerifyingKey, error) {
+	return SetupWithContext(context.Background(), spr, srs, opts...)
+}
+
+// SetupWithContext is like Setup, but checks ctx between the setup phases (selector
+// FFTs, permutation, commitments) and aborts returning ctx.Err() once ctx is done.
+func SetupWithContext(ctx context.Context, spr *cs.SparseR1CS, srs *kzg.SRS, opts ...backend.SetupOption) (*ProvingKey, *VerifyingKey, error) {
+	return setup(ctx, spr, srs, nil, false, opts...)
+}
+
+// SetupWithDomains is like Setup, but uses the given FFT domains instead of building them,
+// to amortize their construction over several setups of systems of the same size; for
+// example, the pk.Domain of a previous setup.
+//
+// domains[0] and domains[1] must have the cardinality of the small and big (quotient) domains
+// Setup would build for spr and opts, otherwise an error is returned. They are not copied:
+// the proving key shares their precomputed twiddles.
+func SetupWithDomains(spr *cs.SparseR1CS, srs *kzg.SRS, domains *[2]fft.Domain, opts ...backend.SetupOption) (*ProvingKey, *VerifyingKey, error) {
+	if domains == nil {
+		return nil, nil, errors.New("nil domains")
+	}
+	return setup(context.Background(), spr, srs, domains, false, opts...)
+}
+
+// SetupVerifyingKeyOnly returns the verifying key Setup would return, without
+// computing the evaluations of the selectors and of the permutation on the big
+// domain, which only the prover needs.
+func SetupVerifyingKeyOnly(spr *cs.SparseR1CS, srs *kzg.SRS, opts ...backend.SetupOption) (*VerifyingKey, error) {
+	_, vk, err := setup(context.Background(), spr, srs, nil, true, opts...)
+	return vk, err
+}
+
+// MatchingKeys reports whether vk is the verifying key embedded in pk, that is, whether pk
+// and vk were returned by the same Setup, by comparing their sizes and commitments. Keys
+// of the same constraint system and SRS match.
+func MatchingKeys(pk *ProvingKey, vk *VerifyingKey) bool {
+	pkVk := pk.Vk
+	if pkVk == nil || vk == nil {
+		return false
+	}
+	if pkVk.Size != vk.Size || pkVk.NbPublicVariables != vk.NbPublicVariables || !pkVk.CosetShift.Equal(&vk.CosetShift) {
+		return false
+	}
+	for i := range vk.S {
+		if !pkVk.S[i].Equal(&vk.S[i]) {
+			return false
+		}
+	}
+	if !pkVk.Ql.Equal(&vk.Ql) || !pkVk.Qr.Equal(&vk.Qr) || !pkVk.Qm.Equal(&vk.Qm) || !pkVk.Qo.Equal(&vk.Qo) || !pkVk.Qk.Equal(&vk.Qk) || !pkVk.Qrange.Equal(&vk.Qrange) {
+		return false
+	}
+	if pkVk.KZGSRS == nil || vk.KZGSRS == nil {
+		return pkVk.KZGSRS == vk.KZGSRS
+	}
+	return pkVk.KZGSRS.G2[1].Equal(&vk.KZGSRS.G2[1])
+}
+
+// setup computes the proving and verifying keys; if vkOnly is set, the returned
+// proving key lacks the Lagrange coset evaluations and must not be used to prove.
+// If domains is nil, the FFT domains are built.
+func setup(ctx context.Context, spr *cs.SparseR1CS, srs *kzg.SRS, domains *[2]fft.Domain, vkOnly bool, opts ...backend.SetupOption) (*ProvingKey, *VerifyingKey, error) {
+	opt, err := backend.NewSetupConfig(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
 	var pk ProvingKey
 	var vk VerifyingKey
 
@@ -105,17 +189,33 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 
 	// fft domains
 	sizeSystem := uint64(nbConstraints + len(spr.Public)) // len(spr.Public) is for the placeholder constraints
-	pk.Domain[0] = *fft.NewDomain(sizeSystem)
-	pk.Vk.CosetShift.Set(&pk.Domain[0].FrMultiplicativeGen)
 
 	// h, the quotient polynomial is of degree 3(n+1)+2, so it's in a 3(n+2) dim vector space,
 	// the domain is the next power of 2 superior to 3(n+2). 4*domainNum is enough in all cases
 	// except when n<6.
+	multiplier := uint64(4)
 	if sizeSystem < 6 {
-		pk.Domain[1] = *fft.NewDomain(8 * sizeSystem)
+		multiplier = 8
+	}
+	if opt.QuotientDomainMultiplier != 0 {
+		if opt.QuotientDomainMultiplier < multiplier {
+			return nil, nil, fmt.Errorf("quotient domain multiplier must be at least %d, got %d", multiplier, opt.QuotientDomainMultiplier)
+		}
+		multiplier = opt.QuotientDomainMultiplier
+	}
+	if domains == nil {
+		pk.Domain[0] = *fft.NewDomain(sizeSystem)
+		pk.Domain[1] = *fft.NewDomain(multiplier * sizeSystem)
 	} else {
-		pk.Domain[1] = *fft.NewDomain(4 * sizeSystem)
+		if expected := ecc.NextPowerOfTwo(sizeSystem); domains[0].Cardinality != expected {
+			return nil, nil, fmt.Errorf("invalid small domain cardinality: got %d, expected %d", domains[0].Cardinality, expected)
+		}
+		if expected := ecc.NextPowerOfTwo(multiplier * sizeSystem); domains[1].Cardinality != expected {
+			return nil, nil, fmt.Errorf("invalid big domain cardinality: got %d, expected %d", domains[1].Cardinality, expected)
+		}
+		pk.Domain = *domains
 	}
+	pk.Vk.CosetShift.Set(&pk.Domain[0].FrMultiplicativeGen)
 
 	vk.Size = pk.Domain[0].Cardinality
 	vk.SizeInv.SetUint64(vk.Size).Inverse(&vk.SizeInv)
@@ -134,14 +234,19 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 	pk.CQk = make([]fr.Element, pk.Domain[0].Cardinality)
 	pk.LQk = make([]fr.Element, pk.Domain[0].Cardinality)
 
-	for i := 0; i < len(spr.Public); i++ { // placeholders (-PUB_INPUT_i + qk_i = 0) TODO should return error is size is inconsistant
-		pk.Ql[i].SetOne().Neg(&pk.Ql[i])
-		pk.Qr[i].SetZero()
-		pk.Qm[i].SetZero()
-		pk.Qo[i].SetZero()
-		pk.CQk[i].SetZero()
-		pk.LQk[i].SetZero() // → to be completed by the prover
-	}
+	// placeholders (-PUB_INPUT_i + qk_i = 0)
+	// the selectors are freshly allocated, so only ql needs to be set; qr, qm, qo and
+	// qk are zero (LQk is completed by the prover).
+	// Note that the FFTs below can't take advantage of the structure of this block:
+	// the placeholder rows contribute -∑ Lᵢ to ql, which costs as much to interpolate
+	// separately as it does in the same inverse FFT as the constraints.
+	var minusOne fr.Element
+	minusOne.SetOne().Neg(&minusOne)
+	utils.Parallelize(len(spr.Public), func(start, end int) {
+		for i := start; i < end; i++ {
+			pk.Ql[i] = minusOne
+		}
+	})
 	offset := len(spr.Public)
 	for i := 0; i < nbConstraints; i++ { // constraints
 
@@ -152,6 +257,12 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 		pk.Qo[offset+i].Set(&spr.Coefficients[spr.Constraints[i].O.CoeffID()])
 		pk.CQk[offset+i].Set(&spr.Coefficients[spr.Constraints[i].K])
 		pk.LQk[offset+i].Set(&spr.Coefficients[spr.Constraints[i].K])
+		if spr.Constraints[i].RangeCheck {
+			if pk.Qrange == nil {
+				pk.Qrange = make([]fr.Element, pk.Domain[0].Cardinality)
+			}
+			pk.Qrange[offset+i].SetOne()
+		}
 	}
 
 	pk.Domain[0].FFTInverse(pk.Ql, fft.DIF)
@@ -164,18 +275,33 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 	fft.BitReverse(pk.Qm)
 	fft.BitReverse(pk.Qo)
 	fft.BitReverse(pk.CQk)
+	if pk.Qrange != nil {
+		pk.Domain[0].FFTInverse(pk.Qrange, fft.DIF)
+		fft.BitReverse(pk.Qrange)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 
 	// build permutation. Note: at this stage, the permutation takes in account the placeholders
-	buildPermutation(spr, &pk)
+	if err := buildPermutation(spr, &pk); err != nil {
+		return nil, nil, err
+	}
 
 	// set s1, s2, s3
 	ccomputePermutationPolynomials(&pk)
 
 	// compute the lagrange coset basis versions (not serialized)
-	pk.computeLagrangeCosetPolys()
+	if !vkOnly {
+		pk.computeLagrangeCosetPolys()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 
 	// Commit to the polynomials to set up the verifying key
-	var err error
 	if vk.Ql, err = kzg.Commit(pk.Ql, vk.KZGSRS); err != nil {
 		return nil, nil, err
 	}
@@ -191,6 +317,14 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 	if vk.Qk, err = kzg.Commit(pk.CQk, vk.KZGSRS); err != nil {
 		return nil, nil, err
 	}
+	if pk.Qrange != nil {
+		if vk.Qrange, err = kzg.Commit(pk.Qrange, vk.KZGSRS); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	if vk.S[0], err = kzg.Commit(pk.S1Canonical, vk.KZGSRS); err != nil {
 		return nil, nil, err
 	}
@@ -217,11 +351,22 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 // The permutation is encoded as a slice s of size 3*size(l), where the
 // i-th entry of l∥r∥o is sent to the s[i]-th entry, so it acts on a tab
 // like this: for i in tab: tab[i] = tab[permutation[i]]
-func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
+//
+// The placeholder constraint of the i-th public variable has wire i in l, the i-th public
+// input of the verifier. An error is returned if this wire isn't solely the input, that is
+// if it is also a hint output, which the prover would solve regardless of the public input.
+// An error is also returned if a constraint references a wire out of the system.
+func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) error {
 
 	nbVariables := spr.NbInternalVariables + len(spr.Public) + len(spr.Secret)
 	sizeSolution := int(pk.Domain[0].Cardinality)
 
+	for i, name := range spr.Public {
+		if _, ok := spr.MHints[i]; ok {
+			return fmt.Errorf("public variable %q (wire %d) is a hint output", name, i)
+		}
+	}
+
 	// init permutation
 	pk.Permutation = make([]int64, 3*sizeSolution)
 	for i := 0; i < len(pk.Permutation); i++ {
@@ -236,6 +381,11 @@ func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
 
 	offset := len(spr.Public)
 	for i := 0; i < len(spr.Constraints); i++ { // IDs of LRO associated to constraints
+		for _, wID := range [3]int{spr.Constraints[i].L.WireID(), spr.Constraints[i].R.WireID(), spr.Constraints[i].O.WireID()} {
+			if wID < 0 || wID >= nbVariables {
+				return fmt.Errorf("constraint %d references wire %d, but the system has %d wires", i, wID, nbVariables)
+			}
+		}
 		lro[offset+i] = spr.Constraints[i].L.WireID()
 		lro[sizeSolution+offset+i] = spr.Constraints[i].R.WireID()
 		lro[2*sizeSolution+offset+i] = spr.Constraints[i].O.WireID()
@@ -263,6 +413,8 @@ func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
 			pk.Permutation[i] = cycle[lro[i]]
 		}
 	}
+
+	return nil
 }
 
 func (pk *ProvingKey) computeLagrangeCosetPolys() {
@@ -290,6 +442,12 @@ func (pk *ProvingKey) computeLagrangeCosetPolys() {
 	pk.lQm = wqmiop.Coefficients()
 	pk.lQo = wqoiop.Coefficients()
 
+	if pk.Qrange != nil {
+		wqrangeiop := iop.NewPolynomial(clone(pk.Qrange, pk.Domain[1].Cardinality), canReg)
+		wqrangeiop.ToLagrangeCoset(&pk.Domain[1])
+		pk.lQrange = wqrangeiop.Coefficients()
+	}
+
 	pk.lS1LagrangeCoset = ws1.Coefficients()
 	pk.lS2LagrangeCoset = ws2.Coefficients()
 	pk.lS3LagrangeCoset = ws3.Coefficients()
@@ -375,7 +533,7 @@ func (vk *VerifyingKey) InitKZG(srs kzgg.SRS) error {
 	_srs := srs.(*kzg.SRS)
 
 	if len(_srs.G1) < int(vk.Size) {
-		return errors.New("kzg srs is too small")
+		return backend.ErrSRSTooSmall{Required: int(vk.Size), Got: len(_srs.G1)}
 	}
 	vk.KZGSRS = _srs
 
@@ -387,7 +545,45 @@ func (vk *VerifyingKey) NbPublicWitness() int {
 	return int(vk.NbPublicVariables)
 }
 
+// NbG1 returns the number of G1 elements in the VerifyingKey: the commitments to
+// ql, qr, qm, qo, qk, to qrange if the system has range check gates, and to the
+// permutation polynomials s1, s2, s3
+func (vk *VerifyingKey) NbG1() int {
+	if !vk.Qrange.IsInfinity() {
+		return 6 + len(vk.S)
+	}
+	return 5 + len(vk.S)
+}
+
+// NbG2 returns the number of G2 elements in the VerifyingKey, that is the
+// G2 points of the KZG SRS used by the verifier
+func (vk *VerifyingKey) NbG2() int {
+	return 2
+}
+
+// ProofSize returns the size in bytes of a proof written with Proof.WriteTo: the
+// commitments to l, r, o, z, h1, h2, h3 and the two opening proofs as compressed G1
+// points, and the 7+1 claimed values, the first 7 of them prefixed by their number.
+// It doesn't depend on the circuit.
+func (vk *VerifyingKey) ProofSize() int {
+	const nbG1, nbClaimedValues = 9, 8
+	return nbG1*curve.SizeOfG1AffineCompressed + 4 + nbClaimedValues*fr.Bytes
+}
+
+// VerificationPairings returns the number of pairings computed by Verify, in a
+// single multi pairing check for the two batched KZG openings.
+func (vk *VerifyingKey) VerificationPairings() int {
+	return 2
+}
+
 // VerifyingKey returns pk.Vk
 func (pk *ProvingKey) VerifyingKey() interface{} {
 	return pk.Vk
 }
+
+// DomainSizes returns the cardinalities of the small domain, on which the
+// constraint system is interpolated, and of the big domain, on which the
+// quotient polynomial is computed.
+func (pk *ProvingKey) DomainSizes() (small, big uint64) {
+	return pk.Domain[0].Cardinality, pk.Domain[1].Cardinality
+}
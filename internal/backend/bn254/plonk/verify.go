@@ -19,6 +19,7 @@ package plonk
 import (
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"time"
@@ -44,6 +45,10 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector) error {
 	log := logger.Logger().With().Str("curve", "bn254").Str("backend", "plonk").Logger()
 	start := time.Now()
 
+	if len(publicWitness) != int(vk.NbPublicVariables) {
+		return fmt.Errorf("invalid number of public inputs: got %d, expected %d", len(publicWitness), vk.NbPublicVariables)
+	}
+
 	// pick a hash function to derive the challenge (the same as in the prover)
 	hFunc := sha256.New()
 
@@ -164,7 +169,8 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector) error {
 	// linearizedPolynomialDigest =
 	// 		l(ζ)*ql+r(ζ)*qr+r(ζ)l(ζ)*qm+o(ζ)*qo+qk +
 	// 		α*( Z(μζ)(l(ζ)+β*s₁(ζ)+γ)*(r(ζ)+β*s₂(ζ)+γ)*s₃(X)-Z(X)(l(ζ)+β*id_1(ζ)+γ)*(r(ζ)+β*id_2(ζ)+γ)*(o(ζ)+β*id_3(ζ)+γ) ) +
-	// 		α²*L₁(ζ)*Z
+	// 		α²*L₁(ζ)*Z +
+	// 		α³*r(ζ)(r(ζ)-1)*qrange, if the system has range check gates
 	// first part: individual constraints
 	var rl fr.Element
 	rl.Mul(&l, &r)
@@ -197,6 +203,17 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector) error {
 		l, r, rl, o, one, // first part
 		_s1, _s2, // second & third part
 	}
+	if !vk.Qrange.IsInfinity() {
+		// fourth part: α³*r(ζ)(r(ζ)-1)*qrange
+		var rangeScalar fr.Element
+		rangeScalar.Sub(&r, &one).
+			Mul(&rangeScalar, &r).
+			Mul(&rangeScalar, &alpha).
+			Mul(&rangeScalar, &alpha).
+			Mul(&rangeScalar, &alpha)
+		points = append(points, vk.Qrange)
+		scalars = append(scalars, rangeScalar)
+	}
 	if _, err := linearizedPolynomialDigest.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
 		return err
 	}
@@ -271,6 +288,13 @@ func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk VerifyingKey
 	if err := fs.Bind(challenge, vk.Qk.Marshal()); err != nil {
 		return err
 	}
+	// not bound without range check gates, which leaves the transcript of the other
+	// systems unchanged
+	if !vk.Qrange.IsInfinity() {
+		if err := fs.Bind(challenge, vk.Qrange.Marshal()); err != nil {
+			return err
+		}
+	}
 
 	// public inputs
 	for i := 0; i < len(publicInputs); i++ {
@@ -309,6 +333,9 @@ func deriveRandomness(fs *fiatshamir.Transcript, challenge string, points ...*cu
 //
 // Code has not been audited and is provided as-is, we make no guarantees or warranties to its safety and reliability.
 func (vk *VerifyingKey) ExportSolidity(w io.Writer) error {
+	if !vk.Qrange.IsInfinity() {
+		return errors.New("range check gates are not supported by the solidity verifier")
+	}
 	tmpl, err := template.New("").Parse(solidityTemplate)
 	if err != nil {
 		return err
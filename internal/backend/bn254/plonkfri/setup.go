@@ -18,6 +18,7 @@ package plonkfri
 
 import (
 	"crypto/sha256"
+	"fmt"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fri"
@@ -107,6 +108,11 @@ func Setup(spr *cs.SparseR1CS) (*ProvingKey, *VerifyingKey, error) {
 	pk.Vk = &vk
 
 	nbConstraints := len(spr.Constraints)
+	for i := range spr.Constraints {
+		if spr.Constraints[i].RangeCheck {
+			return nil, nil, fmt.Errorf("constraint %d: range check gates are not supported", i)
+		}
+	}
 
 	// fft domains
 	sizeSystem := uint64(nbConstraints + len(spr.Public)) // len(spr.Public) is for the placeholder constraints
@@ -38,6 +38,21 @@ type Proof struct {
 	Ar, Krs                   curve.G1Affine
 	Bs                        curve.G2Affine
 	Commitment, CommitmentPok curve.G1Affine
+
+	// compressed is set by Prove when backend.WithCompressedProofOutput is used
+	// and selects the encoding of MarshalBinary. It is not serialized.
+	compressed bool
+
+	// forced is set by Prove when the witness doesn't solve the system and
+	// backend.IgnoreSolverError is used; the proof is then invalid. It is not serialized.
+	forced bool
+}
+
+// IsForced returns true if the proof was generated with backend.IgnoreSolverError from a
+// witness which doesn't solve the constraint system: such a proof is intentionally invalid,
+// and only meant for tests and benchmarks.
+func (proof *Proof) IsForced() bool {
+	return proof.forced
 }
 
 // isValid ensures proof elements are in the correct subgroup
@@ -51,6 +66,9 @@ func (proof *Proof) CurveID() ecc.ID {
 }
 
 // Prove generates the proof of knowledge of a r1cs with full witness (secret + public part).
+//
+// If opt.Profile is set (see backend.WithProfiling), it is filled with the durations of the
+// prover phases.
 func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverConfig) (*Proof, error) {
 	// TODO @gbotrel witness size check is done by R1CS, doesn't mean we shouldn't sanitize here.
 	// if len(witness) != r1cs.NbPublicVariables-1+r1cs.NbSecretVariables {
@@ -64,7 +82,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 	b := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
 	c := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
 
-	proof := &Proof{}
+	proof := &Proof{compressed: opt.CompressedProofOutput}
 	if r1cs.CommitmentInfo.Is() {
 		opt.HintFunctions[r1cs.CommitmentInfo.HintID] = func(_ *big.Int, in []*big.Int, out []*big.Int) error {
 			// Perf-TODO: Converting these values to big.Int and back may be a performance bottleneck.
@@ -94,11 +112,17 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 
 	var wireValues []fr.Element
 	var err error
-	if wireValues, err = r1cs.Solve(witness, a, b, c, opt); err != nil {
+	startSolve := time.Now()
+	wireValues, err = r1cs.Solve(witness, a, b, c, opt)
+	if opt.Profile != nil {
+		opt.Profile.Solve = time.Since(startSolve)
+	}
+	if err != nil {
 		if !opt.Force {
 			return nil, err
 		} else {
 			// we need to fill wireValues with random values else multi exps don't do much
+			proof.forced = true
 			var r fr.Element
 			_, _ = r.SetRandom()
 			for i := r1cs.GetNbPublicVariables() + r1cs.GetNbSecretVariables(); i < len(wireValues); i++ {
@@ -109,11 +133,25 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 	}
 	start := time.Now()
 
+	// number of tasks of the multi-exponentiations and of the parallel loops
+	n := runtime.NumCPU()
+	if opt.ProverParallelism > 0 && opt.ProverParallelism < n {
+		n = opt.ProverParallelism
+	}
+	nbTasksG1 := n / 2 // the G1 multi-exponentiations run two by two
+	if nbTasksG1 < 1 {
+		nbTasksG1 = 1
+	}
+
 	// H (witness reduction / FFT part)
 	var h []fr.Element
 	chHDone := make(chan struct{}, 1)
 	go func() {
-		h = computeH(a, b, c, &pk.Domain)
+		startH := time.Now()
+		h = computeH(a, b, c, &pk.Domain, n, opt.FFTStrategy)
+		if opt.Profile != nil {
+			opt.Profile.H = time.Since(startH)
+		}
 		a = nil
 		b = nil
 		c = nil
@@ -167,12 +205,10 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 
 	var bs1, ar curve.G1Jac
 
-	n := runtime.NumCPU()
-
 	chBs1Done := make(chan error, 1)
 	computeBS1 := func() {
 		<-chWireValuesB
-		if _, err := bs1.MultiExp(pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		if _, err := bs1.MultiExp(pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasksG1}); err != nil {
 			chBs1Done <- err
 			close(chBs1Done)
 			return
@@ -185,7 +221,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 	chArDone := make(chan error, 1)
 	computeAR1 := func() {
 		<-chWireValuesA
-		if _, err := ar.MultiExp(pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		if _, err := ar.MultiExp(pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: nbTasksG1}); err != nil {
 			chArDone <- err
 			close(chArDone)
 			return
@@ -204,14 +240,14 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 		var krs, krs2, p1 curve.G1Jac
 		chKrs2Done := make(chan error, 1)
 		go func() {
-			_, err := krs2.MultiExp(pk.G1.Z, h, ecc.MultiExpConfig{NbTasks: n / 2})
+			_, err := krs2.MultiExp(pk.G1.Z, h, ecc.MultiExpConfig{NbTasks: nbTasksG1})
 			chKrs2Done <- err
 		}()
 
 		// filter the wire values if needed;
 		_wireValues := filter(wireValues, r1cs.CommitmentInfo.PrivateToPublic())
 
-		if _, err := krs.MultiExp(pk.G1.K, _wireValues[r1cs.GetNbPublicVariables():], ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		if _, err := krs.MultiExp(pk.G1.K, _wireValues[r1cs.GetNbPublicVariables():], ecc.MultiExpConfig{NbTasks: nbTasksG1}); err != nil {
 			chKrsDone <- err
 			return
 		}
@@ -250,9 +286,10 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 	computeBS2 := func() error {
 		// Bs2 (1 multi exp G2 - size = len(wires))
 		var Bs, deltaS curve.G2Jac
+		startMSMG2 := time.Now()
 
 		nbTasks := n
-		if nbTasks <= 16 {
+		if nbTasks <= 16 && opt.ProverParallelism <= 0 {
 			// if we don't have a lot of CPUs, this may artificially split the MSM
 			nbTasks *= 2
 		}
@@ -267,6 +304,9 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 		Bs.AddMixed(&pk.G2.Beta)
 
 		proof.Bs.FromJacobian(&Bs)
+		if opt.Profile != nil {
+			opt.Profile.MSMG2 = time.Since(startMSMG2)
+		}
 		return nil
 	}
 
@@ -274,6 +314,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 	<-chHDone
 
 	// schedule our proof part computations
+	startMSMG1 := time.Now()
 	go computeKRS()
 	go computeAR1()
 	go computeBS1()
@@ -285,6 +326,9 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness fr.Vector, opt backend.ProverC
 	if err := <-chKrsDone; err != nil {
 		return nil, err
 	}
+	if opt.Profile != nil {
+		opt.Profile.MSMG1 = time.Since(startMSMG1)
+	}
 
 	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 
@@ -314,7 +358,7 @@ func filter(slice []fr.Element, toRemove []int) (r []fr.Element) {
 	return r
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+func computeH(a, b, c []fr.Element, domain *fft.Domain, nbTasks int, strategy backend.FFTStrategy) []fr.Element {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
@@ -330,13 +374,28 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	c = append(c, padding...)
 	n = len(a)
 
-	domain.FFTInverse(a, fft.DIF)
-	domain.FFTInverse(b, fft.DIF)
-	domain.FFTInverse(c, fft.DIF)
-
-	domain.FFT(a, fft.DIT, true)
-	domain.FFT(b, fft.DIT, true)
-	domain.FFT(c, fft.DIT, true)
+	if strategy == backend.FFTStrategyDIT {
+		// the coset evaluations are in bit-reversed order instead of the coefficients
+		fft.BitReverse(a)
+		fft.BitReverse(b)
+		fft.BitReverse(c)
+
+		domain.FFTInverse(a, fft.DIT)
+		domain.FFTInverse(b, fft.DIT)
+		domain.FFTInverse(c, fft.DIT)
+
+		domain.FFT(a, fft.DIF, true)
+		domain.FFT(b, fft.DIF, true)
+		domain.FFT(c, fft.DIF, true)
+	} else {
+		domain.FFTInverse(a, fft.DIF)
+		domain.FFTInverse(b, fft.DIF)
+		domain.FFTInverse(c, fft.DIF)
+
+		domain.FFT(a, fft.DIT, true)
+		domain.FFT(b, fft.DIT, true)
+		domain.FFT(c, fft.DIT, true)
+	}
 
 	var den, one fr.Element
 	one.SetOne()
@@ -351,10 +410,15 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 				Sub(&a[i], &c[i]).
 				Mul(&a[i], &den)
 		}
-	})
-
-	// ifft_coset
-	domain.FFTInverse(a, fft.DIF, true)
+	}, nbTasks)
+
+	// ifft_coset; h is expected in bit-reversed order, as pk.G1.Z
+	if strategy == backend.FFTStrategyDIT {
+		domain.FFTInverse(a, fft.DIT, true)
+		fft.BitReverse(a)
+	} else {
+		domain.FFTInverse(a, fft.DIF, true)
+	}
 
 	return a
 }
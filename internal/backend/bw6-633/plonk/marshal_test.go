@@ -63,6 +63,120 @@ func TestVerifyingKeySerialization(t *testing.T) {
 	roundTripCheck(t, &vk, &reconstructed)
 }
 
+func TestVerifyingKeyLegacySerialization(t *testing.T) {
+	// a vk written before the version byte and Qrange
+	var vk, reconstructed VerifyingKey
+	vk.randomize()
+	vk.Size = 1 << 20
+	vk.Qrange = curve.G1Affine{}
+
+	var buf bytes.Buffer
+	if err := vk.writeLegacyTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	written := int64(buf.Len())
+	reconstructed.Qrange = randomPoint()
+	read, err := reconstructed.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&vk, &reconstructed) {
+		t.Fatal("reconstructed object don't match original")
+	}
+	if written != read {
+		t.Fatal("bytes written / read don't match")
+	}
+
+	// unknown version
+	if _, err := reconstructed.ReadFrom(bytes.NewReader([]byte{serializationVersion + 1})); err == nil {
+		t.Fatal("expected an error with an unsupported version")
+	}
+}
+
+func TestProvingKeyLegacySerialization(t *testing.T) {
+	// a pk written before the version byte and Qrange
+	var pk, reconstructed ProvingKey
+	pk.randomize()
+	pk.Vk.Size = pk.Domain[0].Cardinality
+	pk.Vk.Qrange = curve.G1Affine{}
+	pk.Qrange = nil
+	pk.lQrange = nil
+
+	var buf bytes.Buffer
+	if err := pk.writeLegacyTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	written := int64(buf.Len())
+	read, err := reconstructed.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&pk, &reconstructed) {
+		t.Fatal("reconstructed object don't match original")
+	}
+	if written != read {
+		t.Fatal("bytes written / read don't match")
+	}
+}
+
+// writeLegacyTo writes vk in the encoding that predates the version byte and Qrange
+func (vk *VerifyingKey) writeLegacyTo(w io.Writer) error {
+	enc := curve.NewEncoder(w)
+	toEncode := []interface{}{
+		vk.Size,
+		&vk.SizeInv,
+		&vk.Generator,
+		vk.NbPublicVariables,
+		&vk.CosetShift,
+		&vk.S[0],
+		&vk.S[1],
+		&vk.S[2],
+		&vk.Ql,
+		&vk.Qr,
+		&vk.Qm,
+		&vk.Qo,
+		&vk.Qk,
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLegacyTo writes pk in the encoding that predates the version byte and Qrange
+func (pk *ProvingKey) writeLegacyTo(w io.Writer) error {
+	if err := pk.Vk.writeLegacyTo(w); err != nil {
+		return err
+	}
+	if _, err := pk.Domain[0].WriteTo(w); err != nil {
+		return err
+	}
+	if _, err := pk.Domain[1].WriteTo(w); err != nil {
+		return err
+	}
+	enc := curve.NewEncoder(w)
+	toEncode := []interface{}{
+		([]fr.Element)(pk.Ql),
+		([]fr.Element)(pk.Qr),
+		([]fr.Element)(pk.Qm),
+		([]fr.Element)(pk.Qo),
+		([]fr.Element)(pk.CQk),
+		([]fr.Element)(pk.LQk),
+		([]fr.Element)(pk.S1Canonical),
+		([]fr.Element)(pk.S2Canonical),
+		([]fr.Element)(pk.S3Canonical),
+		pk.Permutation,
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func roundTripCheck(t *testing.T, from io.WriterTo, reconstructed io.ReaderFrom) {
 	var buf bytes.Buffer
 	written, err := from.WriteTo(&buf)
@@ -122,6 +236,7 @@ func (pk *ProvingKey) randomize() {
 	pk.S1Canonical = randomScalars(n)
 	pk.S2Canonical = randomScalars(n)
 	pk.S3Canonical = randomScalars(n)
+	pk.Qrange = randomScalars(n)
 
 	pk.Permutation = make([]int64, 3*pk.Domain[0].Cardinality)
 	pk.Permutation[0] = -12
@@ -145,6 +260,7 @@ func (vk *VerifyingKey) randomize() {
 	vk.Qm = randomPoint()
 	vk.Qo = randomPoint()
 	vk.Qk = randomPoint()
+	vk.Qrange = randomPoint()
 }
 
 func (proof *Proof) randomize() {
@@ -0,0 +1,53 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backend asserts at compile time that the generated constraint systems
+// implement the constraint package interfaces.
+//
+// The backends (groth16.NewCS, plonk.NewCS, ...) assign the curve specific types
+// to constraint.ConstraintSystem; if a generated type drifts, the build fails here
+// instead of at the (distant) assignment site.
+package backend
+
+import (
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bls24315 "github.com/consensys/gnark/constraint/bls24-315"
+	cs_bls24317 "github.com/consensys/gnark/constraint/bls24-317"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6633 "github.com/consensys/gnark/constraint/bw6-633"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+	cs_tinyfield "github.com/consensys/gnark/constraint/tinyfield"
+)
+
+var (
+	_ constraint.R1CS = (*cs_bn254.R1CS)(nil)
+	_ constraint.R1CS = (*cs_bls12377.R1CS)(nil)
+	_ constraint.R1CS = (*cs_bls12381.R1CS)(nil)
+	_ constraint.R1CS = (*cs_bls24315.R1CS)(nil)
+	_ constraint.R1CS = (*cs_bls24317.R1CS)(nil)
+	_ constraint.R1CS = (*cs_bw6633.R1CS)(nil)
+	_ constraint.R1CS = (*cs_bw6761.R1CS)(nil)
+	_ constraint.R1CS = (*cs_tinyfield.R1CS)(nil)
+
+	_ constraint.SparseR1CS = (*cs_bn254.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_bls12377.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_bls12381.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_bls24315.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_bls24317.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_bw6633.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_bw6761.SparseR1CS)(nil)
+	_ constraint.SparseR1CS = (*cs_tinyfield.SparseR1CS)(nil)
+)
@@ -0,0 +1,67 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+// x³ + x + 5 == y
+func (c *cubicCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(c.X, c.X, c.X)
+	api.AssertIsEqual(c.Y, api.Add(x3, c.X, 5))
+	return nil
+}
+
+func TestCompiledConstraintSystems(t *testing.T) {
+	assert := require.New(t)
+
+	for _, curve := range gnark.Curves() {
+		w, err := frontend.NewWitness(&cubicCircuit{X: 3, Y: 35}, curve.ScalarField())
+		assert.NoError(err)
+		bad, err := frontend.NewWitness(&cubicCircuit{X: 3, Y: 36}, curve.ScalarField())
+		assert.NoError(err)
+
+		ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &cubicCircuit{})
+		assert.NoError(err)
+		_, ok := ccs.(constraint.R1CS)
+		assert.True(ok, "%s: r1cs builder didn't return a constraint.R1CS", curve)
+		checkSolving(assert, ccs, w, bad)
+
+		ccs, err = frontend.Compile(curve.ScalarField(), scs.NewBuilder, &cubicCircuit{})
+		assert.NoError(err)
+		_, ok = ccs.(constraint.SparseR1CS)
+		assert.True(ok, "%s: scs builder didn't return a constraint.SparseR1CS", curve)
+		checkSolving(assert, ccs, w, bad)
+	}
+}
+
+func checkSolving(assert *require.Assertions, ccs constraint.ConstraintSystem, w, bad witness.Witness) {
+	assert.NoError(ccs.IsSolved(w))
+	assert.Error(ccs.IsSolved(bad))
+}
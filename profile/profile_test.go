@@ -4,6 +4,8 @@ package profile_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/frontend"
@@ -25,7 +27,14 @@ func Example() {
 	// use pprof as usual (go tool pprof -http=:8080 gnark.pprof) to read the profile file
 	// overlapping profiles are allowed (define profiles inside Define or subfunction to profile
 	// part of the circuit only)
-	p := profile.Start()
+	// here the profile is written in a temporary directory, not to leave it in the tree
+	dir, err := os.MkdirTemp("", "profile")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := profile.Start(profile.WithPath(filepath.Join(dir, "gnark.pprof")))
 	_, _ = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &Circuit{})
 	p.Stop()
 
@@ -35,6 +44,6 @@ func Example() {
 	// 2
 	// Showing nodes accounting for 2, 100% of 2 total
 	//       flat  flat%   sum%        cum   cum%
-	//          1 50.00% 50.00%          2   100%  profile_test.(*Circuit).Define profile/profile_test.go:19
+	//          1 50.00% 50.00%          2   100%  profile_test.(*Circuit).Define profile/profile_test.go:21
 	//          1 50.00%   100%          1 50.00%  r1cs.(*builder).AssertIsEqual frontend/cs/r1cs/api_assertions.go:37
 }
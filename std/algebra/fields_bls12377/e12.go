@@ -17,10 +17,11 @@ limitations under the License.
 package fields_bls12377
 
 import (
+	"fmt"
 	"math/big"
+	"math/bits"
 
 	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
-	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -112,6 +113,16 @@ func (e *E12) SetOne() *E12 {
 	return e
 }
 
+// NewE12Zero returns the E12 element equal to 0
+func NewE12Zero() E12 {
+	return E12{C0: NewE6Zero(), C1: NewE6Zero()}
+}
+
+// NewE12One returns the E12 element equal to 1
+func NewE12One() E12 {
+	return E12{C0: NewE6One(), C1: NewE6Zero()}
+}
+
 func (e *E12) assign(e1 []frontend.Variable) {
 	e.C0.B0.A0 = e1[0]
 	e.C0.B0.A1 = e1[1]
@@ -263,8 +274,7 @@ func (e *E12) CyclotomicSquareCompressed(api frontend.API, x E12) *E12 {
 func (e *E12) Decompress(api frontend.API, x E12) *E12 {
 
 	var t [3]E2
-	var one E2
-	one.SetOne()
+	one := NewE2One()
 
 	// t0 = g1²
 	t[0].Square(api, x.C0.B1)
@@ -354,7 +364,7 @@ func (e *E12) MulBy034(api frontend.API, c3, c4 E2) *E12 {
 
 	b.MulBy01(api, c3, c4)
 
-	c3.Add(api, E2{A0: 1, A1: 0}, c3)
+	c3.Add(api, NewE2One(), c3)
 	d.Add(api, e.C0, e.C1)
 	d.MulBy01(api, c3, c4)
 
@@ -366,8 +376,8 @@ func (e *E12) MulBy034(api frontend.API, c3, c4 E2) *E12 {
 
 // Mul034By034 multiplication of sparse element (1,0,0,c3,c4,0) by sparse element (1,0,0,d3,d4,0)
 func (e *E12) Mul034By034(api frontend.API, d3, d4, c3, c4 E2) *E12 {
-	var one, tmp, x3, x4, x04, x03, x34 E2
-	one.SetOne()
+	var tmp, x3, x4, x04, x03, x34 E2
+	one := NewE2One()
 	x3.Mul(api, c3, d3)
 	x4.Mul(api, c4, d4)
 	x04.Add(api, c4, d4)
@@ -429,7 +439,7 @@ func (e *E12) FrobeniusCube(api frontend.API, e1 E12) *E12 {
 	return e
 }
 
-var InverseE12Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+func InverseE12Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	var a, c bls12377.E12
 
 	a.C0.B0.A0.SetBigInt(inputs[0])
@@ -463,10 +473,6 @@ var InverseE12Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	return nil
 }
 
-func init() {
-	hint.Register(InverseE12Hint)
-}
-
 // Inverse e12 elmts
 func (e *E12) Inverse(api frontend.API, e1 E12) *E12 {
 
@@ -476,9 +482,9 @@ func (e *E12) Inverse(api frontend.API, e1 E12) *E12 {
 		panic(err)
 	}
 
-	var e3, one E12
+	var e3 E12
 	e3.assign(res[:12])
-	one.SetOne()
+	one := NewE12One()
 
 	// 1 == e3 * e1
 	e3.Mul(api, e3, e1)
@@ -489,7 +495,7 @@ func (e *E12) Inverse(api frontend.API, e1 E12) *E12 {
 	return e
 }
 
-var DivE12Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+func DivE12Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	var a, b, c bls12377.E12
 
 	a.C0.B0.A0.SetBigInt(inputs[0])
@@ -536,10 +542,6 @@ var DivE12Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	return nil
 }
 
-func init() {
-	hint.Register(DivE12Hint)
-}
-
 // DivUnchecked e12 elmts
 func (e *E12) DivUnchecked(api frontend.API, e1, e2 E12) *E12 {
 
@@ -561,22 +563,19 @@ func (e *E12) DivUnchecked(api frontend.API, e1, e2 E12) *E12 {
 	return e
 }
 
+// Div e12 elmts
+//
+// As opposed to DivUnchecked, it constrains e2 to be non-zero such that 0/0
+// can't be satisfied with an arbitrary quotient.
+func (e *E12) Div(api frontend.API, e1, e2 E12) *E12 {
+	api.AssertIsEqual(e2.isZero(api), 0)
+	return e.DivUnchecked(api, e1, e2)
+}
+
 // Select sets e to r1 if b=1, r2 otherwise
 func (e *E12) Select(api frontend.API, b frontend.Variable, r1, r2 E12) *E12 {
-
-	e.C0.B0.A0 = api.Select(b, r1.C0.B0.A0, r2.C0.B0.A0)
-	e.C0.B0.A1 = api.Select(b, r1.C0.B0.A1, r2.C0.B0.A1)
-	e.C0.B1.A0 = api.Select(b, r1.C0.B1.A0, r2.C0.B1.A0)
-	e.C0.B1.A1 = api.Select(b, r1.C0.B1.A1, r2.C0.B1.A1)
-	e.C0.B2.A0 = api.Select(b, r1.C0.B2.A0, r2.C0.B2.A0)
-	e.C0.B2.A1 = api.Select(b, r1.C0.B2.A1, r2.C0.B2.A1)
-	e.C1.B0.A0 = api.Select(b, r1.C1.B0.A0, r2.C1.B0.A0)
-	e.C1.B0.A1 = api.Select(b, r1.C1.B0.A1, r2.C1.B0.A1)
-	e.C1.B1.A0 = api.Select(b, r1.C1.B1.A0, r2.C1.B1.A0)
-	e.C1.B1.A1 = api.Select(b, r1.C1.B1.A1, r2.C1.B1.A1)
-	e.C1.B2.A0 = api.Select(b, r1.C1.B2.A0, r2.C1.B2.A0)
-	e.C1.B2.A1 = api.Select(b, r1.C1.B2.A1, r2.C1.B2.A1)
-
+	e.C0.Select(api, b, r1.C0, r2.C0)
+	e.C1.Select(api, b, r1.C1, r2.C1)
 	return e
 }
 
@@ -587,10 +586,21 @@ func (e *E12) nSquareCompressed(api frontend.API, n int) {
 	}
 }
 
-// Expt compute e1**exponent, where the exponent is hardcoded
-// This function is only used for the final expo of the pairing for bls12377, so the exponent is supposed to be hardcoded
-// and on 64 bits.
+// seed x of BLS12-377, the exponent of the addition chain of Expt
+const seed = 9586122913090633729
+
+// Expt compute e1**exponent, e1 being in the cyclotomic subgroup.
+// The exponent of the final expo of the pairing for bls12377, the seed x, uses a hardcoded
+// addition chain of compressed squares; any other (non-zero) exponent falls back to the
+// table of the squares of e1, see PrecomputePowers and ExptFromPowers.
 func (e *E12) Expt(api frontend.API, e1 E12, exponent uint64) *E12 {
+	if exponent != seed {
+		if exponent == 0 {
+			panic("exponent must be non-zero")
+		}
+		powers := e1.PrecomputePowers(api, bits.Len64(exponent)-1)
+		return e.ExptFromPowers(api, powers, exponent)
+	}
 
 	res := e1
 
@@ -616,6 +626,120 @@ func (e *E12) Expt(api frontend.API, e1 E12, exponent uint64) *E12 {
 
 }
 
+// PrecomputePowers returns the table [e, e², e⁴, ..., e^(2^maxBit)] of the successive
+// cyclotomic squares of e, to be shared by several exponentiations of e, see ExptFromPowers.
+// e must be in the cyclotomic subgroup, as the output of EasyPart.
+func (e E12) PrecomputePowers(api frontend.API, maxBit int) []E12 {
+	powers := make([]E12, maxBit+1)
+	powers[0] = e
+	for i := 1; i <= maxBit; i++ {
+		powers[i].CyclotomicSquare(api, powers[i-1])
+	}
+	return powers
+}
+
+// ExptFromPowers sets e to powers[0]**exponent and returns e, where powers is the table
+// returned by PrecomputePowers, by multiplying the powers matching the set bits of the
+// exponent. No squaring is done, so raising the same element to several exponents costs
+// only the multiplications once the table is built: with the 64-bit seed x, the table then
+// pays off from the second exponentiation of the base, compared to Expt.
+//
+// It panics if the table doesn't cover the most significant bit of the exponent, or if
+// the exponent is 0.
+func (e *E12) ExptFromPowers(api frontend.API, powers []E12, exponent uint64) *E12 {
+	if exponent == 0 {
+		panic("exponent must be non-zero")
+	}
+	if n := bits.Len64(exponent); n > len(powers) {
+		panic(fmt.Sprintf("exponent on %d bits but only %d powers precomputed", n, len(powers)))
+	}
+
+	var res E12
+	first := true
+	for i := 0; exponent>>i != 0; i++ {
+		if (exponent>>i)&1 == 0 {
+			continue
+		}
+		if first {
+			res = powers[i]
+			first = false
+		} else {
+			res.Mul(api, res, powers[i])
+		}
+	}
+	*e = res
+
+	return e
+}
+
+// EasyPart sets e to m^((p⁶-1)(p²+1)), the easy part of the final exponentiation of the
+// pairing, and returns e. m must be non-zero; the result is in the cyclotomic subgroup.
+func (e *E12) EasyPart(api frontend.API, m E12) *E12 {
+	var t E12
+	t.Conjugate(api, m)
+	t.DivUnchecked(api, t, m)
+	e.FrobeniusSquare(api, t).
+		Mul(api, *e, t)
+
+	return e
+}
+
+// HardPart sets e to m raised to the hard part (p⁴-p²+1)/r of the final exponentiation of
+// the pairing, up to the same multiple as in gnark-crypto, and returns e. m must be in the
+// cyclotomic subgroup, as the output of EasyPart.
+//
+// Daiki Hayashida and Kenichiro Hayasaka and Tadanori Teruya
+// https://eprint.iacr.org/2020/875.pdf
+func (e *E12) HardPart(api frontend.API, m E12) *E12 {
+	// every exponentiation raises a different element, so that there is no table of powers
+	// to share: Expt's addition chain is cheaper
+	const x = seed
+
+	result := m
+	var t [3]E12
+	t[0].CyclotomicSquare(api, result)
+	t[1].Expt(api, result, x)
+	t[2].Conjugate(api, result)
+	t[1].Mul(api, t[1], t[2])
+	t[2].Expt(api, t[1], x)
+	t[1].Conjugate(api, t[1])
+	t[1].Mul(api, t[1], t[2])
+	t[2].Expt(api, t[1], x)
+	t[1].Frobenius(api, t[1])
+	t[1].Mul(api, t[1], t[2])
+	result.Mul(api, result, t[0])
+	t[0].Expt(api, t[1], x)
+	t[2].Expt(api, t[0], x)
+	t[0].FrobeniusSquare(api, t[1])
+	t[1].Conjugate(api, t[1])
+	t[1].Mul(api, t[1], t[2])
+	t[1].Mul(api, t[1], t[0])
+	result.Mul(api, result, t[1])
+
+	*e = result
+
+	return e
+}
+
+// AssertIsInCyclotomicSubgroup constraints e to be in the cyclotomic subgroup of 𝔽p¹², of
+// order Φ₁₂(p) = p⁴-p²+1, which contains GT: it checks that e ≠ 0 and e^(p⁴+1) == e^(p²).
+//
+// Note that the weaker condition e^(p⁶) == e.Conjugate() holds for any e in 𝔽p¹², and
+// e^(p⁶+1) == 1 only ensures e is in the subgroup of order p⁶+1.
+func (e *E12) AssertIsInCyclotomicSubgroup(api frontend.API) {
+	api.AssertIsEqual(e.isZero(api), 0)
+
+	var a, b E12
+	b.FrobeniusSquare(api, *e)
+	a.FrobeniusSquare(api, b).Mul(api, a, *e)
+	a.AssertIsEqual(api, b)
+}
+
+// isZero returns 1 if e == 0 and 0 otherwise
+func (e *E12) isZero(api frontend.API) frontend.Variable {
+	return api.And(e.C0.isZero(api), e.C1.isZero(api))
+}
+
 // Assign a value to self (witness assignment)
 func (e *E12) Assign(a *bls12377.E12) {
 	e.C0.Assign(&a.C0)
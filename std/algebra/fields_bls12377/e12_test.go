@@ -22,7 +22,9 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/test"
 )
 
@@ -91,6 +93,75 @@ func TestSubFp12(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type fp12Neg struct {
+	A E12
+	C E12 `gnark:",public"`
+}
+
+func (circuit *fp12Neg) Define(api frontend.API) error {
+	expected := E12{}
+	expected.Neg(api, circuit.A)
+	expected.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestNegFp12(t *testing.T) {
+
+	var circuit, witness fp12Neg
+
+	// witness values
+	var a, c bls12377.E12
+	_, _ = a.SetRandom()
+	c.C0.Neg(&a.C0)
+	c.C1.Neg(&a.C1)
+
+	witness.A.Assign(&a)
+	witness.C.Assign(&c)
+
+	// cs values
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+type fp12Select struct {
+	B    frontend.Variable
+	A, C E12
+	R    E12 `gnark:",public"`
+}
+
+func (circuit *fp12Select) Define(api frontend.API) error {
+	expected := E12{}
+	expected.Select(api, circuit.B, circuit.A, circuit.C)
+	expected.AssertIsEqual(api, circuit.R)
+	return nil
+}
+
+func TestSelectFp12(t *testing.T) {
+
+	var circuit, witness fp12Select
+
+	// witness values
+	var a, c bls12377.E12
+	_, _ = a.SetRandom()
+	_, _ = c.SetRandom()
+
+	witness.A.Assign(&a)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+
+	// b = 1 selects A
+	witness.B = 1
+	witness.R.Assign(&a)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// b = 0 selects C
+	witness.B = 0
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+	witness.R.Assign(&c)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 type fp12Mul struct {
 	A, B E12
 	C    E12 `gnark:",public"`
@@ -135,6 +206,40 @@ func (circuit *fp12Square) Define(api frontend.API) error {
 	return nil
 }
 
+type fp12MulByOne struct {
+	A E12
+}
+
+func (circuit *fp12MulByOne) Define(api frontend.API) error {
+	var expected, zero E12
+	expected.Mul(api, circuit.A, NewE12One())
+	expected.AssertIsEqual(api, circuit.A)
+	zero.Mul(api, circuit.A, NewE12Zero())
+	zero.AssertIsEqual(api, NewE12Zero())
+	return nil
+}
+
+func TestMulByOneFp12(t *testing.T) {
+
+	var circuit, witness fp12MulByOne
+
+	// witness values
+	var a bls12377.E12
+	_, _ = a.SetRandom()
+	witness.A.Assign(&a)
+
+	// the constructors set the same limbs as SetOne and SetZero
+	var one, zero E12
+	one.SetOne()
+	zero.SetZero()
+	if NewE12One() != one || NewE12Zero() != zero {
+		t.Fatal("constructors don't match SetOne and SetZero")
+	}
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 func TestSquareFp12(t *testing.T) {
 
 	var circuit, witness fp12Square
@@ -193,6 +298,53 @@ func TestFp12CyclotomicSquare(t *testing.T) {
 
 }
 
+type fp12InCyclotomicSubgroup struct {
+	A E12
+}
+
+func (circuit *fp12InCyclotomicSubgroup) Define(api frontend.API) error {
+	circuit.A.AssertIsInCyclotomicSubgroup(api)
+	return nil
+}
+
+func TestFp12AssertIsInCyclotomicSubgroup(t *testing.T) {
+
+	var circuit, witness fp12InCyclotomicSubgroup
+	assert := test.NewAssert(t)
+
+	// GT element
+	_, _, g1, g2 := bls12377.Generators()
+	gt, err := bls12377.Pair([]bls12377.G1Affine{g1}, []bls12377.G2Affine{g2})
+	assert.NoError(err)
+	witness.A.Assign(&gt)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// random element raised to the power (p⁶-1)(p²+1)
+	var a, tmp bls12377.E12
+	_, _ = a.SetRandom()
+	tmp.Conjugate(&a)
+	a.Inverse(&a)
+	tmp.Mul(&tmp, &a)
+	a.FrobeniusSquare(&tmp).Mul(&a, &tmp)
+	witness.A.Assign(&a)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// only raised to the power p⁶-1: in the subgroup of order p⁶+1, but not in the cyclotomic one
+	witness.A.Assign(&tmp)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// random element
+	_, _ = a.SetRandom()
+	witness.A.Assign(&a)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// zero
+	a = bls12377.E12{}
+	witness.A.Assign(&a)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+}
+
 type fp12CycloSquareCompressed struct {
 	A E12
 	B E12 `gnark:",public"`
@@ -367,6 +519,43 @@ func TestDivFp12(t *testing.T) {
 	assert.SolvingSucceeded(&e12Div{}, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type e12DivChecked struct {
+	A, B, C E12
+}
+
+func (circuit *e12DivChecked) Define(api frontend.API) error {
+	var expected E12
+
+	expected.Div(api, circuit.A, circuit.B)
+	expected.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestDivCheckedFp12(t *testing.T) {
+
+	// witness values
+	var a, b, c bls12377.E12
+	_, _ = a.SetRandom()
+	_, _ = b.SetRandom()
+	c.Inverse(&b).Mul(&c, &a)
+
+	var witness e12DivChecked
+	witness.A.Assign(&a)
+	witness.B.Assign(&b)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&e12DivChecked{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	// 0/0 is satisfied by any quotient with DivUnchecked, but not with Div
+	var zero bls12377.E12
+	witness.A.Assign(&zero)
+	witness.B.Assign(&zero)
+	witness.C.Assign(&zero)
+	assert.SolvingSucceeded(&e12Div{}, &witness, test.WithCurves(ecc.BW6_761))
+	assert.SolvingFailed(&e12DivChecked{}, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 type fp12FixedExpo struct {
 	A E12
 	C E12 `gnark:",public"`
@@ -405,6 +594,94 @@ func TestExpFixedExpoFp12(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type fp12ExptFromPowers struct {
+	A E12
+	C E12 `gnark:",public"`
+}
+
+func (circuit *fp12ExptFromPowers) Define(api frontend.API) error {
+	expo := uint64(9586122913090633729)
+
+	var direct, powered E12
+	direct.Expt(api, circuit.A, expo)
+	powers := circuit.A.PrecomputePowers(api, 63)
+	powered.ExptFromPowers(api, powers, expo)
+	powered.AssertIsEqual(api, direct)
+	powered.AssertIsEqual(api, circuit.C)
+
+	// the same table serves another exponent
+	var cube, expected E12
+	cube.ExptFromPowers(api, powers, 3)
+	expected.CyclotomicSquare(api, circuit.A).Mul(api, expected, circuit.A)
+	cube.AssertIsEqual(api, expected)
+
+	// as does Expt, for exponents other than the seed
+	cube.Expt(api, circuit.A, 3)
+	cube.AssertIsEqual(api, expected)
+	return nil
+}
+
+type fp12ExptCost struct {
+	A        E12
+	nbExpt   int
+	fromPows bool
+}
+
+func (circuit *fp12ExptCost) Define(api frontend.API) error {
+	var powers []E12
+	if circuit.fromPows {
+		powers = circuit.A.PrecomputePowers(api, 63)
+	}
+	for i := 0; i < circuit.nbExpt; i++ {
+		var res E12
+		if circuit.fromPows {
+			res.ExptFromPowers(api, powers, seed)
+		} else {
+			res.Expt(api, circuit.A, seed)
+		}
+	}
+	return nil
+}
+
+func TestExptFromPowersCost(t *testing.T) {
+	nbConstraints := func(nbExpt int, fromPows bool) int {
+		ccs, err := frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &fp12ExptCost{nbExpt: nbExpt, fromPows: fromPows})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs.GetNbConstraints()
+	}
+	// the table doesn't pay off for a single exponentiation, as in HardPart
+	if expt, table := nbConstraints(1, false), nbConstraints(1, true); table <= expt {
+		t.Fatalf("1 exponentiation with the table: %d constraints, %d with Expt", table, expt)
+	}
+	if expt, table := nbConstraints(2, false), nbConstraints(2, true); table >= expt {
+		t.Fatalf("2 exponentiations with the table: %d constraints, %d with Expt", table, expt)
+	}
+}
+
+func TestExptFromPowersFp12(t *testing.T) {
+	var circuit, witness fp12ExptFromPowers
+
+	var a, b, c bls12377.E12
+	expo := uint64(9586122913090633729)
+
+	// put a in the cyclotomic subgroup
+	_, _ = a.SetRandom()
+	b.Conjugate(&a)
+	a.Inverse(&a)
+	b.Mul(&b, &a)
+	a.FrobeniusSquare(&b).Mul(&a, &b)
+
+	c.Exp(a, new(big.Int).SetUint64(expo))
+
+	witness.A.Assign(&a)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 type fp12MulBy034 struct {
 	A    E12 `gnark:",public"`
 	W    E12
@@ -442,3 +719,37 @@ func TestFp12MulBy034(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 
 }
+
+type fp12FinalExpParts struct {
+	A E12
+	C E12 `gnark:",public"`
+}
+
+func (circuit *fp12FinalExpParts) Define(api frontend.API) error {
+	var e E12
+	e.EasyPart(api, circuit.A)
+	e.HardPart(api, e)
+	e.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestFinalExpPartsFp12(t *testing.T) {
+	var circuit, witness fp12FinalExpParts
+
+	// a Miller loop output
+	_, _, g1, g2 := bls12377.Generators()
+	var s fr.Element
+	_, _ = s.SetRandom()
+	g1.ScalarMultiplication(&g1, s.BigInt(new(big.Int)))
+	a, err := bls12377.MillerLoop([]bls12377.G1Affine{g1}, []bls12377.G2Affine{g2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := bls12377.FinalExponentiation(&a)
+
+	witness.A.Assign(&a)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
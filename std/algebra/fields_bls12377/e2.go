@@ -17,11 +17,12 @@ limitations under the License.
 package fields_bls12377
 
 import (
+	"errors"
 	"math/big"
 
 	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
 	"github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
-	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -44,18 +45,44 @@ func (e *E2) SetOne() *E2 {
 	return e
 }
 
-func (e *E2) assign(e1 []frontend.Variable) {
-	e.A0 = e1[0]
-	e.A1 = e1[1]
+// NewE2 returns the E2 element a0 + a1⋅u, where u² = -5.
+// It is the canonical constructor from limbs, and the inverse of Limbs.
+func NewE2(a0, a1 frontend.Variable) E2 {
+	return E2{A0: a0, A1: a1}
+}
+
+// Limbs returns the limbs a0, a1 of e = a0 + a1⋅u, in the order expected by NewE2
+func (e E2) Limbs() (a0, a1 frontend.Variable) {
+	return e.A0, e.A1
+}
+
+// NewE2Zero returns the E2 element equal to 0
+func NewE2Zero() E2 {
+	return NewE2(0, 0)
+}
+
+// NewE2One returns the E2 element equal to 1
+func NewE2One() E2 {
+	return NewE2(1, 0)
 }
 
 // Neg negates a e2 elmt
 func (e *E2) Neg(api frontend.API, e1 E2) *E2 {
-	e.A0 = api.Sub(0, e1.A0)
-	e.A1 = api.Sub(0, e1.A1)
+	e.A0 = negLimb(api, e1.A0)
+	e.A1 = negLimb(api, e1.A1)
 	return e
 }
 
+// negLimb returns -v; if v is known at compile time, so is -v and no constraint is added
+func negLimb(api frontend.API, v frontend.Variable) frontend.Variable {
+	if c, ok := api.Compiler().ConstantValue(v); ok {
+		q := api.Compiler().Field()
+		res := new(big.Int).Neg(c)
+		return res.Mod(res, q)
+	}
+	return api.Sub(0, v)
+}
+
 // Add e2 elmts
 func (e *E2) Add(api frontend.API, e1, e2 E2) *E2 {
 	e.A0 = api.Add(e1.A0, e2.A0)
@@ -79,40 +106,13 @@ func (e *E2) Sub(api frontend.API, e1, e2 E2) *E2 {
 
 // Mul e2 elmts
 func (e *E2) Mul(api frontend.API, e1, e2 E2) *E2 {
-
-	// 1C
-	l1 := api.Add(e1.A0, e1.A1)
-	l2 := api.Add(e2.A0, e2.A1)
-
-	u := api.Mul(l1, l2)
-
-	// 2C
-	ac := api.Mul(e1.A0, e2.A0)
-	bd := api.Mul(e1.A1, e2.A1)
-
-	l31 := api.Add(ac, bd)
-	e.A1 = api.Sub(u, l31)
-
-	l41 := api.Mul(bd, ext.uSquare)
-	e.A0 = api.Add(ac, l41)
-
+	*e = ext2.Mul(api, e1, e2)
 	return e
 }
 
 // Square e2 elt
 func (e *E2) Square(api frontend.API, x E2) *E2 {
-	//algo 22 https://eprint.iacr.org/2010/354.pdf
-	c0 := api.Add(x.A0, x.A1)
-	c2 := api.Mul(x.A1, ext.uSquare)
-	c2 = api.Add(c2, x.A0)
-
-	c0 = api.Mul(c0, c2) // (x1+x2)*(x1+(u**2)x2)
-	c2 = api.Mul(x.A0, x.A1)
-	c2 = api.Add(c2, c2)
-	e.A1 = c2
-	c2 = api.Add(c2, c2)
-	e.A0 = api.Add(c0, c2)
-
+	*e = ext2.Square(api, x)
 	return e
 }
 
@@ -124,11 +124,9 @@ func (e *E2) MulByFp(api frontend.API, e1 E2, c interface{}) *E2 {
 }
 
 // MulByNonResidue multiplies an fp2 elmt by the imaginary elmt
-// ext.uSquare is the square of the imaginary root
+// u² is the square of the imaginary root, see Ext2
 func (e *E2) MulByNonResidue(api frontend.API, e1 E2) *E2 {
-	x := e1.A0
-	e.A0 = api.Mul(e1.A1, ext.uSquare)
-	e.A1 = x
+	*e = ext2.MulByNonResidue(api, e1)
 	return e
 }
 
@@ -139,7 +137,7 @@ func (e *E2) Conjugate(api frontend.API, e1 E2) *E2 {
 	return e
 }
 
-var InverseE2Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+func InverseE2Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	var a, c bls12377.E2
 
 	a.A0.SetBigInt(inputs[0])
@@ -153,33 +151,29 @@ var InverseE2Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	return nil
 }
 
-func init() {
-	hint.Register(InverseE2Hint)
-}
-
 // Inverse e2 elmts
 func (e *E2) Inverse(api frontend.API, e1 E2) *E2 {
 
-	res, err := api.NewHint(InverseE2Hint, 2, e1.A0, e1.A1)
+	a0, a1 := e1.Limbs()
+	res, err := api.NewHint(InverseE2Hint, 2, a0, a1)
 	if err != nil {
 		// err is non-nil only for invalid number of inputs
 		panic(err)
 	}
 
-	var e3, one E2
-	e3.assign(res[:2])
-	one.SetOne()
+	e3 := NewE2(res[0], res[1])
+	one := NewE2One()
 
 	// 1 == e3 * e1
 	e3.Mul(api, e3, e1)
 	e3.AssertIsEqual(api, one)
 
-	e.assign(res[:2])
+	*e = NewE2(res[0], res[1])
 
 	return e
 }
 
-var DivE2Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+func DivE2Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	var a, b, c bls12377.E2
 
 	a.A0.SetBigInt(inputs[0])
@@ -195,31 +189,142 @@ var DivE2Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	return nil
 }
 
-func init() {
-	hint.Register(DivE2Hint)
-}
-
 // DivUnchecked e2 elmts
 func (e *E2) DivUnchecked(api frontend.API, e1, e2 E2) *E2 {
 
-	res, err := api.NewHint(DivE2Hint, 2, e1.A0, e1.A1, e2.A0, e2.A1)
+	a0, a1 := e1.Limbs()
+	b0, b1 := e2.Limbs()
+	res, err := api.NewHint(DivE2Hint, 2, a0, a1, b0, b1)
 	if err != nil {
 		// err is non-nil only for invalid number of inputs
 		panic(err)
 	}
 
-	var e3 E2
-	e3.assign(res[:2])
+	e3 := NewE2(res[0], res[1])
 
 	// e1 == e3 * e2
 	e3.Mul(api, e3, e2)
 	e3.AssertIsEqual(api, e1)
 
-	e.assign(res[:2])
+	*e = NewE2(res[0], res[1])
+
+	return e
+}
+
+func LegendreE2Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+	var a bls12377.E2
+
+	a.A0.SetBigInt(inputs[0])
+	a.A1.SetBigInt(inputs[1])
+
+	// norm(a) = a0² - u²⋅a1²
+	var n, t, r, uSquare fp.Element
+	uSquare.SetBigInt(ext.uSquare)
+	n.Square(&a.A0)
+	t.Square(&a.A1).Mul(&t, &uSquare)
+	n.Sub(&n, &t)
+
+	// r² = n, or r² = u²⋅n if n is not a square
+	l := a.Legendre()
+	if l == -1 {
+		n.Mul(&n, &uSquare)
+	}
+	if r.Sqrt(&n) == nil {
+		return errors.New("no square root")
+	}
+
+	res[0].SetInt64(int64(l))
+	res[0].Mod(res[0], fp.Modulus())
+	r.BigInt(res[1])
+
+	return nil
+}
+
+// Legendre returns the quadratic character of a: 1 if a is a non-zero square in E2,
+// -1 if it isn't a square and 0 if a == 0.
+//
+// a is a square in E2 if and only if its norm a0² - u²⋅a1² is a square in the base
+// field. The symbol s is given by a hint, along with a witness r such that r² = norm
+// if s == 1 and r² = u²⋅norm if s == -1, u² being a non-residue.
+func (e E2) Legendre(api frontend.API, a E2) frontend.Variable {
+	a0, a1 := a.Limbs()
+	res, err := api.NewHint(LegendreE2Hint, 2, a0, a1)
+	if err != nil {
+		// err is non-nil only for invalid number of inputs
+		panic(err)
+	}
+	s, r := res[0], res[1]
+
+	norm := api.Sub(api.Mul(a0, a0), api.Mul(a1, a1, ext.uSquare))
+
+	// s² == 1 if norm != 0, 0 otherwise; so that s ∈ {-1, 0, 1}
+	api.AssertIsEqual(api.Mul(s, s), api.Sub(1, api.IsZero(norm)))
+
+	// r² == norm⋅((1+s)/2 + u²⋅(1-s)/2)
+	var c0, c1 big.Int
+	c0.Add(big.NewInt(1), ext.uSquare)
+	c1.Sub(big.NewInt(1), ext.uSquare)
+	c0.Quo(&c0, big.NewInt(2)) // 1+u² = -4 is even
+	c1.Quo(&c1, big.NewInt(2)) // 1-u² = 6 is even
+	api.AssertIsEqual(api.Mul(r, r), api.Mul(norm, api.Add(&c0, api.Mul(s, &c1))))
+
+	return s
+}
+
+func SqrtE2Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+	var a, r bls12377.E2
+
+	a.A0.SetBigInt(inputs[0])
+	a.A1.SetBigInt(inputs[1])
+
+	if a.Legendre() == -1 {
+		return errors.New("no square root")
+	}
+	r.Sqrt(&a)
+
+	// return the lexicographically smallest root, as the compressed encoding of
+	// gnark-crypto does
+	if r.LexicographicallyLargest() {
+		r.Neg(&r)
+	}
+
+	r.A0.BigInt(res[0])
+	r.A1.BigInt(res[1])
+
+	return nil
+}
+
+// Sqrt sets e to a square root of a and returns e. If a is not a square in E2, the hint
+// fails and the circuit can't be satisfied.
+//
+// Only e² == a is constrained: the hint returns the lexicographically smallest of the
+// two roots, but a caller relying on the sign must constrain it.
+func (e *E2) Sqrt(api frontend.API, a E2) *E2 {
+
+	a0, a1 := a.Limbs()
+	res, err := api.NewHint(SqrtE2Hint, 2, a0, a1)
+	if err != nil {
+		// err is non-nil only for invalid number of inputs
+		panic(err)
+	}
+
+	r := NewE2(res[0], res[1])
+
+	// a == r²
+	var r2 E2
+	r2.Square(api, r)
+	r2.AssertIsEqual(api, a)
+
+	*e = r
 
 	return e
 }
 
+// isZero returns 1 if e == 0 and 0 otherwise
+func (e *E2) isZero(api frontend.API) frontend.Variable {
+	return api.And(api.IsZero(e.A0), api.IsZero(e.A1))
+}
+
 // Assign a value to self (witness assignment)
 func (e *E2) Assign(a *bls12377.E2) {
 	e.A0 = (fr.Element)(a.A0)
@@ -232,6 +337,27 @@ func (e *E2) AssertIsEqual(api frontend.API, other E2) {
 	api.AssertIsEqual(e.A1, other.A1)
 }
 
+// AssertIsEqualConstant constraint self to be equal to the constant c. The limbs of c
+// are folded in the constraints instead of being allocated as variables, and limbs of
+// self which are known at compile time are checked without adding a constraint.
+func (e *E2) AssertIsEqualConstant(api frontend.API, c bls12377.E2) {
+	var b0, b1 big.Int
+	c.A0.BigInt(&b0)
+	c.A1.BigInt(&b1)
+	assertIsEqualConstant(api, e.A0, &b0)
+	assertIsEqualConstant(api, e.A1, &b1)
+}
+
+func assertIsEqualConstant(api frontend.API, v frontend.Variable, c *big.Int) {
+	if cv, ok := api.Compiler().ConstantValue(v); ok {
+		if cv.Cmp(c) != 0 {
+			panic("e2 is not equal to the constant")
+		}
+		return
+	}
+	api.AssertIsEqual(v, c)
+}
+
 // Select sets e to r1 if b=1, r2 otherwise
 func (e *E2) Select(api frontend.API, b frontend.Variable, r1, r2 E2) *E2 {
 
@@ -240,3 +366,74 @@ func (e *E2) Select(api frontend.API, b frontend.Variable, r1, r2 E2) *E2 {
 
 	return e
 }
+
+// Ext2 holds the non-residue u² of a quadratic extension Fp(u) of the native field, on
+// which the multiplications of E2 elements depend. It allows the same E2 arithmetic to
+// serve another quadratic extension; the methods of E2 compute in the BLS12-377 Fp2, see
+// NewExt2BLS12377.
+type Ext2 struct {
+	uSquare             *big.Int
+	minusOnePlusUSquare *big.Int // -(1+u²)
+}
+
+// ext2 is the BLS12-377 Fp2 used by the methods of E2
+var ext2 = NewExt2BLS12377()
+
+// NewExt2 returns the Ext2 of the quadratic extension Fp(u) where u² = uSquare, which must
+// be a quadratic non-residue in the native field.
+func NewExt2(uSquare *big.Int) Ext2 {
+	x := Ext2{uSquare: new(big.Int).Set(uSquare), minusOnePlusUSquare: big.NewInt(-1)}
+	x.minusOnePlusUSquare.Sub(x.minusOnePlusUSquare, uSquare)
+	return x
+}
+
+// NewExt2BLS12377 returns the Ext2 of the BLS12-377 Fp2, where u² = -5.
+func NewExt2BLS12377() Ext2 {
+	return NewExt2(ext.uSquare)
+}
+
+// Mul returns e1⋅e2
+func (x Ext2) Mul(api frontend.API, e1, e2 E2) E2 {
+	var e E2
+
+	// 1C
+	l1 := api.Add(e1.A0, e1.A1)
+	l2 := api.Add(e2.A0, e2.A1)
+
+	u := api.Mul(l1, l2)
+
+	// 2C
+	ac := api.Mul(e1.A0, e2.A0)
+	bd := api.Mul(e1.A1, e2.A1)
+
+	l31 := api.Add(ac, bd)
+	e.A1 = api.Sub(u, l31)
+
+	l41 := api.Mul(bd, x.uSquare)
+	e.A0 = api.Add(ac, l41)
+
+	return e
+}
+
+// Square returns a²
+func (x Ext2) Square(api frontend.API, a E2) E2 {
+	var e E2
+
+	//algo 22 https://eprint.iacr.org/2010/354.pdf
+	c0 := api.Add(a.A0, a.A1)
+	c2 := api.Mul(a.A1, x.uSquare)
+	c2 = api.Add(c2, a.A0)
+
+	c0 = api.Mul(c0, c2) // (x1+x2)*(x1+(u**2)x2)
+	c2 = api.Mul(a.A0, a.A1)
+	e.A1 = api.Add(c2, c2)
+	// c0 - (1+u²)⋅x1⋅x2
+	e.A0 = api.Add(c0, api.Mul(c2, x.minusOnePlusUSquare))
+
+	return e
+}
+
+// MulByNonResidue returns a⋅u
+func (x Ext2) MulByNonResidue(api frontend.API, a E2) E2 {
+	return E2{A0: api.Mul(a.A1, x.uSquare), A1: a.A0}
+}
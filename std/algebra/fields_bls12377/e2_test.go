@@ -17,6 +17,7 @@ limitations under the License.
 package fields_bls12377
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -24,6 +25,8 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
 	"github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
 )
 
@@ -56,6 +59,41 @@ func TestAddFp2(t *testing.T) {
 
 }
 
+type e2Limbs struct {
+	A      E2
+	A0, A1 frontend.Variable
+}
+
+func (circuit *e2Limbs) Define(api frontend.API) error {
+	e := NewE2(circuit.A0, circuit.A1)
+	e.AssertIsEqual(api, circuit.A)
+
+	a0, a1 := e.Limbs()
+	api.AssertIsEqual(a0, circuit.A.A0)
+	api.AssertIsEqual(a1, circuit.A.A1)
+	return nil
+}
+
+func TestLimbsFp2(t *testing.T) {
+
+	// witness values
+	var a bls12377.E2
+	_, _ = a.SetRandom()
+
+	var witness e2Limbs
+	witness.A.Assign(&a)
+	witness.A0 = witness.A.A0
+	witness.A1 = witness.A.A1
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&e2Limbs{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	// swapped limbs
+	witness.A0, witness.A1 = witness.A1, witness.A0
+	assert.SolvingFailed(&e2Limbs{}, &witness, test.WithCurves(ecc.BW6_761))
+
+}
+
 type e2Sub struct {
 	A, B, C E2
 }
@@ -242,6 +280,176 @@ func TestInverseFp2(t *testing.T) {
 
 }
 
+type fp2Legendre struct {
+	A []E2
+	L []frontend.Variable `gnark:",public"`
+}
+
+func (circuit *fp2Legendre) Define(api frontend.API) error {
+	for i := range circuit.A {
+		api.AssertIsEqual(E2{}.Legendre(api, circuit.A[i]), circuit.L[i])
+	}
+	return nil
+}
+
+func TestLegendreFp2(t *testing.T) {
+
+	// a square, a non-residue and zero
+	var values [3]bls12377.E2
+	_, _ = values[0].SetRandom()
+	values[0].Square(&values[0])
+	for {
+		_, _ = values[1].SetRandom()
+		if values[1].Legendre() == -1 {
+			break
+		}
+	}
+
+	circuit := fp2Legendre{A: make([]E2, len(values)), L: make([]frontend.Variable, len(values))}
+	witness := fp2Legendre{A: make([]E2, len(values)), L: make([]frontend.Variable, len(values))}
+	for i := range values {
+		witness.A[i].Assign(&values[i])
+		witness.L[i] = values[i].Legendre()
+	}
+	if witness.L[0] != 1 || witness.L[1] != -1 || witness.L[2] != 0 {
+		t.Fatal("unexpected native Legendre symbols")
+	}
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// swap the symbols of the square and the non-residue
+	witness.L[0], witness.L[1] = witness.L[1], witness.L[0]
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+type fp2AssertIsEqualConstant struct {
+	A E2
+	c bls12377.E2
+}
+
+func (circuit *fp2AssertIsEqualConstant) Define(api frontend.API) error {
+	var square E2
+	square.Square(api, circuit.A)
+	square.AssertIsEqualConstant(api, circuit.c)
+	return nil
+}
+
+// same statement, the expected value is a variable
+type fp2AssertIsEqualVariable struct {
+	A, C E2
+}
+
+func (circuit *fp2AssertIsEqualVariable) Define(api frontend.API) error {
+	var square E2
+	square.Square(api, circuit.A)
+	square.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+// the compared value is known at compile time
+type fp2AssertIsEqualFolded struct {
+	A E2
+	c bls12377.E2
+}
+
+func (circuit *fp2AssertIsEqualFolded) Define(api frontend.API) error {
+	var one E2
+	one.SetOne()
+	one.AssertIsEqualConstant(api, circuit.c)
+	api.AssertIsEqual(circuit.A.A0, circuit.A.A1)
+	return nil
+}
+
+func TestAssertIsEqualConstantFp2(t *testing.T) {
+
+	var a, c bls12377.E2
+	_, _ = a.SetRandom()
+	c.Square(&a)
+
+	circuit := fp2AssertIsEqualConstant{c: c}
+	var witness fp2AssertIsEqualConstant
+	witness.A.Assign(&a)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	_, _ = a.A0.SetRandom()
+	witness.A.Assign(&a)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// the constant doesn't need wires, and costs no more constraints than a variable
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccsConstant, err := frontend.Compile(ecc.BW6_761.ScalarField(), newBuilder, &circuit)
+		assert.NoError(err)
+		ccsVariable, err := frontend.Compile(ecc.BW6_761.ScalarField(), newBuilder, &fp2AssertIsEqualVariable{})
+		assert.NoError(err)
+		assert.LessOrEqual(ccsConstant.GetNbConstraints(), ccsVariable.GetNbConstraints())
+		assert.Equal(ccsVariable.GetNbSecretVariables()-2, ccsConstant.GetNbSecretVariables())
+
+		// comparing two constants is checked at compile time
+		var one bls12377.E2
+		one.SetOne()
+		ccsFolded, err := frontend.Compile(ecc.BW6_761.ScalarField(), newBuilder, &fp2AssertIsEqualFolded{c: one})
+		assert.NoError(err)
+		assert.Equal(1, ccsFolded.GetNbConstraints())
+	}
+}
+
+type fp2NegConstant struct {
+	A E2 `gnark:",public"`
+	c bls12377.E2
+}
+
+func (circuit *fp2NegConstant) Define(api frontend.API) error {
+	var c, neg E2
+	c.Assign(&circuit.c)
+	neg.Neg(api, c)
+	neg.AssertIsEqual(api, circuit.A)
+	return nil
+}
+
+// same statement, the negated value is a variable
+type fp2NegVariable struct {
+	A E2 `gnark:",public"`
+	C E2
+}
+
+func (circuit *fp2NegVariable) Define(api frontend.API) error {
+	var neg E2
+	neg.Neg(api, circuit.C)
+	neg.AssertIsEqual(api, circuit.A)
+	return nil
+}
+
+func TestNegConstantFp2(t *testing.T) {
+
+	var a, c bls12377.E2
+	_, _ = c.SetRandom()
+	c.A1.SetZero()
+	a.Neg(&c)
+
+	circuit := fp2NegConstant{c: c}
+	var witness fp2NegConstant
+	witness.A.Assign(&a)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	witness.A.Assign(&c)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// negating the constant costs no constraint
+	for _, newBuilder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccsConstant, err := frontend.Compile(ecc.BW6_761.ScalarField(), newBuilder, &circuit)
+		assert.NoError(err)
+		ccsVariable, err := frontend.Compile(ecc.BW6_761.ScalarField(), newBuilder, &fp2NegVariable{})
+		assert.NoError(err)
+		assert.LessOrEqual(ccsConstant.GetNbConstraints(), ccsVariable.GetNbConstraints())
+		assert.Equal(2, ccsConstant.GetNbConstraints())
+	}
+}
+
 func TestMulByNonResidueFp2(t *testing.T) {
 	// TODO fixme
 	t.Skip("missing e2.MulByNonSquare")
@@ -273,3 +481,133 @@ func TestMulByNonResidueFp2(t *testing.T) {
 	// witness.C.A1 = (c.A1)
 
 }
+
+type fp2Sqrt struct {
+	A E2
+}
+
+func (circuit *fp2Sqrt) Define(api frontend.API) error {
+	var r, r2 E2
+	r.Sqrt(api, circuit.A)
+	r2.Square(api, r)
+	r2.AssertIsEqual(api, circuit.A)
+	return nil
+}
+
+func TestSqrtFp2(t *testing.T) {
+
+	var circuit, witness fp2Sqrt
+
+	var a bls12377.E2
+	_, _ = a.SetRandom()
+	a.Square(&a)
+	witness.A.Assign(&a)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// a non-residue has no square root
+	for {
+		_, _ = a.SetRandom()
+		if a.Legendre() == -1 {
+			break
+		}
+	}
+	witness.A.Assign(&a)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+type ext2Preset struct {
+	A, B          E2
+	Mul, Sq, MulU E2
+}
+
+func (circuit *ext2Preset) Define(api frontend.API) error {
+	x := NewExt2BLS12377()
+	var mul, sq, mulU E2
+	mul.Mul(api, circuit.A, circuit.B)
+	sq.Square(api, circuit.A)
+	mulU.MulByNonResidue(api, circuit.A)
+	for _, c := range [][3]E2{
+		{mul, x.Mul(api, circuit.A, circuit.B), circuit.Mul},
+		{sq, x.Square(api, circuit.A), circuit.Sq},
+		{mulU, x.MulByNonResidue(api, circuit.A), circuit.MulU},
+	} {
+		c[0].AssertIsEqual(api, c[2])
+		c[1].AssertIsEqual(api, c[2])
+	}
+	return nil
+}
+
+func TestExt2BLS12377(t *testing.T) {
+	var a, b, mul, sq, mulU bls12377.E2
+	_, _ = a.SetRandom()
+	_, _ = b.SetRandom()
+	mul.Mul(&a, &b)
+	sq.Square(&a)
+	mulU.MulByNonResidue(&a)
+
+	var witness ext2Preset
+	witness.A.Assign(&a)
+	witness.B.Assign(&b)
+	witness.Mul.Assign(&mul)
+	witness.Sq.Assign(&sq)
+	witness.MulU.Assign(&mulU)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&ext2Preset{}, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+type ext2Custom struct {
+	A, B          E2
+	Mul, Sq, MulU E2
+}
+
+func (circuit *ext2Custom) Define(api frontend.API) error {
+	x := NewExt2(big.NewInt(7))
+	mul := x.Mul(api, circuit.A, circuit.B)
+	mul.AssertIsEqual(api, circuit.Mul)
+	sq := x.Square(api, circuit.A)
+	sq.AssertIsEqual(api, circuit.Sq)
+	mulU := x.MulByNonResidue(api, circuit.A)
+	mulU.AssertIsEqual(api, circuit.MulU)
+	return nil
+}
+
+func TestExt2Custom(t *testing.T) {
+	// Fp(u) with u² = 7
+	var a0, a1, b0, b1, seven, t0, t1 fp.Element
+	_, _ = a0.SetRandom()
+	_, _ = a1.SetRandom()
+	_, _ = b0.SetRandom()
+	_, _ = b1.SetRandom()
+	seven.SetUint64(7)
+
+	var witness ext2Custom
+	witness.A = NewE2(a0.String(), a1.String())
+	witness.B = NewE2(b0.String(), b1.String())
+
+	// (a0 + a1⋅u)(b0 + b1⋅u) = a0⋅b0 + 7⋅a1⋅b1 + (a0⋅b1 + a1⋅b0)⋅u
+	t0.Mul(&a1, &b1).Mul(&t0, &seven)
+	t1.Mul(&a0, &b0)
+	t0.Add(&t0, &t1)
+	t1.Mul(&a0, &b1)
+	var t2 fp.Element
+	t2.Mul(&a1, &b0)
+	t1.Add(&t1, &t2)
+	witness.Mul = NewE2(t0.String(), t1.String())
+
+	// (a0 + a1⋅u)² = a0² + 7⋅a1² + 2⋅a0⋅a1⋅u
+	t0.Square(&a1).Mul(&t0, &seven)
+	t1.Square(&a0)
+	t0.Add(&t0, &t1)
+	t1.Mul(&a0, &a1).Double(&t1)
+	witness.Sq = NewE2(t0.String(), t1.String())
+
+	// (a0 + a1⋅u)⋅u = 7⋅a1 + a0⋅u
+	t0.Mul(&a1, &seven)
+	witness.MulU = NewE2(t0.String(), a0.String())
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&ext2Custom{}, &witness, test.WithCurves(ecc.BW6_761))
+}
@@ -20,7 +20,6 @@ import (
 	"math/big"
 
 	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
-	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -66,11 +65,18 @@ func (e *E6) Add(api frontend.API, e1, e2 E6) *E6 {
 
 // NewFp6Zero creates a new
 func NewFp6Zero(api frontend.API) *E6 {
-	return &E6{
-		B0: E2{0, 0},
-		B1: E2{0, 0},
-		B2: E2{0, 0},
-	}
+	e := NewE6Zero()
+	return &e
+}
+
+// NewE6Zero returns the E6 element equal to 0
+func NewE6Zero() E6 {
+	return E6{B0: NewE2Zero(), B1: NewE2Zero(), B2: NewE2Zero()}
+}
+
+// NewE6One returns the E6 element equal to 1
+func NewE6One() E6 {
+	return E6{B0: NewE2One(), B1: NewE2Zero(), B2: NewE2Zero()}
 }
 
 // Sub creates a fp6elmt from fp elmts
@@ -91,6 +97,14 @@ func (e *E6) Neg(api frontend.API, e1 E6) *E6 {
 	return e
 }
 
+// Select sets e to r1 if b=1, r2 otherwise
+func (e *E6) Select(api frontend.API, b frontend.Variable, r1, r2 E6) *E6 {
+	e.B0.Select(api, b, r1.B0, r2.B0)
+	e.B1.Select(api, b, r1.B1, r2.B1)
+	e.B2.Select(api, b, r1.B2, r2.B2)
+	return e
+}
+
 // Mul creates a fp6elmt from fp elmts
 // icube is the imaginary elmt to the cube
 func (e *E6) Mul(api frontend.API, e1, e2 E6) *E6 {
@@ -168,7 +182,7 @@ func (e *E6) Square(api frontend.API, x E6) *E6 {
 	return e
 }
 
-var DivE6Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+func DivE6Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	var a, b, c bls12377.E6
 
 	a.B0.A0.SetBigInt(inputs[0])
@@ -197,10 +211,6 @@ var DivE6Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	return nil
 }
 
-func init() {
-	hint.Register(DivE6Hint)
-}
-
 // DivUnchecked e6 elmts
 func (e *E6) DivUnchecked(api frontend.API, e1, e2 E6) *E6 {
 
@@ -210,9 +220,8 @@ func (e *E6) DivUnchecked(api frontend.API, e1, e2 E6) *E6 {
 		panic(err)
 	}
 
-	var e3, one E6
+	var e3 E6
 	e3.assign(res[:6])
-	one.SetOne()
 
 	// e1 == e3 * e2
 	e3.Mul(api, e3, e2)
@@ -223,7 +232,16 @@ func (e *E6) DivUnchecked(api frontend.API, e1, e2 E6) *E6 {
 	return e
 }
 
-var InverseE6Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+// Div e6 elmts
+//
+// As opposed to DivUnchecked, it constrains e2 to be non-zero such that 0/0
+// can't be satisfied with an arbitrary quotient.
+func (e *E6) Div(api frontend.API, e1, e2 E6) *E6 {
+	api.AssertIsEqual(e2.isZero(api), 0)
+	return e.DivUnchecked(api, e1, e2)
+}
+
+func InverseE6Hint(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	var a, c bls12377.E6
 
 	a.B0.A0.SetBigInt(inputs[0])
@@ -245,10 +263,6 @@ var InverseE6Hint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
 	return nil
 }
 
-func init() {
-	hint.Register(InverseE6Hint)
-}
-
 // Inverse e6 elmts
 func (e *E6) Inverse(api frontend.API, e1 E6) *E6 {
 
@@ -258,9 +272,9 @@ func (e *E6) Inverse(api frontend.API, e1 E6) *E6 {
 		panic(err)
 	}
 
-	var e3, one E6
+	var e3 E6
 	e3.assign(res[:6])
-	one.SetOne()
+	one := NewE6One()
 
 	// 1 == e3 * e1
 	e3.Mul(api, e3, e1)
@@ -271,6 +285,11 @@ func (e *E6) Inverse(api frontend.API, e1 E6) *E6 {
 	return e
 }
 
+// isZero returns 1 if e == 0 and 0 otherwise
+func (e *E6) isZero(api frontend.API) frontend.Variable {
+	return api.And(api.And(e.B0.isZero(api), e.B1.isZero(api)), e.B2.isZero(api))
+}
+
 // Assign a value to self (witness assignment)
 func (e *E6) Assign(a *bls12377.E6) {
 	e.B0.Assign(&a.B0)
@@ -90,6 +90,74 @@ func TestSubFp6(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type fp6Neg struct {
+	A E6
+	C E6 `gnark:",public"`
+}
+
+func (circuit *fp6Neg) Define(api frontend.API) error {
+	expected := E6{}
+	expected.Neg(api, circuit.A)
+	expected.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestNegFp6(t *testing.T) {
+
+	var circuit, witness fp6Neg
+
+	// witness values
+	var a, c bls12377.E6
+	_, _ = a.SetRandom()
+	c.Neg(&a)
+
+	witness.A.Assign(&a)
+	witness.C.Assign(&c)
+
+	// cs values
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+type fp6Select struct {
+	B    frontend.Variable
+	A, C E6
+	R    E6 `gnark:",public"`
+}
+
+func (circuit *fp6Select) Define(api frontend.API) error {
+	expected := E6{}
+	expected.Select(api, circuit.B, circuit.A, circuit.C)
+	expected.AssertIsEqual(api, circuit.R)
+	return nil
+}
+
+func TestSelectFp6(t *testing.T) {
+
+	var circuit, witness fp6Select
+
+	// witness values
+	var a, c bls12377.E6
+	_, _ = a.SetRandom()
+	_, _ = c.SetRandom()
+
+	witness.A.Assign(&a)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+
+	// b = 1 selects A
+	witness.B = 1
+	witness.R.Assign(&a)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// b = 0 selects C
+	witness.B = 0
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+	witness.R.Assign(&c)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 type fp6Mul struct {
 	A, B E6
 	C    E6 `gnark:",public"`
@@ -215,6 +283,43 @@ func TestDivFp6(t *testing.T) {
 	assert.SolvingSucceeded(&e6Div{}, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type e6DivChecked struct {
+	A, B, C E6
+}
+
+func (circuit *e6DivChecked) Define(api frontend.API) error {
+	var expected E6
+
+	expected.Div(api, circuit.A, circuit.B)
+	expected.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestDivCheckedFp6(t *testing.T) {
+
+	// witness values
+	var a, b, c bls12377.E6
+	_, _ = a.SetRandom()
+	_, _ = b.SetRandom()
+	c.Inverse(&b).Mul(&c, &a)
+
+	var witness e6DivChecked
+	witness.A.Assign(&a)
+	witness.B.Assign(&b)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&e6DivChecked{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	// 0/0 is satisfied by any quotient with DivUnchecked, but not with Div
+	var zero bls12377.E6
+	witness.A.Assign(&zero)
+	witness.B.Assign(&zero)
+	witness.C.Assign(&zero)
+	assert.SolvingSucceeded(&e6Div{}, &witness, test.WithCurves(ecc.BW6_761))
+	assert.SolvingFailed(&e6DivChecked{}, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 func TestMulByFp2Fp6(t *testing.T) {
 	// TODO fixme
 	t.Skip("missing e6.MulByE2")
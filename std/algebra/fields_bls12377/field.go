@@ -0,0 +1,162 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields_bls12377
+
+import "github.com/consensys/gnark/frontend"
+
+// Field is the arithmetic of a field whose elements are of type T, in a circuit.
+//
+// It allows to write gadgets once for the native field and its extensions; see
+// NewBaseField, NewE2Field and NewE12Field.
+type Field[T any] interface {
+	Add(a, b T) T
+	Sub(a, b T) T
+	Mul(a, b T) T
+	Square(a T) T
+	// Select returns a if b=1, c otherwise. b must be boolean constrained.
+	Select(b frontend.Variable, a, c T) T
+	AssertIsEqual(a, b T)
+}
+
+// BaseField implements Field over the native field of the circuit, which is
+// the base field 𝔽p of BLS12-377 when the circuit is defined over BW6-761.
+type BaseField struct {
+	api frontend.API
+}
+
+// NewBaseField returns the native field arithmetic defined by api.
+func NewBaseField(api frontend.API) *BaseField {
+	return &BaseField{api: api}
+}
+
+func (f *BaseField) Add(a, b frontend.Variable) frontend.Variable {
+	return f.api.Add(a, b)
+}
+
+func (f *BaseField) Sub(a, b frontend.Variable) frontend.Variable {
+	return f.api.Sub(a, b)
+}
+
+func (f *BaseField) Mul(a, b frontend.Variable) frontend.Variable {
+	return f.api.Mul(a, b)
+}
+
+func (f *BaseField) Square(a frontend.Variable) frontend.Variable {
+	return f.api.Mul(a, a)
+}
+
+func (f *BaseField) Select(b frontend.Variable, a, c frontend.Variable) frontend.Variable {
+	return f.api.Select(b, a, c)
+}
+
+func (f *BaseField) AssertIsEqual(a, b frontend.Variable) {
+	f.api.AssertIsEqual(a, b)
+}
+
+// E2Field implements Field over 𝔽p²
+type E2Field struct {
+	api frontend.API
+}
+
+// NewE2Field returns the 𝔽p² arithmetic over the native field defined by api.
+func NewE2Field(api frontend.API) *E2Field {
+	return &E2Field{api: api}
+}
+
+func (f *E2Field) Add(a, b E2) E2 {
+	var r E2
+	r.Add(f.api, a, b)
+	return r
+}
+
+func (f *E2Field) Sub(a, b E2) E2 {
+	var r E2
+	r.Sub(f.api, a, b)
+	return r
+}
+
+func (f *E2Field) Mul(a, b E2) E2 {
+	var r E2
+	r.Mul(f.api, a, b)
+	return r
+}
+
+func (f *E2Field) Square(a E2) E2 {
+	var r E2
+	r.Square(f.api, a)
+	return r
+}
+
+func (f *E2Field) Select(b frontend.Variable, a, c E2) E2 {
+	var r E2
+	r.Select(f.api, b, a, c)
+	return r
+}
+
+func (f *E2Field) AssertIsEqual(a, b E2) {
+	a.AssertIsEqual(f.api, b)
+}
+
+// E12Field implements Field over 𝔽p¹²
+type E12Field struct {
+	api frontend.API
+}
+
+// NewE12Field returns the 𝔽p¹² arithmetic over the native field defined by api.
+func NewE12Field(api frontend.API) *E12Field {
+	return &E12Field{api: api}
+}
+
+func (f *E12Field) Add(a, b E12) E12 {
+	var r E12
+	r.Add(f.api, a, b)
+	return r
+}
+
+func (f *E12Field) Sub(a, b E12) E12 {
+	var r E12
+	r.Sub(f.api, a, b)
+	return r
+}
+
+func (f *E12Field) Mul(a, b E12) E12 {
+	var r E12
+	r.Mul(f.api, a, b)
+	return r
+}
+
+func (f *E12Field) Square(a E12) E12 {
+	var r E12
+	r.Square(f.api, a)
+	return r
+}
+
+func (f *E12Field) Select(b frontend.Variable, a, c E12) E12 {
+	var r E12
+	r.Select(f.api, b, a, c)
+	return r
+}
+
+func (f *E12Field) AssertIsEqual(a, b E12) {
+	a.AssertIsEqual(f.api, b)
+}
+
+var (
+	_ Field[frontend.Variable] = (*BaseField)(nil)
+	_ Field[E2]                = (*E2Field)(nil)
+	_ Field[E12]               = (*E12Field)(nil)
+)
@@ -0,0 +1,86 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields_bls12377
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// sbox computes x⁵ + y, as in a Poseidon round, over any field
+func sbox[T any](f Field[T], x, y T) T {
+	x2 := f.Square(x)
+	x4 := f.Square(x2)
+	return f.Add(f.Mul(x4, x), y)
+}
+
+type fpSbox struct {
+	X, Y, C frontend.Variable
+}
+
+func (circuit *fpSbox) Define(api frontend.API) error {
+	f := NewBaseField(api)
+	f.AssertIsEqual(sbox[frontend.Variable](f, circuit.X, circuit.Y), circuit.C)
+	return nil
+}
+
+type fp2Sbox struct {
+	X, Y, C E2
+}
+
+func (circuit *fp2Sbox) Define(api frontend.API) error {
+	f := NewE2Field(api)
+	f.AssertIsEqual(sbox[E2](f, circuit.X, circuit.Y), circuit.C)
+	return nil
+}
+
+func TestGenericGadget(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// base field
+	{
+		var x, y, c fp.Element
+		_, _ = x.SetRandom()
+		_, _ = y.SetRandom()
+		c.Square(&x).Square(&c).Mul(&c, &x).Add(&c, &y)
+
+		witness := fpSbox{X: x.String(), Y: y.String(), C: c.String()}
+		assert.SolvingSucceeded(&fpSbox{}, &witness, test.WithCurves(ecc.BW6_761))
+	}
+
+	// 𝔽p²
+	{
+		var x, y, c bls12377.E2
+		_, _ = x.SetRandom()
+		_, _ = y.SetRandom()
+		c.Square(&x).Square(&c).Mul(&c, &x).Add(&c, &y)
+
+		var witness fp2Sbox
+		witness.X.Assign(&x)
+		witness.Y.Assign(&y)
+		witness.C.Assign(&c)
+		assert.SolvingSucceeded(&fp2Sbox{}, &witness, test.WithCurves(ecc.BW6_761))
+
+		witness.C.Assign(&y)
+		assert.SolvingFailed(&fp2Sbox{}, &witness, test.WithCurves(ecc.BW6_761))
+	}
+}
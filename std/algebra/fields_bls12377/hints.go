@@ -0,0 +1,43 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields_bls12377
+
+import "github.com/consensys/gnark/backend/hint"
+
+func init() {
+	hint.Register(GetHints()...)
+}
+
+// GetHints returns all hint functions used in the package, to be given to the solver with
+// backend.WithHints when solving a deserialized constraint system without importing this
+// package.
+//
+// The hints are named functions, so that their IDs, derived from their names (e.g.
+// "github.com/consensys/gnark/std/algebra/fields_bls12377.InverseE2Hint"), are stable
+// across versions of the package.
+func GetHints() []hint.Function {
+	return []hint.Function{
+		DivE2Hint,
+		DivE6Hint,
+		DivE12Hint,
+		InverseE2Hint,
+		InverseE6Hint,
+		InverseE12Hint,
+		LegendreE2Hint,
+		SqrtE2Hint,
+	}
+}
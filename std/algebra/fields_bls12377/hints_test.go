@@ -0,0 +1,74 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields_bls12377
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/hint"
+	cs "github.com/consensys/gnark/constraint/bw6-761"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+func TestGetHints(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &fp2Inverse{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := ccs.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var reconstructed cs.R1CS
+	if _, err := reconstructed.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// the hint is referenced by its stable name
+	const name = "github.com/consensys/gnark/std/algebra/fields_bls12377.InverseE2Hint"
+	if got := reconstructed.MHintsDependencies[hint.UUID(InverseE2Hint)]; got != name {
+		t.Fatalf("expected hint %s, got %q", name, got)
+	}
+
+	var a, c bls12377.E2
+	_, _ = a.SetRandom()
+	c.Inverse(&a)
+	var assignment fp2Inverse
+	assignment.A.Assign(&a)
+	assignment.C.Assign(&c)
+	w, err := frontend.NewWitness(&assignment, ecc.BW6_761.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// solve with the hints of the package only, not the global registry
+	onlyGetHints := func(opt *backend.ProverConfig) error {
+		opt.HintFunctions = make(map[hint.ID]hint.Function)
+		return nil
+	}
+	if err := reconstructed.IsSolved(w, onlyGetHints); err == nil {
+		t.Fatal("expected a missing hint error")
+	}
+	if err := reconstructed.IsSolved(w, onlyGetHints, backend.WithHints(GetHints()...)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields_bls12377
+
+import (
+	"errors"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// MarshalBinary encodes the value of an assigned E2 (see Assign) as A1 | A0, in
+// big-endian, following gnark-crypto's layout for E2 coordinates.
+func (e *E2) MarshalBinary() ([]byte, error) {
+	a, err := e.native()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 2*fp.Bytes)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(buf[:fp.Bytes]), a.A1)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(buf[fp.Bytes:]), a.A0)
+	return buf, nil
+}
+
+// UnmarshalBinary assigns e from data, as encoded by MarshalBinary.
+func (e *E2) UnmarshalBinary(data []byte) error {
+	if len(data) != 2*fp.Bytes {
+		return errors.New("invalid buffer size")
+	}
+	var a bls12377.E2
+	if err := a.A1.SetBytesCanonical(data[:fp.Bytes]); err != nil {
+		return err
+	}
+	if err := a.A0.SetBytesCanonical(data[fp.Bytes:]); err != nil {
+		return err
+	}
+	e.Assign(&a)
+	return nil
+}
+
+// MarshalBinary encodes the value of an assigned E12 (see Assign) following
+// gnark-crypto's E12.Bytes layout.
+func (e *E12) MarshalBinary() ([]byte, error) {
+	var a bls12377.E12
+	for _, v := range []struct {
+		src *E2
+		dst *bls12377.E2
+	}{
+		{&e.C0.B0, &a.C0.B0}, {&e.C0.B1, &a.C0.B1}, {&e.C0.B2, &a.C0.B2},
+		{&e.C1.B0, &a.C1.B0}, {&e.C1.B1, &a.C1.B1}, {&e.C1.B2, &a.C1.B2},
+	} {
+		var err error
+		if *v.dst, err = v.src.native(); err != nil {
+			return nil, err
+		}
+	}
+	buf := a.Bytes()
+	return buf[:], nil
+}
+
+// UnmarshalBinary assigns e from data, as encoded by MarshalBinary.
+func (e *E12) UnmarshalBinary(data []byte) error {
+	var a bls12377.E12
+	if err := a.SetBytes(data); err != nil {
+		return err
+	}
+	e.Assign(&a)
+	return nil
+}
+
+// native returns the value of an assigned E2
+func (e *E2) native() (bls12377.E2, error) {
+	var a bls12377.E2
+	if err := setLimb(&a.A0, e.A0); err != nil {
+		return a, err
+	}
+	if err := setLimb(&a.A1, e.A1); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+func setLimb(dst *fp.Element, v frontend.Variable) error {
+	if v == nil {
+		return errors.New("unassigned limb")
+	}
+	b := utils.FromInterface(v)
+	dst.SetBigInt(&b)
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields_bls12377
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+)
+
+func TestMarshalBinaryE2(t *testing.T) {
+	var a bls12377.E2
+	a.SetRandom()
+
+	var e, restored E2
+	e.Assign(&a)
+	data, err := e.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, restored) {
+		t.Fatal("round trip failed")
+	}
+
+	if _, err := (&E2{}).MarshalBinary(); err == nil {
+		t.Fatal("expected an error for an unassigned element")
+	}
+	if err := restored.UnmarshalBinary(data[1:]); err == nil {
+		t.Fatal("expected an error for a short buffer")
+	}
+}
+
+func TestMarshalBinaryE12(t *testing.T) {
+	var a bls12377.E12
+	a.SetRandom()
+
+	var e, restored E12
+	e.Assign(&a)
+	data, err := e.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := a.Bytes()
+	if !bytes.Equal(data, expected[:]) {
+		t.Fatal("encoding doesn't match gnark-crypto's")
+	}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, restored) {
+		t.Fatal("round trip failed")
+	}
+
+	// small integer assignments are handled as well
+	var one E12
+	one.SetOne()
+	data, err = one.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetOne()
+	expected = a.Bytes()
+	if !bytes.Equal(data, expected[:]) {
+		t.Fatal("encoding of one doesn't match gnark-crypto's")
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sw_bls12377
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// G2BaseTable holds the multiples of a G2 point needed by G2BaseTable.ScalarMul,
+// see PrecomputeG2Base.
+type G2BaseTable struct {
+	base       G2Affine
+	windowBits int
+	// tables[w][c] = [(2c+1)⋅2^(w⋅windowBits)] base
+	tables [][]G2Affine
+}
+
+// PrecomputeG2Base returns the table of the multiples of base used by
+// G2BaseTable.ScalarMul, for scalars cut in windows of windowBits bits: for each
+// window w, the odd multiples [(2c+1)⋅2^(w⋅windowBits)] base for c < 2^(windowBits-1).
+// A scalar multiplication with the table then costs, per window, one lookup and one
+// addition, and no doubling; building the table costs windowBits doublings and
+// 2^(windowBits-1)-1 additions per window, and is amortized over the scalar
+// multiplications by the same base in the circuit. In R1CS, windowBits = 2 is the
+// cheapest.
+func PrecomputeG2Base(api frontend.API, base G2Affine, windowBits int) G2BaseTable {
+	if windowBits < 1 {
+		panic("window size must be positive")
+	}
+	nbWindows, _ := fixedBaseWindows(api, windowBits)
+
+	t := G2BaseTable{base: base, windowBits: windowBits, tables: make([][]G2Affine, nbWindows)}
+	shifted := base
+	for w := range t.tables {
+		t.tables[w] = make([]G2Affine, 1<<(windowBits-1))
+		t.tables[w][0] = shifted
+		if w == nbWindows-1 && windowBits == 1 {
+			break
+		}
+		var double G2Affine
+		double.Double(api, shifted)
+		for c := 1; c < len(t.tables[w]); c++ {
+			t.tables[w][c] = t.tables[w][c-1]
+			t.tables[w][c].AddAssign(api, double)
+		}
+		if w == nbWindows-1 {
+			break
+		}
+		shifted = double
+		for j := 1; j < windowBits; j++ {
+			shifted.Double(api, shifted)
+		}
+	}
+
+	return t
+}
+
+// fixedBaseWindows returns the number of windows of windowBits bits and the number of
+// bits of the scalars in G2BaseTable.ScalarMul.
+func fixedBaseWindows(api frontend.API, windowBits int) (nbWindows, nbBits int) {
+	nbBits = getInnerCurveConfig(api.Compiler().Field()).fr.BitLen()
+	return (nbBits + windowBits - 1) / windowBits, nbBits
+}
+
+// ScalarMul returns [s] base, where base is the point the table was built from. s
+// must be in [0, r) where r is the order of G2.
+//
+// The scalar is recoded with digits ±1: for t = s or s+1, whichever is odd,
+// t = ∑ (2uᵢ-1) 2ⁱ where u = (t-1)/2 + 2ⁿ⁻¹ has the bits of s shifted by one and
+// its top bit set. Grouped per window, the digits make an odd number, so that the
+// lookup is in the table of odd multiples up to the sign, and [s]base is [t]base,
+// minus base if s is even. The accumulator of the windows below w is less than the
+// multiple added for window w, which avoids the exceptional cases of the incomplete
+// addition formulas but for a handful of scalars, among which 0, 1 and r-1, for which
+// the circuit is not satisfiable.
+func (t G2BaseTable) ScalarMul(api frontend.API, s frontend.Variable) G2Affine {
+	nbWindows, nbBits := fixedBaseWindows(api, t.windowBits)
+	sBits := api.ToBinary(s, nbBits)
+	uBits := make([]frontend.Variable, nbWindows*t.windowBits)
+	for i := range uBits {
+		uBits[i] = 0
+	}
+	copy(uBits, sBits[1:])
+	uBits[len(uBits)-1] = 1
+
+	var acc G2Affine
+	for w := range t.tables {
+		window := uBits[w*t.windowBits : (w+1)*t.windowBits]
+		sign := window[t.windowBits-1]
+		// the digit is ±(2c+1) where c is given by the low bits of the window if
+		// the sign is positive, and their complement otherwise
+		index := make([]frontend.Variable, t.windowBits-1)
+		for j := range index {
+			index[j] = api.Sub(1, api.Xor(window[j], sign))
+		}
+		e := lookupG2(api, t.tables[w], index)
+		var negY G2Affine
+		negY.Neg(api, e)
+		e.Y.Select(api, sign, e.Y, negY.Y)
+		if w == 0 {
+			acc = e
+		} else {
+			acc.AddAssign(api, e)
+		}
+	}
+
+	var negBase, even G2Affine
+	negBase.Neg(api, t.base)
+	even = acc
+	even.AddAssign(api, negBase)
+	acc.Select(api, sBits[0], acc, even)
+
+	return acc
+}
+
+// lookupG2 returns table[d] where d is the integer of little-endian bits; table must
+// have at least 2^len(bits) entries.
+func lookupG2(api frontend.API, table []G2Affine, bits []frontend.Variable) G2Affine {
+	if len(bits) == 0 {
+		return table[0]
+	}
+	half := 1 << (len(bits) - 1)
+	lo := lookupG2(api, table[:half], bits[:len(bits)-1])
+	hi := lookupG2(api, table[half:2*half], bits[:len(bits)-1])
+	var res G2Affine
+	res.Select(api, bits[len(bits)-1], hi, lo)
+	return res
+}
@@ -419,6 +419,48 @@ func (p *G1Affine) AssertIsEqual(api frontend.API, other G1Affine) {
 	api.AssertIsEqual(p.Y, other.Y)
 }
 
+// AssertIsOnCurve constrains p to be a point of the BLS12-377 curve, that is,
+// Y² == X³ + 1. The infinity point, represented as (0,0), doesn't pass.
+//
+// Unlike BN254, the BLS12-377 curve doesn't have a prime order: points given as
+// witnesses must also be checked to be in the G1 subgroup before being used where the
+// subgroup is assumed, as in the pairing.
+func (p *G1Affine) AssertIsOnCurve(api frontend.API) {
+	left := api.Mul(p.Y, p.Y)
+	right := api.Add(api.Mul(p.X, p.X, p.X), 1)
+	api.AssertIsEqual(left, right)
+}
+
+// AssertPointsEqual constrains a[i] to be equal to b[i] for all i. It panics if a and b
+// don't have the same length.
+func AssertPointsEqual(api frontend.API, a, b []G1Affine) {
+	if len(a) != len(b) {
+		panic("number of points don't match")
+	}
+	for i := range a {
+		a[i].AssertIsEqual(api, b[i])
+	}
+}
+
+// Equal returns 1 if p == q and 0 otherwise
+func (p *G1Affine) Equal(api frontend.API, q G1Affine) frontend.Variable {
+	return allZero(api, api.Sub(p.X, q.X), api.Sub(p.Y, q.Y))
+}
+
+// EqualNeg returns 1 if p == -q and 0 otherwise
+func (p *G1Affine) EqualNeg(api frontend.API, q G1Affine) frontend.Variable {
+	return allZero(api, api.Sub(p.X, q.X), api.Add(p.Y, q.Y))
+}
+
+// allZero returns 1 if all the limbs are 0 and 0 otherwise
+func allZero(api frontend.API, limbs ...frontend.Variable) frontend.Variable {
+	res := api.IsZero(limbs[0])
+	for _, l := range limbs[1:] {
+		res = api.And(res, api.IsZero(l))
+	}
+	return res
+}
+
 // DoubleAndAdd computes 2*p1+p in affine coords
 func (p *G1Affine) DoubleAndAdd(api frontend.API, p1, p2 *G1Affine) *G1Affine {
 
@@ -251,6 +251,81 @@ func TestNegG1(t *testing.T) {
 
 }
 
+// -------------------------------------------------------------------------------------------------
+// Equality predicates
+
+type g1Equal struct {
+	A, B           G1Affine
+	IsEqual, IsNeg frontend.Variable `gnark:",public"`
+}
+
+func (circuit *g1Equal) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.A.Equal(api, circuit.B), circuit.IsEqual)
+	api.AssertIsEqual(circuit.A.EqualNeg(api, circuit.B), circuit.IsNeg)
+	return nil
+}
+
+func TestEqualG1(t *testing.T) {
+
+	// sample 2 random points
+	_a, _b := randomPointG1(), randomPointG1()
+	var a, b, negA bls12377.G1Affine
+	a.FromJacobian(&_a)
+	b.FromJacobian(&_b)
+	negA.Neg(&a)
+
+	assert := test.NewAssert(t)
+	for _, tc := range []struct {
+		name           string
+		b              *bls12377.G1Affine
+		isEqual, isNeg int
+	}{
+		{"equal", &a, 1, 0},
+		{"negation", &negA, 0, 1},
+		{"distinct", &b, 0, 0},
+	} {
+		var witness g1Equal
+		witness.A.Assign(&a)
+		witness.B.Assign(tc.b)
+		witness.IsEqual = tc.isEqual
+		witness.IsNeg = tc.isNeg
+		assert.SolvingSucceeded(&g1Equal{}, &witness, test.WithCurves(ecc.BW6_761))
+
+		// wrong predicates
+		witness.IsEqual = 1 - tc.isEqual
+		assert.SolvingFailed(&g1Equal{}, &witness, test.WithCurves(ecc.BW6_761))
+	}
+
+}
+
+type g1PointsEqual struct {
+	A, B [3]G1Affine
+}
+
+func (circuit *g1PointsEqual) Define(api frontend.API) error {
+	AssertPointsEqual(api, circuit.A[:], circuit.B[:])
+	return nil
+}
+
+func TestAssertPointsEqualG1(t *testing.T) {
+	var witness g1PointsEqual
+	var points [3]bls12377.G1Affine
+	for i := range points {
+		p := randomPointG1()
+		points[i].FromJacobian(&p)
+		witness.A[i].Assign(&points[i])
+		witness.B[i].Assign(&points[i])
+	}
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&g1PointsEqual{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	// one pair differs
+	var other bls12377.G1Affine
+	other.Neg(&points[1])
+	witness.B[1].Assign(&other)
+	assert.SolvingFailed(&g1PointsEqual{}, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 // -------------------------------------------------------------------------------------------------
 // Scalar multiplication
 
@@ -369,6 +444,34 @@ func TestScalarMulG1(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type g1IsOnCurve struct {
+	A G1Affine
+}
+
+func (circuit *g1IsOnCurve) Define(api frontend.API) error {
+	circuit.A.AssertIsOnCurve(api)
+	return nil
+}
+
+func TestIsOnCurveG1(t *testing.T) {
+	var circuit, witness g1IsOnCurve
+	assert := test.NewAssert(t)
+
+	aJac := randomPointG1()
+	var a bls12377.G1Affine
+	a.FromJacobian(&aJac)
+	witness.A.Assign(&a)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// moving Y off the curve
+	a.Y.Double(&a.Y)
+	if a.IsOnCurve() {
+		t.Fatal("expected the point to be off the curve")
+	}
+	witness.A.Assign(&a)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 func randomPointG1() bls12377.G1Jac {
 
 	p1, _, _, _ := bls12377.Generators()
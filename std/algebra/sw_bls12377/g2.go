@@ -17,6 +17,7 @@ limitations under the License.
 package sw_bls12377
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -131,6 +132,13 @@ func (p *G2Jac) AddAssign(api frontend.API, p1 *G2Jac) *G2Jac {
 	return p
 }
 
+// SubAssign subtracts p1 from p in Jacobian coordinates and returns p
+func (p *G2Jac) SubAssign(api frontend.API, p1 *G2Jac) *G2Jac {
+	var neg G2Jac
+	neg.Neg(api, *p1)
+	return p.AddAssign(api, &neg)
+}
+
 // Double doubles a point in jacobian coords
 func (p *G2Jac) Double(api frontend.API, p1 G2Jac) *G2Jac {
 
@@ -408,6 +416,182 @@ func (P *G2Affine) constScalarMul(api frontend.API, Q G2Affine, s *big.Int) *G2A
 	return P
 }
 
+// DecomposeScalarNAF computes the non-adjacent form of inputs[0] in res, least
+// significant digit first. The digits are in {-1, 0, 1}, -1 being encoded as
+// scalarField-1. inputs[0] must be in [0, r) where r is the order of G2.
+var DecomposeScalarNAF = func(scalarField *big.Int, inputs []*big.Int, res []*big.Int) error {
+	cc := getInnerCurveConfig(scalarField)
+	if inputs[0].Sign() < 0 || inputs[0].Cmp(cc.fr) >= 0 {
+		return errors.New("scalar out of range")
+	}
+	s := new(big.Int).Set(inputs[0])
+	for i := range res {
+		switch s.Bit(0) {
+		case 0:
+			res[i].SetUint64(0)
+		default:
+			// zᵢ = 2 - (s mod 4), ±1
+			if s.Bit(1) == 0 {
+				res[i].SetUint64(1)
+				s.Sub(s, big.NewInt(1))
+			} else {
+				res[i].Sub(scalarField, big.NewInt(1))
+				s.Add(s, big.NewInt(1))
+			}
+		}
+		s.Rsh(s, 1)
+	}
+	if s.Sign() != 0 {
+		return errors.New("not enough digits")
+	}
+	return nil
+}
+
+func init() {
+	hint.Register(DecomposeScalarNAF)
+}
+
+// ScalarMulNAF sets p = [s]p1 and returns p. It uses a double-and-add over the
+// non-adjacent form of s, computed by the hint DecomposeScalarNAF, so that on
+// average only a third of the digits are non zero.
+//
+// s must be in [0, r) where r is the order of G2, otherwise the circuit is not
+// satisfiable. As the non zero digits are selected in circuit, the number of
+// constraints doesn't depend on s; ScalarMul, which uses the GLV endomorphism,
+// is cheaper. The accumulator is offset by a fixed point to avoid the point at
+// infinity; the exceptional cases of the incomplete addition formulas are ignored.
+func (p *G2Affine) ScalarMulNAF(api frontend.API, p1 G2Affine, s frontend.Variable) *G2Affine {
+	cc := getInnerCurveConfig(api.Compiler().Field())
+	nbDigits := cc.fr.BitLen() + 1
+
+	digits, err := api.Compiler().NewHint(DecomposeScalarNAF, nbDigits, s)
+	if err != nil {
+		// err is non-nil only for invalid number of inputs
+		panic(err)
+	}
+
+	// offset the accumulator by G so that it is never the point at infinity, and
+	// subtract [2ⁿ]G at the end
+	_, _, _, g := bls12377.Generators()
+	var offset bls12377.G2Affine
+	offset.ScalarMultiplication(&g, new(big.Int).Lsh(big.NewInt(1), uint(nbDigits)))
+	offset.Neg(&offset)
+	var acc, negOffset G2Affine
+	acc.Assign(&g)
+	negOffset.Assign(&offset)
+
+	var negP1 G2Affine
+	negP1.Neg(api, p1)
+
+	var reconstructed frontend.Variable = 0
+	for i := nbDigits - 1; i >= 0; i-- {
+		// d ∈ {-1, 0, 1} ⇔ d³ == d
+		d := digits[i]
+		isNonZero := api.Mul(d, d)
+		api.AssertIsEqual(api.Mul(isNonZero, d), d)
+		isNeg := api.Div(api.Sub(isNonZero, d), 2)
+		reconstructed = api.Add(api.Mul(reconstructed, 2), d)
+
+		var doubled, added, b G2Affine
+		doubled.Double(api, acc)
+		b.X = p1.X
+		b.Y.Select(api, isNeg, negP1.Y, p1.Y)
+		added = doubled
+		added.AddAssign(api, b)
+		acc.Select(api, isNonZero, added, doubled)
+	}
+	api.AssertIsEqual(reconstructed, s)
+
+	acc.AddAssign(api, negOffset)
+	p.X, p.Y = acc.X, acc.Y
+
+	return p
+}
+
+// mulByX sets p = [x₀]p1 where x₀ = 9586122913090633729 is the seed of
+// BLS12-377, and returns p. Unlike ScalarMul it doesn't use the GLV
+// endomorphism, so p1 doesn't need to be in the prime order subgroup.
+func (p *G2Affine) mulByX(api frontend.API, p1 G2Affine) *G2Affine {
+
+	// x₀ = 0x8508c00000000001, same addition chain as E12.Expt
+	res := p1
+	for i := 0; i < 4; i++ {
+		res.Double(api, res)
+	}
+	res.DoubleAndAdd(api, &res, &p1)
+	x33 := res
+	for i := 0; i < 6; i++ {
+		res.Double(api, res)
+	}
+	res.DoubleAndAdd(api, &res, &x33)
+	for i := 0; i < 3; i++ {
+		res.Double(api, res)
+	}
+	res.DoubleAndAdd(api, &res, &p1)
+	res.DoubleAndAdd(api, &res, &p1)
+	for i := 0; i < 45; i++ {
+		res.Double(api, res)
+	}
+	res.DoubleAndAdd(api, &res, &p1)
+
+	*p = res
+
+	return p
+}
+
+// Psi sets p to ψ(p1) and returns p, where ψ = φ o π o φ⁻¹ is the
+// untwist-Frobenius-twist endomorphism and φ:E → E' is the degree 6
+// isomorphism defined over 𝔽p¹². On the r-torsion, ψ acts as the
+// multiplication by p and satisfies ψ² - [t]ψ + [p] = 0 with t = x₀+1 the
+// trace of the Frobenius.
+func (p *G2Affine) Psi(api frontend.API, p1 G2Affine) *G2Affine {
+	u := fields_bls12377.NewE2("80949648264912719408558363140637477264845294720710499478137287262712535938301461879813459410946", 0)
+	v := fields_bls12377.NewE2("216465761340224619389371505802605247630151569547285782856803747159100223055385581585702401816380679166954762214499", 0)
+	p.X.Conjugate(api, p1.X).Mul(api, p.X, u)
+	p.Y.Conjugate(api, p1.Y).Mul(api, p.Y, v)
+	return p
+}
+
+// ClearCofactor maps a point p1 on the twist into the r-torsion subgroup and
+// assigns the result to p. It uses the endomorphism ψ to compute the
+// multiplication by the effective cofactor with two multiplications by the
+// seed x₀ (https://eprint.iacr.org/2017/419.pdf, 4.1), that is
+//
+//	[x₀²-x₀-1]p1 + ψ([x₀-1]p1) + ψ²([2]p1)
+//
+// The result matches the native bls12377.G2Affine.ClearCofactor. p1 must not
+// be the point at infinity, and the exceptional cases of the incomplete
+// addition formulas are ignored as they happen with negligible probability.
+func (p *G2Affine) ClearCofactor(api frontend.API, p1 G2Affine) *G2Affine {
+	var xg, xxg, negP, res, t G2Affine
+
+	xg.mulByX(api, p1)
+	xxg.mulByX(api, xg)
+	negP.Neg(api, p1)
+
+	// [x₀²-x₀-1]p1
+	t.Neg(api, xg)
+	res = xxg
+	res.AddAssign(api, t)
+	res.AddAssign(api, negP)
+
+	// ψ([x₀-1]p1)
+	t = xg
+	t.AddAssign(api, negP)
+	t.Psi(api, t)
+	res.AddAssign(api, t)
+
+	// ψ²([2]p1) = (ω·x, -y) with ω a third root of unity in 𝔽p
+	t.Double(api, p1)
+	t.X.MulByFp(api, t.X, "80949648264912719408558363140637477264845294720710499478137287262712535938301461879813459410945")
+	t.Y.Neg(api, t.Y)
+	res.AddAssign(api, t)
+
+	*p = res
+
+	return p
+}
+
 // Assign a value to self (witness assignment)
 func (p *G2Jac) Assign(p1 *bls12377.G2Jac) {
 	p.X.Assign(&p1.X)
@@ -422,6 +606,26 @@ func (p *G2Jac) AssertIsEqual(api frontend.API, other G2Jac) {
 	p.Z.AssertIsEqual(api, other.Z)
 }
 
+// IsEqual returns 1 if p and q represent the same point and 0 otherwise. The Jacobian
+// coordinates are cross-multiplied, X₁⋅Z₂² == X₂⋅Z₁² and Y₁⋅Z₂³ == Y₂⋅Z₁³, so that different
+// scalings of the same point are equal without converting them to affine. Two points at
+// infinity (Z == 0) are equal.
+func (p *G2Jac) IsEqual(api frontend.API, q G2Jac) frontend.Variable {
+	var pZZ, qZZ, pZZZ, qZZZ, dx, dy, t fields_bls12377.E2
+	pZZ.Square(api, p.Z)
+	qZZ.Square(api, q.Z)
+	pZZZ.Mul(api, pZZ, p.Z)
+	qZZZ.Mul(api, qZZ, q.Z)
+
+	dx.Mul(api, p.X, qZZ)
+	t.Mul(api, q.X, pZZ)
+	dx.Sub(api, dx, t)
+	dy.Mul(api, p.Y, qZZZ)
+	t.Mul(api, q.Y, pZZZ)
+	dy.Sub(api, dy, t)
+	return allZero(api, dx.A0, dx.A1, dy.A0, dy.A1)
+}
+
 // Assign a value to self (witness assignment)
 func (p *G2Affine) Assign(p1 *bls12377.G2Affine) {
 	p.X.Assign(&p1.X)
@@ -434,6 +638,33 @@ func (p *G2Affine) AssertIsEqual(api frontend.API, other G2Affine) {
 	p.Y.AssertIsEqual(api, other.Y)
 }
 
+// AssertPointsEqualG2 constrains a[i] to be equal to b[i] for all i. It panics if a
+// and b don't have the same length.
+func AssertPointsEqualG2(api frontend.API, a, b []G2Affine) {
+	if len(a) != len(b) {
+		panic("number of points don't match")
+	}
+	for i := range a {
+		a[i].AssertIsEqual(api, b[i])
+	}
+}
+
+// Equal returns 1 if p == q and 0 otherwise
+func (p *G2Affine) Equal(api frontend.API, q G2Affine) frontend.Variable {
+	var dx, dy fields_bls12377.E2
+	dx.Sub(api, p.X, q.X)
+	dy.Sub(api, p.Y, q.Y)
+	return allZero(api, dx.A0, dx.A1, dy.A0, dy.A1)
+}
+
+// EqualNeg returns 1 if p == -q and 0 otherwise
+func (p *G2Affine) EqualNeg(api frontend.API, q G2Affine) frontend.Variable {
+	var dx, sy fields_bls12377.E2
+	dx.Sub(api, p.X, q.X)
+	sy.Add(api, p.Y, q.Y)
+	return allZero(api, dx.A0, dx.A1, sy.A0, sy.A1)
+}
+
 // DoubleAndAdd computes 2*p1+p2 in affine coords
 func (p *G2Affine) DoubleAndAdd(api frontend.API, p1, p2 *G2Affine) *G2Affine {
 
@@ -471,3 +702,41 @@ func (p *G2Affine) DoubleAndAdd(api frontend.API, p1, p2 *G2Affine) *G2Affine {
 
 	return p
 }
+
+// DecompressG2 returns the point of the twist whose affine X coordinate is xCompressed
+// and whose Y coordinate is the lexicographically largest of the two candidates if
+// ySign == 1, the smallest if ySign == 0, as in the compressed encoding of gnark-crypto.
+//
+// y is given by a hint (square root in E2) and is constrained by the curve equation
+// y² = x³ + b'; the circuit can't be satisfied if xCompressed isn't the X coordinate of
+// a point of the twist. The point isn't checked to be in the G2 subgroup.
+func DecompressG2(api frontend.API, xCompressed fields_bls12377.E2, ySign frontend.Variable) (G2Affine, error) {
+	p := ecc.BLS12_377.BaseField()
+	if api.Compiler().Field().Cmp(p) != 0 {
+		return G2Affine{}, errors.New("DecompressG2 requires the BLS12-377 base field as native field")
+	}
+	api.AssertIsBoolean(ySign)
+
+	// b' = 1/u = -u/5
+	var bTwist big.Int
+	bTwist.ModInverse(big.NewInt(-5), p)
+
+	var rhs, y, yNeg fields_bls12377.E2
+	rhs.Square(api, xCompressed).Mul(api, rhs, xCompressed)
+	rhs.A1 = api.Add(rhs.A1, &bTwist)
+	y.Sqrt(api, rhs)
+
+	// y must be the smallest root, that is, its first non-zero limb starting from A1 is
+	// at most (p-1)/2; the bound being < p, the decomposition of the limb is canonical
+	var half big.Int
+	half.Rsh(p, 1)
+	api.AssertIsLessOrEqual(api.Select(api.IsZero(y.A1), y.A0, y.A1), &half)
+
+	yNeg.Neg(api, y)
+
+	var res G2Affine
+	res.X = xCompressed
+	res.Y.Select(api, ySign, yNeg, y)
+
+	return res, nil
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/fields_bls12377"
 	"github.com/consensys/gnark/test"
 
 	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
@@ -257,6 +258,212 @@ func TestNegG2(t *testing.T) {
 
 }
 
+type g2NegJacAffine struct {
+	A G2Jac
+	C G2Affine `gnark:",public"`
+}
+
+func (circuit *g2NegJacAffine) Define(api frontend.API) error {
+	var negJac G2Jac
+	negJac.Neg(api, circuit.A)
+	var fromJac, negAffine G2Affine
+	fromJac.FromJac(api, negJac)
+	negAffine.FromJac(api, circuit.A)
+	negAffine.Neg(api, negAffine)
+	fromJac.AssertIsEqual(api, negAffine)
+	fromJac.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestNegJacAffineG2(t *testing.T) {
+
+	a := randomPointG2()
+
+	var circuit, witness g2NegJacAffine
+	witness.A.Assign(&a)
+
+	var c bls12377.G2Affine
+	c.FromJacobian(&a)
+	c.Neg(&c)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+}
+
+// -------------------------------------------------------------------------------------------------
+// Equality predicates
+
+type g2Equal struct {
+	A, B           G2Affine
+	IsEqual, IsNeg frontend.Variable `gnark:",public"`
+}
+
+func (circuit *g2Equal) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.A.Equal(api, circuit.B), circuit.IsEqual)
+	api.AssertIsEqual(circuit.A.EqualNeg(api, circuit.B), circuit.IsNeg)
+	return nil
+}
+
+func TestEqualG2(t *testing.T) {
+
+	// sample 2 random points
+	_a, _b := randomPointG2(), randomPointG2()
+	var a, b, negA bls12377.G2Affine
+	a.FromJacobian(&_a)
+	b.FromJacobian(&_b)
+	negA.Neg(&a)
+
+	assert := test.NewAssert(t)
+	for _, tc := range []struct {
+		name           string
+		b              *bls12377.G2Affine
+		isEqual, isNeg int
+	}{
+		{"equal", &a, 1, 0},
+		{"negation", &negA, 0, 1},
+		{"distinct", &b, 0, 0},
+	} {
+		var witness g2Equal
+		witness.A.Assign(&a)
+		witness.B.Assign(tc.b)
+		witness.IsEqual = tc.isEqual
+		witness.IsNeg = tc.isNeg
+		assert.SolvingSucceeded(&g2Equal{}, &witness, test.WithCurves(ecc.BW6_761))
+
+		// wrong predicates
+		witness.IsEqual = 1 - tc.isEqual
+		assert.SolvingFailed(&g2Equal{}, &witness, test.WithCurves(ecc.BW6_761))
+	}
+
+}
+
+type g2IsEqualJac struct {
+	A, B    G2Jac
+	IsEqual frontend.Variable `gnark:",public"`
+}
+
+func (circuit *g2IsEqualJac) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.A.IsEqual(api, circuit.B), circuit.IsEqual)
+	return nil
+}
+
+func TestIsEqualJacG2(t *testing.T) {
+
+	a, b := randomPointG2(), randomPointG2()
+
+	// (λ²X, λ³Y, λZ) represents the same point as (X, Y, Z)
+	var lambda, lambda2, lambda3 bls12377.E2
+	_, _ = lambda.SetRandom()
+	lambda2.Square(&lambda)
+	lambda3.Mul(&lambda2, &lambda)
+	scaled := a
+	scaled.X.Mul(&scaled.X, &lambda2)
+	scaled.Y.Mul(&scaled.Y, &lambda3)
+	scaled.Z.Mul(&scaled.Z, &lambda)
+
+	var negA, infinity, scaledInfinity bls12377.G2Jac
+	negA.Neg(&a)
+	infinity.X.SetOne()
+	infinity.Y.SetOne()
+	scaledInfinity.X.Set(&lambda2)
+	scaledInfinity.Y.Set(&lambda3)
+
+	assert := test.NewAssert(t)
+	for _, tc := range []struct {
+		name    string
+		a, b    *bls12377.G2Jac
+		isEqual int
+	}{
+		{"same", &a, &a, 1},
+		{"scaled", &a, &scaled, 1},
+		{"negation", &a, &negA, 0},
+		{"distinct", &a, &b, 0},
+		{"infinity", &infinity, &scaledInfinity, 1},
+		{"finite and infinity", &a, &infinity, 0},
+	} {
+		var witness g2IsEqualJac
+		witness.A.Assign(tc.a)
+		witness.B.Assign(tc.b)
+		witness.IsEqual = tc.isEqual
+		assert.SolvingSucceeded(&g2IsEqualJac{}, &witness, test.WithCurves(ecc.BW6_761))
+
+		witness.IsEqual = 1 - tc.isEqual
+		assert.SolvingFailed(&g2IsEqualJac{}, &witness, test.WithCurves(ecc.BW6_761))
+	}
+
+}
+
+type g2PointsEqual struct {
+	A, B [3]G2Affine
+}
+
+func (circuit *g2PointsEqual) Define(api frontend.API) error {
+	AssertPointsEqualG2(api, circuit.A[:], circuit.B[:])
+	return nil
+}
+
+func TestAssertPointsEqualG2(t *testing.T) {
+	var witness g2PointsEqual
+	var points [3]bls12377.G2Affine
+	for i := range points {
+		p := randomPointG2()
+		points[i].FromJacobian(&p)
+		witness.A[i].Assign(&points[i])
+		witness.B[i].Assign(&points[i])
+	}
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&g2PointsEqual{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	// one pair differs
+	var other bls12377.G2Affine
+	other.Neg(&points[1])
+	witness.B[1].Assign(&other)
+	assert.SolvingFailed(&g2PointsEqual{}, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+// -------------------------------------------------------------------------------------------------
+// Sub jacobian
+
+type g2SubAssign struct {
+	A, B G2Jac
+	C    G2Affine `gnark:",public"`
+}
+
+func (circuit *g2SubAssign) Define(api frontend.API) error {
+	expected := circuit.A
+	expected.SubAssign(api, &circuit.B)
+	var expectedAffine G2Affine
+	expectedAffine.FromJac(api, expected)
+	expectedAffine.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestSubAssignG2(t *testing.T) {
+
+	// sample 2 random points
+	a := randomPointG2()
+	b := randomPointG2()
+
+	// create the cs
+	var circuit, witness g2SubAssign
+
+	// assign the inputs
+	witness.A.Assign(&a)
+	witness.B.Assign(&b)
+
+	// compute the result
+	a.SubAssign(&b)
+	var c bls12377.G2Affine
+	c.FromJacobian(&a)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+}
+
 // -------------------------------------------------------------------------------------------------
 // Scalar multiplication
 
@@ -335,6 +542,48 @@ func TestVarScalarMulG2(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type g2ScalarMulNAF struct {
+	A G2Affine
+	C G2Affine `gnark:",public"`
+	R frontend.Variable
+}
+
+func (circuit *g2ScalarMulNAF) Define(api frontend.API) error {
+	var expected, expected2 G2Affine
+	expected.ScalarMulNAF(api, circuit.A, circuit.R)
+	expected.AssertIsEqual(api, circuit.C)
+	expected2.ScalarMul(api, circuit.A, circuit.R)
+	expected2.AssertIsEqual(api, expected)
+	return nil
+}
+
+func TestScalarMulNAFG2(t *testing.T) {
+	// sample random point
+	_a := randomPointG2()
+	var a, c bls12377.G2Affine
+	a.FromJacobian(&_a)
+
+	// create the cs
+	var circuit, witness g2ScalarMulNAF
+	var r fr.Element
+	_, _ = r.SetRandom()
+	witness.R = r.String()
+	// assign the inputs
+	witness.A.Assign(&a)
+	// compute the result
+	var br big.Int
+	_a.ScalarMultiplication(&_a, r.BigInt(&br))
+	c.FromJacobian(&_a)
+	witness.C.Assign(&c)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// wrong result
+	witness.C.Assign(&a)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 type g2ScalarMul struct {
 	A    G2Affine
 	C    G2Affine `gnark:",public"`
@@ -374,6 +623,147 @@ func TestScalarMulG2(t *testing.T) {
 	assert := test.NewAssert(t)
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
+
+// -------------------------------------------------------------------------------------------------
+// Clear cofactor
+
+type g2ClearCofactor struct {
+	A G2Affine
+	C G2Affine `gnark:",public"`
+}
+
+func (circuit *g2ClearCofactor) Define(api frontend.API) error {
+	var expected G2Affine
+	expected.ClearCofactor(api, circuit.A)
+	expected.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func TestClearCofactorG2(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for i := 0; i < 2; i++ {
+		// sample a point on the twist, outside of the r-torsion
+		a := randomPointOnTwist()
+		assert.False(a.IsInSubGroup())
+
+		var c bls12377.G2Affine
+		c.ClearCofactor(&a)
+		assert.True(c.IsOnCurve())
+		assert.True(c.IsInSubGroup())
+
+		var circuit, witness g2ClearCofactor
+		witness.A.Assign(&a)
+		witness.C.Assign(&c)
+
+		assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+		witness.C.Assign(&a)
+		assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+	}
+}
+
+// -------------------------------------------------------------------------------------------------
+// Psi
+
+type g2Psi struct {
+	A G2Affine
+}
+
+func (circuit *g2Psi) Define(api frontend.API) error {
+	// ψ²(P) + [p]P == [t]ψ(P) with t = x₀+1
+	var psiP, psi2P, pP, tPsiP G2Affine
+	psiP.Psi(api, circuit.A)
+	psi2P.Psi(api, psiP)
+	// p is the modulus of the native field and would be reduced to 0, use p mod r
+	pModR := new(big.Int).Mod(ecc.BLS12_377.BaseField(), ecc.BLS12_377.ScalarField())
+	pP.ScalarMul(api, circuit.A, pModR)
+	psi2P.AddAssign(api, pP)
+	tPsiP.ScalarMul(api, psiP, new(big.Int).SetUint64(ateLoop+1))
+	psi2P.AssertIsEqual(api, tPsiP)
+	return nil
+}
+
+func TestPsiG2(t *testing.T) {
+	a := randomPointG2()
+
+	var circuit, witness g2Psi
+	var aAff bls12377.G2Affine
+	aAff.FromJacobian(&a)
+	witness.A.Assign(&aAff)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+// randomPointOnTwist returns a random point on the twist y² = x³ + 1/u which
+// is not necessarily in the r-torsion.
+func randomPointOnTwist() bls12377.G2Affine {
+	var b, x, y bls12377.E2
+	b.A1.SetOne()
+	b.Inverse(&b)
+	for {
+		_, _ = x.SetRandom()
+		y.Square(&x).Mul(&y, &x).Add(&y, &b)
+		if y.Legendre() == 1 {
+			y.Sqrt(&y)
+			return bls12377.G2Affine{X: x, Y: y}
+		}
+	}
+}
+
+type g2Decompress struct {
+	X     fields_bls12377.E2
+	YSign frontend.Variable
+	R     G2Affine `gnark:",public"`
+}
+
+func (circuit *g2Decompress) Define(api frontend.API) error {
+	p, err := DecompressG2(api, circuit.X, circuit.YSign)
+	if err != nil {
+		return err
+	}
+	p.AssertIsEqual(api, circuit.R)
+	return nil
+}
+
+func TestDecompressG2(t *testing.T) {
+	var circuit g2Decompress
+	assert := test.NewAssert(t)
+
+	// a random point and its negation, so that both signs are covered
+	var aJac bls12377.G2Jac
+	var points [2]bls12377.G2Affine
+	aJac = randomPointG2()
+	points[0].FromJacobian(&aJac)
+	points[1].Neg(&points[0])
+
+	for i := range points {
+		// compress natively; the third-most significant bit of the encoding is set if y
+		// is lexicographically largest
+		compressed := points[i].Bytes()
+		ySign := (compressed[0] >> 5) & 1
+
+		var decompressed bls12377.G2Affine
+		if _, err := decompressed.SetBytes(compressed[:]); err != nil {
+			t.Fatal(err)
+		}
+		if !decompressed.Equal(&points[i]) {
+			t.Fatal("native round trip failed")
+		}
+
+		var witness g2Decompress
+		witness.X.Assign(&points[i].X)
+		witness.YSign = ySign
+		witness.R.Assign(&decompressed)
+		assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+		// the wrong sign gives the negated point
+		witness.YSign = 1 - ySign
+		assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+	}
+}
+
 func randomPointG2() bls12377.G2Jac {
 	_, p2, _, _ := bls12377.Generators()
 
@@ -475,3 +865,128 @@ func BenchmarkVarScalarMulG2(b *testing.B) {
 	b.Log("plonk", ccsBench.GetNbConstraints())
 
 }
+
+type g2ScalarMulNAFBench struct {
+	A G2Affine
+	C G2Affine `gnark:",public"`
+	R frontend.Variable
+}
+
+func (circuit *g2ScalarMulNAFBench) Define(api frontend.API) error {
+	expected := G2Affine{}
+	expected.ScalarMulNAF(api, circuit.A, circuit.R)
+	expected.AssertIsEqual(api, circuit.C)
+	return nil
+}
+
+func BenchmarkScalarMulNAFG2(b *testing.B) {
+	var c g2ScalarMulNAFBench
+	var v g2varScalarMul
+	b.Run("groth16", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ccsBench, _ = frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &c)
+		}
+
+	})
+	b.Log("groth16", ccsBench.GetNbConstraints())
+	ccsBench, _ = frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &v)
+	b.Log("groth16 (GLV)", ccsBench.GetNbConstraints())
+	b.Run("plonk", func(b *testing.B) {
+		var err error
+		for i := 0; i < b.N; i++ {
+			ccsBench, err = frontend.Compile(ecc.BW6_761.ScalarField(), scs.NewBuilder, &c)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+	})
+	b.Log("plonk", ccsBench.GetNbConstraints())
+	ccsBench, _ = frontend.Compile(ecc.BW6_761.ScalarField(), scs.NewBuilder, &v)
+	b.Log("plonk (GLV)", ccsBench.GetNbConstraints())
+}
+
+type g2FixedBaseScalarMul struct {
+	A          G2Affine
+	Scalars    [4]frontend.Variable
+	windowBits int
+}
+
+func (circuit *g2FixedBaseScalarMul) Define(api frontend.API) error {
+	table := PrecomputeG2Base(api, circuit.A, circuit.windowBits)
+	for _, s := range circuit.Scalars {
+		var expected G2Affine
+		expected.ScalarMul(api, circuit.A, s)
+		res := table.ScalarMul(api, s)
+		res.AssertIsEqual(api, expected)
+	}
+	return nil
+}
+
+func TestFixedBaseScalarMulG2(t *testing.T) {
+	_a := randomPointG2()
+	var a bls12377.G2Affine
+	a.FromJacobian(&_a)
+
+	for _, windowBits := range []int{1, 2, 3} {
+		circuit := g2FixedBaseScalarMul{windowBits: windowBits}
+		var witness g2FixedBaseScalarMul
+		witness.A.Assign(&a)
+		for i := range witness.Scalars {
+			var r fr.Element
+			_, _ = r.SetRandom()
+			witness.Scalars[i] = r.String()
+		}
+		witness.Scalars[2] = 3
+		witness.Scalars[3] = 2
+
+		// the test engine evaluates 0/0 as 0, so that it can't catch the doubling
+		// exceptions of the incomplete addition: solve the compiled circuit instead.
+		ccs, err := frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := frontend.NewWitness(&witness, ecc.BW6_761.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ccs.IsSolved(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type g2FixedBaseCost struct {
+	A          G2Affine
+	Scalars    [4]frontend.Variable
+	windowBits int
+}
+
+func (circuit *g2FixedBaseCost) Define(api frontend.API) error {
+	if circuit.windowBits == 0 {
+		for _, s := range circuit.Scalars {
+			var res G2Affine
+			res.ScalarMul(api, circuit.A, s)
+		}
+		return nil
+	}
+	table := PrecomputeG2Base(api, circuit.A, circuit.windowBits)
+	for _, s := range circuit.Scalars {
+		table.ScalarMul(api, s)
+	}
+	return nil
+}
+
+func TestFixedBaseScalarMulG2Cost(t *testing.T) {
+	nbConstraints := func(windowBits int) int {
+		ccs, err := frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &g2FixedBaseCost{windowBits: windowBits})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs.GetNbConstraints()
+	}
+	plain, table := nbConstraints(0), nbConstraints(2)
+	if table >= plain {
+		t.Fatalf("4 scalar multiplications with the table: %d constraints, %d with ScalarMul", table, plain)
+	}
+}
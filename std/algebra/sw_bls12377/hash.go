@@ -0,0 +1,41 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sw_bls12377
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// HashPointsMiMC absorbs the coordinates of pts in the MiMC hasher h, in the order
+// p₀.X, p₀.Y, p₁.X, p₁.Y, ...
+//
+// The points are expected to be in affine coordinates; the infinity point (0,0) is
+// absorbed as is.
+func HashPointsMiMC(api frontend.API, h *mimc.MiMC, pts ...G1Affine) {
+	for i := range pts {
+		h.Write(pts[i].X, pts[i].Y)
+	}
+}
+
+// HashPointsMiMCG2 absorbs the coordinates of pts in the MiMC hasher h, in the order
+// p₀.X.A0, p₀.X.A1, p₀.Y.A0, p₀.Y.A1, p₁.X.A0, ...
+func HashPointsMiMCG2(api frontend.API, h *mimc.MiMC, pts ...G2Affine) {
+	for i := range pts {
+		h.Write(pts[i].X.A0, pts[i].X.A1, pts[i].Y.A0, pts[i].Y.A1)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sw_bls12377
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
+	"github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	gmimc "github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
+)
+
+type hashPointsMiMC struct {
+	G1       [2]G1Affine
+	G2       [2]G2Affine
+	Expected frontend.Variable `gnark:",public"`
+}
+
+func (circuit *hashPointsMiMC) Define(api frontend.API) error {
+	h, err := gmimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	HashPointsMiMC(api, &h, circuit.G1[:]...)
+	HashPointsMiMCG2(api, &h, circuit.G2[:]...)
+	api.AssertIsEqual(h.Sum(), circuit.Expected)
+	return nil
+}
+
+func TestHashPointsMiMC(t *testing.T) {
+	var witness hashPointsMiMC
+
+	// native transcript, with the coordinates absorbed in the same order
+	native := mimc.NewMiMC()
+	write := func(e *fp.Element) {
+		b := e.Bytes()
+		native.Write(b[:])
+	}
+	for i := range witness.G1 {
+		_p := randomPointG1()
+		var p bls12377.G1Affine
+		p.FromJacobian(&_p)
+		witness.G1[i].Assign(&p)
+		write(&p.X)
+		write(&p.Y)
+	}
+	for i := range witness.G2 {
+		_p := randomPointG2()
+		var p bls12377.G2Affine
+		p.FromJacobian(&_p)
+		witness.G2[i].Assign(&p)
+		write(&p.X.A0)
+		write(&p.X.A1)
+		write(&p.Y.A0)
+		write(&p.Y.A1)
+	}
+	witness.Expected = native.Sum(nil)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&hashPointsMiMC{}, &witness, test.WithCurves(ecc.BW6_761))
+
+	// swapping two points changes the transcript
+	witness.G1[0], witness.G1[1] = witness.G1[1], witness.G1[0]
+	assert.SolvingFailed(&hashPointsMiMC{}, &witness, test.WithCurves(ecc.BW6_761))
+}
@@ -0,0 +1,197 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sw_bls12377
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+)
+
+// MSM sets p = ∑ [scalars[i]] points[i] and returns p. It computes the scalar
+// multiplications independently with ScalarMul, which is the cheapest for a few
+// points; see MSMLarge for many points.
+func (p *G1Affine) MSM(api frontend.API, points []G1Affine, scalars []frontend.Variable) *G1Affine {
+	if len(points) == 0 || len(points) != len(scalars) {
+		panic("invalid number of points or scalars")
+	}
+	var res G1Affine
+	res.ScalarMul(api, points[0], scalars[0])
+	for i := 1; i < len(points); i++ {
+		var q G1Affine
+		q.ScalarMul(api, points[i], scalars[i])
+		res.AddAssign(api, q)
+	}
+	p.X, p.Y = res.X, res.Y
+	return p
+}
+
+// MSMLarge sets p = ∑ [scalars[i]] points[i] and returns p. The scalars are cut in
+// windows of windowBits bits, and the doublings are shared between all the points:
+// for each window, the accumulator is doubled windowBits times, then the multiple of
+// each point selected by its window digit is added, from a table of 2^windowBits
+// multiples built once per point. The scalars must be in [0, r) where r is the order
+// of G1.
+//
+// As circuits can't index memory, this is a Straus-like windowed method rather than
+// the bucket method of native implementations; the bucket accumulation would cost a
+// conditional addition per bucket and per point. Per point, the cost is 2^windowBits
+// additions for the table, and per window a lookup of 2^windowBits-1 selections per
+// coordinate and an addition, while the doublings cost about a thousand constraints
+// whatever the number of points. In R1CS, windowBits = 2 is the cheapest, and then
+// MSMLarge needs fewer constraints than MSM from 9 points on (13912 against 13940,
+// and 92770 against 99135 for 64 points); see BenchmarkMSMG1.
+//
+// The table of each point is offset by a fixed point hashed to the curve, so that the
+// entries and the accumulator are never the point at infinity, and the sum of the
+// shifted offsets is subtracted at the end. The remaining exceptional cases of the
+// incomplete addition formulas happen with negligible probability and are ignored.
+func (p *G1Affine) MSMLarge(api frontend.API, points []G1Affine, scalars []frontend.Variable, windowBits int) *G1Affine {
+	if len(points) == 0 || len(points) != len(scalars) {
+		panic("invalid number of points or scalars")
+	}
+	if windowBits < 1 {
+		panic("window size must be positive")
+	}
+	cc := getInnerCurveConfig(api.Compiler().Field())
+	nbBits := cc.fr.BitLen()
+	nbWindows := (nbBits + windowBits - 1) / windowBits
+
+	// offsets of unknown discrete logarithms between each other, so that no
+	// partial sum of them vanishes or doubles
+	offsets := make([]bls12377.G1Affine, len(points))
+	var offsetSum bls12377.G1Jac
+	for i := range offsets {
+		var err error
+		offsets[i], err = bls12377.HashToG1([]byte(strconv.Itoa(i)), []byte("gnark sw_bls12377 MSM offset"))
+		if err != nil {
+			panic(err)
+		}
+		offsetSum.AddMixed(&offsets[i])
+	}
+
+	// tables[i][d] = [d]points[i] + offsets[i]
+	tables := make([][]G1Affine, len(points))
+	for i := range points {
+		tables[i] = make([]G1Affine, 1<<windowBits)
+		tables[i][0].Assign(&offsets[i])
+		for d := 1; d < len(tables[i]); d++ {
+			tables[i][d] = tables[i][d-1]
+			tables[i][d].AddAssign(api, points[i])
+		}
+	}
+
+	bits := make([][]frontend.Variable, len(scalars))
+	for i := range scalars {
+		bits[i] = api.ToBinary(scalars[i], nbBits)
+	}
+
+	var acc G1Affine
+	nbAdds := 0
+	for w := nbWindows - 1; w >= 0; w-- {
+		lo, hi := w*windowBits, (w+1)*windowBits
+		if hi > nbBits {
+			hi = nbBits
+		}
+		for i := range points {
+			t := lookupG1(api, tables[i], bits[i][lo:hi])
+			if w == nbWindows-1 && i == 0 {
+				acc = t
+			} else {
+				acc.AddAssign(api, t)
+			}
+			if nbAdds++; nbAdds%msmCompactPeriod == 0 {
+				acc = compactG1(api, acc)
+			}
+		}
+		if w != 0 {
+			for j := 0; j < windowBits; j++ {
+				acc.Double(api, acc)
+			}
+		}
+	}
+
+	// each window added the sum of the offsets, then got doubled windowBits
+	// times per following window
+	var total, windowShift big.Int
+	windowShift.Lsh(big.NewInt(1), uint(windowBits))
+	for w := 0; w < nbWindows; w++ {
+		total.Mul(&total, &windowShift).Add(&total, big.NewInt(1))
+	}
+	total.Mod(&total, cc.fr)
+	offsetSum.ScalarMultiplication(&offsetSum, &total)
+	offsetSum.Neg(&offsetSum)
+	var offset bls12377.G1Affine
+	offset.FromJacobian(&offsetSum)
+	var negOffset G1Affine
+	negOffset.Assign(&offset)
+	acc.AddAssign(api, negOffset)
+
+	p.X, p.Y = acc.X, acc.Y
+	return p
+}
+
+// msmCompactPeriod is the number of additions to the accumulator of MSMLarge
+// between two calls to compactG1.
+const msmCompactPeriod = 8
+
+// CopyHint returns its inputs.
+var CopyHint = func(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != len(outputs) {
+		return errors.New("number of inputs and outputs don't match")
+	}
+	for i := range inputs {
+		outputs[i].Set(inputs[i])
+	}
+	return nil
+}
+
+func init() {
+	hint.Register(CopyHint)
+}
+
+// compactG1 returns a copy of p in new variables. The coordinates computed by the
+// incomplete addition formulas are linear expressions growing with the length of the
+// chain of additions, which for a long chain in R1CS makes the compilation quadratic;
+// compactG1 costs two constraints.
+func compactG1(api frontend.API, p G1Affine) G1Affine {
+	res, err := api.Compiler().NewHint(CopyHint, 2, p.X, p.Y)
+	if err != nil {
+		panic(err)
+	}
+	api.AssertIsEqual(res[0], p.X)
+	api.AssertIsEqual(res[1], p.Y)
+	return G1Affine{X: res[0], Y: res[1]}
+}
+
+// lookupG1 returns table[d] where d is the integer of little-endian bits; table must
+// have at least 2^len(bits) entries.
+func lookupG1(api frontend.API, table []G1Affine, bits []frontend.Variable) G1Affine {
+	if len(bits) == 0 {
+		return table[0]
+	}
+	half := 1 << (len(bits) - 1)
+	lo := lookupG1(api, table[:half], bits[:len(bits)-1])
+	hi := lookupG1(api, table[half:2*half], bits[:len(bits)-1])
+	var res G1Affine
+	res.Select(api, bits[len(bits)-1], hi, lo)
+	return res
+}
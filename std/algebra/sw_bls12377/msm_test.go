@@ -0,0 +1,150 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sw_bls12377
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+type g1MSM struct {
+	Points  []G1Affine
+	Scalars []frontend.Variable
+	Res     G1Affine
+	large   bool
+}
+
+func (circuit *g1MSM) Define(api frontend.API) error {
+	var res G1Affine
+	if circuit.large {
+		res.MSMLarge(api, circuit.Points, circuit.Scalars, 2)
+	} else {
+		res.MSM(api, circuit.Points, circuit.Scalars)
+	}
+	res.AssertIsEqual(api, circuit.Res)
+	return nil
+}
+
+type g1MSMCompare struct {
+	Points  []G1Affine
+	Scalars []frontend.Variable
+	Res     G1Affine
+}
+
+func (circuit *g1MSMCompare) Define(api frontend.API) error {
+	var simple, large G1Affine
+	simple.MSM(api, circuit.Points, circuit.Scalars)
+	large.MSMLarge(api, circuit.Points, circuit.Scalars, 2)
+	simple.AssertIsEqual(api, circuit.Res)
+	large.AssertIsEqual(api, simple)
+	return nil
+}
+
+func randomMSMG1(n int) ([]bls12377.G1Affine, []fr.Element, bls12377.G1Affine) {
+	points := make([]bls12377.G1Affine, n)
+	scalars := make([]fr.Element, n)
+	for i := range points {
+		p := randomPointG1()
+		points[i].FromJacobian(&p)
+		_, _ = scalars[i].SetRandom()
+	}
+	var res bls12377.G1Affine
+	if _, err := res.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
+		panic(err)
+	}
+	return points, scalars, res
+}
+
+func TestMSMLargeG1(t *testing.T) {
+	const n = 3
+	points, scalars, res := randomMSMG1(n)
+
+	circuit := g1MSM{Points: make([]G1Affine, n), Scalars: make([]frontend.Variable, n), large: true}
+	witness := g1MSM{Points: make([]G1Affine, n), Scalars: make([]frontend.Variable, n)}
+	for i := 0; i < n; i++ {
+		witness.Points[i].Assign(&points[i])
+		witness.Scalars[i] = scalars[i].String()
+	}
+	witness.Res.Assign(&res)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	var wrong bls12377.G1Jac
+	wrong.FromAffine(&res)
+	wrong.AddMixed(&points[0])
+	var wrongAff bls12377.G1Affine
+	wrongAff.FromJacobian(&wrong)
+	witness.Res.Assign(&wrongAff)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
+func TestMSMLargeAgainstMSMG1(t *testing.T) {
+	const n = 64
+	points, scalars, res := randomMSMG1(n)
+
+	circuit := g1MSMCompare{Points: make([]G1Affine, n), Scalars: make([]frontend.Variable, n)}
+	witness := g1MSMCompare{Points: make([]G1Affine, n), Scalars: make([]frontend.Variable, n)}
+	for i := 0; i < n; i++ {
+		witness.Points[i].Assign(&points[i])
+		witness.Scalars[i] = scalars[i].String()
+	}
+	witness.Res.Assign(&res)
+
+	// the test engine evaluates 0/0 as 0, so that it can't catch the doubling
+	// exceptions of the incomplete addition: solve the compiled circuit instead.
+	ccs, err := frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(&witness, ecc.BW6_761.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ccs.IsSolved(w); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkMSMG1(b *testing.B) {
+	for _, n := range []int{4, 8, 16, 64} {
+		for _, large := range []bool{false, true} {
+			c := g1MSM{Points: make([]G1Affine, n), Scalars: make([]frontend.Variable, n), large: large}
+			name := fmt.Sprintf("simple/n=%d", n)
+			if large {
+				name = fmt.Sprintf("large/n=%d", n)
+			}
+			b.Run(name, func(b *testing.B) {
+				var err error
+				for i := 0; i < b.N; i++ {
+					ccsBench, err = frontend.Compile(ecc.BW6_761.ScalarField(), r1cs.NewBuilder, &c)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Log("groth16", name, ccsBench.GetNbConstraints())
+		}
+	}
+}
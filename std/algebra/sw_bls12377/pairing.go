@@ -20,6 +20,10 @@ import (
 	"errors"
 	"math/big"
 
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra/fields_bls12377"
 )
@@ -49,8 +53,7 @@ func MillerLoop(api frontend.API, P []G1Affine, Q []G2Affine) (GT, error) {
 		ateLoopBin[i] = ateLoopBigInt.Bit(i)
 	}
 
-	var res GT
-	res.SetOne()
+	res := fields_bls12377.NewE12One()
 
 	var l1, l2 LineEvaluation
 	Qacc := make([]G2Affine, n)
@@ -114,41 +117,10 @@ func MillerLoop(api frontend.API, P []G1Affine, Q []G2Affine) (GT, error) {
 
 // FinalExponentiation computes the final expo x**(p**6-1)(p**2+1)(p**4 - p**2 +1)/r
 func FinalExponentiation(api frontend.API, e1 GT) GT {
-	const genT = ateLoop
-
-	result := e1
-
 	// https://eprint.iacr.org/2016/130.pdf
-	var t [3]GT
-
-	// easy part
-	t[0].Conjugate(api, result)
-	t[0].DivUnchecked(api, t[0], result)
-	result.FrobeniusSquare(api, t[0]).
-		Mul(api, result, t[0])
-
-	// hard part (up to permutation)
-	// Daiki Hayashida and Kenichiro Hayasaka
-	// and Tadanori Teruya
-	// https://eprint.iacr.org/2020/875.pdf
-	t[0].CyclotomicSquare(api, result)
-	t[1].Expt(api, result, genT)
-	t[2].Conjugate(api, result)
-	t[1].Mul(api, t[1], t[2])
-	t[2].Expt(api, t[1], genT)
-	t[1].Conjugate(api, t[1])
-	t[1].Mul(api, t[1], t[2])
-	t[2].Expt(api, t[1], genT)
-	t[1].Frobenius(api, t[1])
-	t[1].Mul(api, t[1], t[2])
-	result.Mul(api, result, t[0])
-	t[0].Expt(api, t[1], genT)
-	t[2].Expt(api, t[0], genT)
-	t[0].FrobeniusSquare(api, t[1])
-	t[1].Conjugate(api, t[1])
-	t[1].Mul(api, t[1], t[2])
-	t[1].Mul(api, t[1], t[0])
-	result.Mul(api, result, t[1])
+	var result GT
+	result.EasyPart(api, e1)
+	result.HardPart(api, result)
 
 	return result
 }
@@ -162,6 +134,105 @@ func Pair(api frontend.API, P []G1Affine, Q []G2Affine) (GT, error) {
 	return FinalExponentiation(api, f), nil
 }
 
+// PairingCheck asserts that Π e(Pᵢ,Qᵢ) == 1 without computing the hard part
+// of the final exponentiation.
+//
+// Let f be the product of the Miller loops and g = f^((p⁶-1)(p²+1)) its easy
+// part. Then Π e(Pᵢ,Qᵢ) = g^((p⁴-p²+1)/r) and it is 1 iff g is a r-th power
+// in the cyclotomic subgroup. The prover provides c such that c^r == g (hint)
+// and the circuit checks it along with c^(p⁴-p²+1) == 1, which costs 2
+// Frobenius maps instead of an exponentiation.
+func PairingCheck(api frontend.API, P []G1Affine, Q []G2Affine) error {
+	f, err := MillerLoop(api, P, Q)
+	if err != nil {
+		return err
+	}
+
+	var g GT
+	g.EasyPart(api, f)
+
+	res, err := api.NewHint(PairingCheckHint, 12, g.C0.B0.A0, g.C0.B0.A1, g.C0.B1.A0, g.C0.B1.A1, g.C0.B2.A0, g.C0.B2.A1, g.C1.B0.A0, g.C1.B0.A1, g.C1.B1.A0, g.C1.B1.A1, g.C1.B2.A0, g.C1.B2.A1)
+	if err != nil {
+		return err
+	}
+	var c GT
+	c.C0.B0.A0, c.C0.B0.A1 = res[0], res[1]
+	c.C0.B1.A0, c.C0.B1.A1 = res[2], res[3]
+	c.C0.B2.A0, c.C0.B2.A1 = res[4], res[5]
+	c.C1.B0.A0, c.C1.B0.A1 = res[6], res[7]
+	c.C1.B1.A0, c.C1.B1.A1 = res[8], res[9]
+	c.C1.B2.A0, c.C1.B2.A1 = res[10], res[11]
+
+	// c is in the cyclotomic subgroup: c^(p⁴) * c == c^(p²)
+	var cp2, cp4 GT
+	cp2.FrobeniusSquare(api, c)
+	cp4.FrobeniusSquare(api, cp2).
+		Mul(api, cp4, c)
+	cp4.AssertIsEqual(api, cp2)
+
+	// c^r == g where r = x⁴-x²+1, cyclotomic inverse being the conjugate
+	var x2, x4 GT
+	x2.Expt(api, c, ateLoop)
+	x2.Expt(api, x2, ateLoop)
+	x4.Expt(api, x2, ateLoop)
+	x4.Expt(api, x4, ateLoop)
+	x2.Conjugate(api, x2)
+	x4.Mul(api, x4, x2).
+		Mul(api, x4, c)
+	x4.AssertIsEqual(api, g)
+
+	return nil
+}
+
+// PairingCheckHint returns c = g^(r⁻¹ mod h) where h = (p⁴-p²+1)/r, that is
+// the r-th root of g whenever g^h == 1.
+var PairingCheckHint = func(_ *big.Int, inputs []*big.Int, res []*big.Int) error {
+	var g, c bls12377.E12
+
+	g.C0.B0.A0.SetBigInt(inputs[0])
+	g.C0.B0.A1.SetBigInt(inputs[1])
+	g.C0.B1.A0.SetBigInt(inputs[2])
+	g.C0.B1.A1.SetBigInt(inputs[3])
+	g.C0.B2.A0.SetBigInt(inputs[4])
+	g.C0.B2.A1.SetBigInt(inputs[5])
+	g.C1.B0.A0.SetBigInt(inputs[6])
+	g.C1.B0.A1.SetBigInt(inputs[7])
+	g.C1.B1.A0.SetBigInt(inputs[8])
+	g.C1.B1.A1.SetBigInt(inputs[9])
+	g.C1.B2.A0.SetBigInt(inputs[10])
+	g.C1.B2.A1.SetBigInt(inputs[11])
+
+	// h = (p⁴-p²+1)/r
+	var p2, h, e big.Int
+	p2.Mul(fp.Modulus(), fp.Modulus())
+	h.Mul(&p2, &p2).Sub(&h, &p2).Add(&h, big.NewInt(1))
+	h.Div(&h, fr.Modulus())
+	if e.ModInverse(fr.Modulus(), &h) == nil {
+		return errors.New("r is not invertible mod (p⁴-p²+1)/r")
+	}
+
+	c.Exp(g, &e)
+
+	c.C0.B0.A0.BigInt(res[0])
+	c.C0.B0.A1.BigInt(res[1])
+	c.C0.B1.A0.BigInt(res[2])
+	c.C0.B1.A1.BigInt(res[3])
+	c.C0.B2.A0.BigInt(res[4])
+	c.C0.B2.A1.BigInt(res[5])
+	c.C1.B0.A0.BigInt(res[6])
+	c.C1.B0.A1.BigInt(res[7])
+	c.C1.B1.A0.BigInt(res[8])
+	c.C1.B1.A1.BigInt(res[9])
+	c.C1.B2.A0.BigInt(res[10])
+	c.C1.B2.A1.BigInt(res[11])
+
+	return nil
+}
+
+func init() {
+	hint.Register(PairingCheckHint)
+}
+
 // DoubleAndAddStep
 func DoubleAndAddStep(api frontend.API, p1, p2 *G2Affine) (G2Affine, LineEvaluation, LineEvaluation) {
 
@@ -127,6 +127,48 @@ func TestTriplePairingBLS377(t *testing.T) {
 }
 
 // utils
+type pairingCheckBLS377 struct {
+	P1, P2 G1Affine `gnark:",public"`
+	Q1, Q2 G2Affine
+}
+
+func (circuit *pairingCheckBLS377) Define(api frontend.API) error {
+	return PairingCheck(api, []G1Affine{circuit.P1, circuit.P2}, []G2Affine{circuit.Q1, circuit.Q2})
+}
+
+func TestPairingCheckBLS377(t *testing.T) {
+
+	// e(aG₁, bG₂) * e(-bG₁, aG₂) == 1
+	_, _, g1, g2 := bls12377.Generators()
+	var a, b fr.Element
+	var _a, _b big.Int
+	_, _ = a.SetRandom()
+	_, _ = b.SetRandom()
+	a.BigInt(&_a)
+	b.BigInt(&_b)
+
+	var P1, P2 bls12377.G1Affine
+	var Q1, Q2 bls12377.G2Affine
+	P1.ScalarMultiplication(&g1, &_a)
+	P2.ScalarMultiplication(&g1, &_b).Neg(&P2)
+	Q1.ScalarMultiplication(&g2, &_b)
+	Q2.ScalarMultiplication(&g2, &_a)
+
+	var circuit, witness pairingCheckBLS377
+	witness.P1.Assign(&P1)
+	witness.P2.Assign(&P2)
+	witness.Q1.Assign(&Q1)
+	witness.Q2.Assign(&Q2)
+
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// tampered product
+	P2.Add(&P2, &g1)
+	witness.P2.Assign(&P2)
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 func pairingData() (P bls12377.G1Affine, Q bls12377.G2Affine, milRes, pairingRes bls12377.GT) {
 	_, _, P, Q = bls12377.Generators()
 	milRes, _ = bls12377.MillerLoop([]bls12377.G1Affine{P}, []bls12377.G2Affine{Q})
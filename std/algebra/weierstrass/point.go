@@ -33,6 +33,7 @@ func New[Base, Scalars emulated.FieldParams](api frontend.API, params CurveParam
 			Y: Gy,
 		},
 		a:    emulated.ValueOf[Base](params.A),
+		b:    emulated.ValueOf[Base](params.B),
 		addA: params.A.Cmp(big.NewInt(0)) != 0,
 	}, nil
 }
@@ -52,6 +53,7 @@ type Curve[Base, Scalars emulated.FieldParams] struct {
 	g AffinePoint[Base]
 
 	a    emulated.Element[Base]
+	b    emulated.Element[Base]
 	addA bool
 }
 
@@ -81,6 +83,26 @@ func (c *Curve[B, S]) AssertIsEqual(p, q *AffinePoint[B]) {
 	c.baseApi.AssertIsEqual(&p.Y, &q.Y)
 }
 
+// AssertIsOnCurve asserts that p satisfies the curve equation Y² = X³ + aX + b.
+func (c *Curve[B, S]) AssertIsOnCurve(p *AffinePoint[B]) {
+	left := c.baseApi.MulMod(&p.Y, &p.Y)
+	right := c.baseApi.MulMod(&p.X, &p.X)
+	if c.addA {
+		right = c.baseApi.Add(right, &c.a)
+	}
+	right = c.baseApi.MulMod(right, &p.X)
+	right = c.baseApi.Add(right, &c.b)
+	c.baseApi.AssertIsEqual(left, right)
+}
+
+// AssertIsInSubGroup asserts that p is in the subgroup of order the scalar field modulus.
+// The curves of this package (see GetCurveParams), and BN254 G1 in particular, have prime
+// order, so the subgroup is the whole group and this only asserts that p is on the curve.
+// It lets generic gadgets, shared with curves with a cofactor, compile uniformly.
+func (c *Curve[B, S]) AssertIsInSubGroup(p *AffinePoint[B]) {
+	c.AssertIsOnCurve(p)
+}
+
 // Add adds q and r and returns it.
 func (c *Curve[B, S]) Add(q, r *AffinePoint[B]) *AffinePoint[B] {
 	// compute lambda = (p1.y-p.y)/(p1.x-p.x)
@@ -195,3 +195,49 @@ func TestScalarMul2(t *testing.T) {
 	_, err = frontend.Compile(testCurve.ScalarField(), r1cs.NewBuilder, &circuit)
 	assert.NoError(err)
 }
+
+type OnCurveTest[T, S emulated.FieldParams] struct {
+	P AffinePoint[T]
+}
+
+func (c *OnCurveTest[T, S]) Define(api frontend.API) error {
+	cr, err := New[T, S](api, GetCurveParams[T]())
+	if err != nil {
+		return err
+	}
+	cr.AssertIsOnCurve(&c.P)
+	return nil
+}
+
+type SubGroupTest[T, S emulated.FieldParams] struct {
+	P AffinePoint[T]
+}
+
+func (c *SubGroupTest[T, S]) Define(api frontend.API) error {
+	cr, err := New[T, S](api, GetCurveParams[T]())
+	if err != nil {
+		return err
+	}
+	cr.AssertIsInSubGroup(&c.P)
+	return nil
+}
+
+func TestAssertIsInSubGroup(t *testing.T) {
+	assert := test.NewAssert(t)
+	_, _, g, _ := bn254.Generators()
+	var p bn254.G1Affine
+	p.ScalarMultiplication(&g, big.NewInt(12345))
+	off := p
+	off.Y.Double(&off.Y)
+
+	for _, q := range []bn254.G1Affine{g, p, off} {
+		pt := AffinePoint[emulated.BN254Fp]{
+			X: emulated.ValueOf[emulated.BN254Fp](q.X),
+			Y: emulated.ValueOf[emulated.BN254Fp](q.Y),
+		}
+		errOnCurve := test.IsSolved(&OnCurveTest[emulated.BN254Fp, emulated.BN254Fr]{}, &OnCurveTest[emulated.BN254Fp, emulated.BN254Fr]{P: pt}, testCurve.ScalarField())
+		errSubGroup := test.IsSolved(&SubGroupTest[emulated.BN254Fp, emulated.BN254Fr]{}, &SubGroupTest[emulated.BN254Fp, emulated.BN254Fr]{P: pt}, testCurve.ScalarField())
+		assert.Equal(q.IsOnCurve(), errOnCurve == nil)
+		assert.Equal(errOnCurve == nil, errSubGroup == nil)
+	}
+}
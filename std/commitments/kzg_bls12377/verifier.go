@@ -18,6 +18,9 @@ limitations under the License.
 package kzg_bls12377
 
 import (
+	"errors"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra/fields_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls12377"
@@ -82,3 +85,65 @@ func Verify(api frontend.API, commitment Digest, proof OpeningProof, point front
 	resPairing.AssertIsEqual(api, one)
 
 }
+
+// BatchVerify verifies at once the openings of several commitments at a single point.
+//
+// proofs is either the folded proof Σγⁱ⋅Hᵢ as computed by gnark-crypto's
+// BatchOpenSinglePoint, or a proof per commitment and evals are the claimed
+// values. The openings are folded using the challenge gamma, which must be
+// derived from the transcript, and checked with a single pairing check:
+//
+//	e(Σγⁱ⋅([fᵢ(α) - fᵢ(a)]G₁) + [a]H, G₂).e(-H, [α]G₂) ==? 1, where H = Σγⁱ⋅Hᵢ
+//
+// The G₁ part of the SRS is assumed to be the canonical BLS12-377 generator.
+func BatchVerify(api frontend.API, commitments []Digest, proofs []sw_bls12377.G1Affine, point frontend.Variable, evals []frontend.Variable, srsG2 [2]sw_bls12377.G2Affine, gamma frontend.Variable) error {
+	nbDigests := len(commitments)
+	if nbDigests == 0 || len(evals) != nbDigests {
+		return errors.New("number of claimed values doesn't match the number of commitments")
+	}
+	if len(proofs) != 1 && len(proofs) != nbDigests {
+		return errors.New("expected a folded proof or one proof per commitment")
+	}
+
+	_, _, g1, _ := bls12377.Generators()
+	var G1 sw_bls12377.G1Affine
+	G1.Assign(&g1)
+
+	// [fᵢ(α) - fᵢ(a)]G₁
+	folded := make([]sw_bls12377.G1Affine, nbDigests)
+	for i := 0; i < nbDigests; i++ {
+		var claimedValueG1Aff sw_bls12377.G1Affine
+		claimedValueG1Aff.ScalarMul(api, G1, evals[i])
+		folded[i].Neg(api, claimedValueG1Aff)
+		folded[i].AddAssign(api, commitments[i])
+	}
+
+	// Σγⁱ⋅([fᵢ(α) - fᵢ(a)]G₁) and Σγⁱ⋅Hᵢ
+	foldedDigest := fold(api, folded, gamma)
+	H := fold(api, proofs, gamma)
+
+	// Σγⁱ⋅([fᵢ(α) - fᵢ(a)]G₁) + [a]H
+	var aH sw_bls12377.G1Affine
+	aH.ScalarMul(api, H, point)
+	foldedDigest.AddAssign(api, aH)
+
+	// [-H(α)]G₁
+	var negH sw_bls12377.G1Affine
+	negH.Neg(api, H)
+
+	return sw_bls12377.PairingCheck(
+		api,
+		[]sw_bls12377.G1Affine{foldedDigest, negH},
+		[]sw_bls12377.G2Affine{srsG2[0], srsG2[1]},
+	)
+}
+
+// fold returns Σγⁱ⋅pᵢ
+func fold(api frontend.API, p []sw_bls12377.G1Affine, gamma frontend.Variable) sw_bls12377.G1Affine {
+	res := p[len(p)-1]
+	for i := len(p) - 2; i >= 0; i-- {
+		res.ScalarMul(api, res, gamma)
+		res.AddAssign(api, p[i])
+	}
+	return res
+}
@@ -18,15 +18,18 @@ package kzg_bls12377
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
+	fiatshamir "github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 	"github.com/consensys/gnark/test"
 )
 
@@ -116,6 +119,83 @@ func TestVerifierDynamic(t *testing.T) {
 
 }
 
+type batchVerifierCircuit struct {
+	SRSG2 [2]sw_bls12377.G2Affine
+	H     sw_bls12377.G1Affine
+	Com   [3]Digest
+	Evals [3]frontend.Variable
+	S     frontend.Variable
+	Gamma frontend.Variable
+}
+
+func (circuit *batchVerifierCircuit) Define(api frontend.API) error {
+	return BatchVerify(api, circuit.Com[:], []sw_bls12377.G1Affine{circuit.H}, circuit.S, circuit.Evals[:], circuit.SRSG2, circuit.Gamma)
+}
+
+func TestBatchVerifier(t *testing.T) {
+
+	assert := test.NewAssert(t)
+
+	const kzgSize = 64
+	const polynomialSize = 40
+
+	// trusted setup
+	alpha, err := rand.Int(rand.Reader, ecc.BLS12_377.ScalarField())
+	assert.NoError(err)
+	srs, err := kzg.NewSRS(kzgSize, alpha)
+	assert.NoError(err)
+
+	// random polynomials and their commitments
+	var witness batchVerifierCircuit
+	polynomials := make([][]fr.Element, len(witness.Com))
+	digests := make([]kzg.Digest, len(witness.Com))
+	for i := range polynomials {
+		polynomials[i] = make([]fr.Element, polynomialSize)
+		for j := range polynomials[i] {
+			polynomials[i][j].SetRandom()
+		}
+		digests[i], err = kzg.Commit(polynomials[i], srs)
+		assert.NoError(err)
+	}
+
+	var point fr.Element
+	point.SetRandom()
+	proof, err := kzg.BatchOpenSinglePoint(polynomials, digests, point, sha256.New(), srs)
+	assert.NoError(err)
+	assert.NoError(kzg.BatchVerifySinglePoint(digests, &proof, point, sha256.New(), srs))
+
+	// the batching challenge, derived as gnark-crypto does
+	fs := fiatshamir.NewTranscript(sha256.New(), "gamma")
+	assert.NoError(fs.Bind("gamma", point.Marshal()))
+	for i := range digests {
+		assert.NoError(fs.Bind("gamma", digests[i].Marshal()))
+	}
+	gammaBytes, err := fs.ComputeChallenge("gamma")
+	assert.NoError(err)
+	var gamma fr.Element
+	gamma.SetBytes(gammaBytes)
+
+	// populate the witness
+	witness.SRSG2[0].Assign(&srs.G2[0])
+	witness.SRSG2[1].Assign(&srs.G2[1])
+	witness.H.Assign(&proof.H)
+	for i := range digests {
+		witness.Com[i].Assign(&digests[i])
+		witness.Evals[i] = proof.ClaimedValues[i].String()
+	}
+	witness.S = point.String()
+	witness.Gamma = gamma.String()
+
+	var circuit batchVerifierCircuit
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// wrong claimed value
+	var wrong fr.Element
+	wrong.SetOne().Add(&wrong, &proof.ClaimedValues[1])
+	witness.Evals[1] = wrong.String()
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 //-------------------------------------------------------
 // harcoded values
 
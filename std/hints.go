@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/std/algebra/fields_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls24315"
 	"github.com/consensys/gnark/std/math/bits"
@@ -31,4 +32,5 @@ func registerHints() {
 	hint.Register(bits.IthBit)
 	hint.Register(bits.NBits)
 	hint.Register(emulated.GetHints()...)
+	hint.Register(fields_bls12377.GetHints()...)
 }
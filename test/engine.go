@@ -501,6 +501,17 @@ func (e *engine) MarkBoolean(v frontend.Variable) {
 	}
 }
 
+// MarkHintWiring is a no-op: the test engine checks all the constraints.
+func (e *engine) MarkHintWiring(v ...frontend.Variable) {}
+
+// RangeCheck falls back to api.ToBinary, which checks that v < 2ⁿᵇᴮⁱᵗˢ.
+func (e *engine) RangeCheck(v frontend.Variable, nbBits int) []frontend.Variable {
+	if nbBits <= 0 {
+		panic("invalid nbBits")
+	}
+	return e.ToBinary(v, nbBits)
+}
+
 func (e *engine) toBigInt(i1 frontend.Variable) *big.Int {
 	switch vv := i1.(type) {
 	case *big.Int: